@@ -0,0 +1,434 @@
+package category
+
+import (
+	"bytes"
+	_ "embed"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"sync"
+
+	"github.com/pkg/errors"
+	"github.com/spf13/viper"
+)
+
+const (
+	// FileName is the name of the user rules file in the LDA directory
+	FileName = "categories.toml"
+	// builtInName is the name used for the embedded rules in error messages
+	builtInName = "built-in"
+)
+
+const (
+	// ModeBatch is a command that does some work and exits, e.g. `go test ./...`
+	ModeBatch = "batch"
+	// ModeInteractive is a command driven by the user through the terminal, e.g. `vim` or `ssh`
+	ModeInteractive = "interactive"
+	// ModeService is a long-running command serving others until it is stopped, e.g. `npm run dev`
+	ModeService = "service"
+)
+
+// modes are the valid modes of the mode rules
+var modes = map[string]bool{
+	ModeBatch:       true,
+	ModeInteractive: true,
+	ModeService:     true,
+}
+
+// Embedding default rules
+//
+//go:embed default.toml
+var defaultRules []byte
+
+// envAssignment matches the `NAME=value` assignments that can prefix a command
+var envAssignment = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*=`)
+
+// Wrapper is a command that runs another command, e.g. `sudo` or `poetry run`
+type Wrapper struct {
+	// Command is one or more words, the first word is matched against the executable name
+	Command string `mapstructure:"command"`
+	// ValueFlags are flags of the wrapper that take a separate value
+	ValueFlags []string `mapstructure:"value_flags"`
+	// Args is the number of positional arguments consumed by the wrapper
+	Args int `mapstructure:"args"`
+}
+
+// Tool defines how many subcommand words are kept for a command
+type Tool struct {
+	// Command is one or more words, the first word is matched against the executable name
+	Command string `mapstructure:"command"`
+	// Depth is the number of subcommand words kept after the command
+	Depth int `mapstructure:"depth"`
+	// ValueFlags are global flags that take a separate value before the subcommand
+	ValueFlags []string `mapstructure:"value_flags"`
+}
+
+// Rule maps commands matching the pattern to a category
+type Rule struct {
+	Category string `mapstructure:"category"`
+	Match    string `mapstructure:"match"`
+}
+
+// ModeRule maps commands matching the pattern to a mode, the pattern is matched against the command with wrappers stripped
+type ModeRule struct {
+	Mode  string `mapstructure:"mode"`
+	Match string `mapstructure:"match"`
+}
+
+// Rules is the content of a rules file
+type Rules struct {
+	// ReplaceDefaults drops the built-in rules instead of extending them
+	ReplaceDefaults bool       `mapstructure:"replace_defaults"`
+	Wrappers        []Wrapper  `mapstructure:"wrappers"`
+	Tools           []Tool     `mapstructure:"tools"`
+	Rules           []Rule     `mapstructure:"rules"`
+	Modes           []ModeRule `mapstructure:"modes"`
+}
+
+// Result is the outcome of categorizing a command
+type Result struct {
+	// Stripped is the command without wrappers and environment assignments
+	Stripped string
+	// Tool is the name of the executable
+	Tool string
+	// Subcommand is the tool with its subcommands, e.g. `git push`
+	Subcommand string
+	// Category is the matched rule category, or the tool when no rule matched
+	Category string
+	// Rule is the pattern of the matched rule, empty when no rule matched
+	Rule string
+	// Mode is the mode of the matched mode rule, empty when no mode rule matched
+	Mode string
+}
+
+type compiledRule struct {
+	category string
+	match    string
+	pattern  *regexp.Regexp
+}
+
+type compiledCommand struct {
+	words      []string
+	valueFlags map[string]bool
+}
+
+// Categorizer categorizes commands using wrapper, tool and category rules
+type Categorizer struct {
+	wrappers []compiledCommand
+	// wrapperArgs is the number of positional arguments of the wrapper with the same index
+	wrapperArgs []int
+	tools       []compiledCommand
+	depths      []int
+	rules       []compiledRule
+	// modes are compiled like the category rules, with the mode as the category
+	modes []compiledRule
+}
+
+var (
+	// defaultCategorizer is built lazily from the built-in rules
+	defaultCategorizer     *Categorizer
+	defaultCategorizerOnce sync.Once
+)
+
+// Default returns the categorizer using only the built-in rules
+func Default() *Categorizer {
+	defaultCategorizerOnce.Do(func() {
+		rules, err := parseRules(bytes.NewReader(defaultRules), builtInName)
+		if err == nil {
+			defaultCategorizer, err = New(rules)
+		}
+		if err != nil {
+			// The built-in rules are embedded and covered by tests, so this is a programming error
+			panic(fmt.Sprintf("invalid built-in categorization rules: %s", err))
+		}
+	})
+
+	return defaultCategorizer
+}
+
+// Load creates a categorizer from the built-in rules extended with the user rules in the LDA directory.
+// A missing user rules file is not an error.
+func Load(ldaDir string) (*Categorizer, error) {
+	rulesPath := filepath.Join(ldaDir, FileName)
+
+	file, err := os.Open(rulesPath)
+	if errors.Is(err, os.ErrNotExist) {
+		return Default(), nil
+	}
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to open %s", rulesPath)
+	}
+	defer file.Close()
+
+	userRules, err := parseRules(file, rulesPath)
+	if err != nil {
+		return nil, err
+	}
+
+	if userRules.ReplaceDefaults {
+		return New(userRules)
+	}
+
+	builtIn, err := parseRules(bytes.NewReader(defaultRules), builtInName)
+	if err != nil {
+		return nil, err
+	}
+
+	// User rules are evaluated first so they can override the built-in ones
+	return New(Rules{
+		Wrappers: append(userRules.Wrappers, builtIn.Wrappers...),
+		Tools:    append(userRules.Tools, builtIn.Tools...),
+		Rules:    append(userRules.Rules, builtIn.Rules...),
+		Modes:    append(userRules.Modes, builtIn.Modes...),
+	})
+}
+
+// parseRules reads the rules in TOML format, unknown keys are rejected so typos don't go unnoticed
+func parseRules(content io.Reader, name string) (Rules, error) {
+	v := viper.New()
+	v.SetConfigType("toml")
+
+	if err := v.ReadConfig(content); err != nil {
+		return Rules{}, errors.Wrapf(err, "failed to read categorization rules %s", name)
+	}
+
+	var rules Rules
+	if err := v.UnmarshalExact(&rules); err != nil {
+		return Rules{}, errors.Wrapf(err, "failed to parse categorization rules %s", name)
+	}
+
+	return rules, nil
+}
+
+// New compiles the rules into a categorizer
+func New(rules Rules) (*Categorizer, error) {
+	categorizer := &Categorizer{}
+
+	for i, wrapper := range rules.Wrappers {
+		words := strings.Fields(wrapper.Command)
+		if len(words) == 0 {
+			return nil, fmt.Errorf("wrapper %d has an empty command", i+1)
+		}
+		categorizer.wrappers = append(categorizer.wrappers, compiledCommand{words: words, valueFlags: toSet(wrapper.ValueFlags)})
+		categorizer.wrapperArgs = append(categorizer.wrapperArgs, wrapper.Args)
+	}
+
+	for i, tool := range rules.Tools {
+		words := strings.Fields(tool.Command)
+		if len(words) == 0 {
+			return nil, fmt.Errorf("tool %d has an empty command", i+1)
+		}
+		categorizer.tools = append(categorizer.tools, compiledCommand{words: words, valueFlags: toSet(tool.ValueFlags)})
+		categorizer.depths = append(categorizer.depths, tool.Depth)
+	}
+
+	for i, rule := range rules.Rules {
+		if rule.Category == "" {
+			return nil, fmt.Errorf("rule %d has an empty category", i+1)
+		}
+		pattern, err := regexp.Compile(rule.Match)
+		if err != nil {
+			return nil, errors.Wrapf(err, "rule %d (%s) has an invalid pattern", i+1, rule.Category)
+		}
+		categorizer.rules = append(categorizer.rules, compiledRule{category: rule.Category, match: rule.Match, pattern: pattern})
+	}
+
+	for i, rule := range rules.Modes {
+		if !modes[rule.Mode] {
+			return nil, fmt.Errorf("mode rule %d has an invalid mode %q, expected %s, %s or %s", i+1, rule.Mode, ModeBatch, ModeInteractive, ModeService)
+		}
+		pattern, err := regexp.Compile(rule.Match)
+		if err != nil {
+			return nil, errors.Wrapf(err, "mode rule %d (%s) has an invalid pattern", i+1, rule.Mode)
+		}
+		categorizer.modes = append(categorizer.modes, compiledRule{category: rule.Mode, match: rule.Match, pattern: pattern})
+	}
+
+	return categorizer, nil
+}
+
+// Categorize strips the wrappers of the command, extracts the tool and subcommand and matches the category rules
+func (c *Categorizer) Categorize(command string) Result {
+	tokens := c.stripWrappers(tokenize(command))
+	if len(tokens) == 0 {
+		command = strings.TrimSpace(command)
+		return Result{Stripped: command, Tool: command, Subcommand: command, Category: command}
+	}
+
+	tool := path.Base(tokens[0])
+	subject := strings.Join(append([]string{tool}, tokens[1:]...), " ")
+
+	result := Result{
+		Stripped:   strings.Join(tokens, " "),
+		Tool:       tool,
+		Subcommand: c.subcommand(tool, tokens[1:]),
+		Category:   tool,
+	}
+
+	for _, rule := range c.rules {
+		if rule.pattern.MatchString(result.Subcommand) || rule.pattern.MatchString(subject) {
+			result.Category = rule.category
+			result.Rule = rule.match
+			break
+		}
+	}
+
+	// Modes depend on the arguments, `node` is a REPL while `node server.js` is not, so only the subject is matched
+	for _, rule := range c.modes {
+		if rule.pattern.MatchString(subject) {
+			result.Mode = rule.category
+			break
+		}
+	}
+
+	return result
+}
+
+// stripWrappers removes environment assignments and wrappers from the beginning of the tokens
+func (c *Categorizer) stripWrappers(tokens []string) []string {
+	for {
+		for len(tokens) > 0 && envAssignment.MatchString(tokens[0]) {
+			tokens = tokens[1:]
+		}
+
+		index := c.match(c.wrappers, tokens)
+		if index == -1 {
+			return tokens
+		}
+
+		wrapper := c.wrappers[index]
+		rest := skipFlags(tokens[len(wrapper.words):], wrapper.valueFlags)
+		for i := 0; i < c.wrapperArgs[index] && len(rest) > 0; i++ {
+			rest = rest[1:]
+		}
+
+		// A wrapper on its own is the command, e.g. `time`
+		if len(rest) == 0 {
+			return tokens
+		}
+
+		tokens = rest
+	}
+}
+
+// subcommand returns the tool followed by the configured number of subcommand words
+func (c *Categorizer) subcommand(tool string, args []string) string {
+	index := c.match(c.tools, append([]string{tool}, args...))
+	if index == -1 {
+		return tool
+	}
+
+	spec := c.tools[index]
+	words := []string{tool}
+	words = append(words, spec.words[1:]...)
+	args = args[len(spec.words)-1:]
+
+	for depth := 0; depth < c.depths[index]; depth++ {
+		args = skipFlags(args, spec.valueFlags)
+		if len(args) == 0 {
+			break
+		}
+		words = append(words, args[0])
+		args = args[1:]
+	}
+
+	return strings.Join(words, " ")
+}
+
+// match returns the index of the longest command matching the beginning of the tokens, or -1
+func (c *Categorizer) match(commands []compiledCommand, tokens []string) int {
+	best := -1
+	for i, command := range commands {
+		if len(command.words) > len(tokens) || path.Base(tokens[0]) != command.words[0] {
+			continue
+		}
+
+		matches := true
+		for j := 1; j < len(command.words); j++ {
+			if tokens[j] != command.words[j] {
+				matches = false
+				break
+			}
+		}
+
+		if matches && (best == -1 || len(command.words) > len(commands[best].words)) {
+			best = i
+		}
+	}
+
+	return best
+}
+
+// skipFlags removes the leading flags and the values of flags that take a separate value
+func skipFlags(tokens []string, valueFlags map[string]bool) []string {
+	for len(tokens) > 0 && strings.HasPrefix(tokens[0], "-") && tokens[0] != "-" {
+		flag := tokens[0]
+		tokens = tokens[1:]
+
+		if flag == "--" {
+			break
+		}
+
+		if valueFlags[flag] && len(tokens) > 0 {
+			tokens = tokens[1:]
+		}
+	}
+
+	return tokens
+}
+
+// tokenize splits the command into words, honoring quotes and backslash escapes
+func tokenize(command string) []string {
+	var tokens []string
+	var current strings.Builder
+	inToken := false
+	var quote rune
+	escaped := false
+
+	for _, r := range command {
+		switch {
+		case escaped:
+			current.WriteRune(r)
+			escaped = false
+		case r == '\\' && quote != '\'':
+			escaped = true
+			inToken = true
+		case quote != 0:
+			if r == quote {
+				quote = 0
+			} else {
+				current.WriteRune(r)
+			}
+		case r == '\'' || r == '"':
+			quote = r
+			inToken = true
+		case r == ' ' || r == '\t' || r == '\n':
+			if inToken {
+				tokens = append(tokens, current.String())
+				current.Reset()
+				inToken = false
+			}
+		default:
+			current.WriteRune(r)
+			inToken = true
+		}
+	}
+
+	if inToken {
+		tokens = append(tokens, current.String())
+	}
+
+	return tokens
+}
+
+func toSet(values []string) map[string]bool {
+	set := make(map[string]bool, len(values))
+	for _, value := range values {
+		set[value] = true
+	}
+	return set
+}
@@ -0,0 +1,152 @@
+package category
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDefaultCategorize(t *testing.T) {
+	testCases := []struct {
+		command    string
+		tool       string
+		subcommand string
+		category   string
+	}{
+		{"./myapp", "myapp", "myapp", "myapp"},
+		{"sudo -u root /usr/bin/docker ps -a", "docker", "docker ps", "container"},
+		{"time go test ./...", "go", "go test", "test"},
+		{"env FOO=1 make", "make", "make", "build"},
+		{"FOO=1 BAR=2 make -C src test", "make", "make test", "test"},
+		{"npx jest --watch", "jest", "jest", "test"},
+		{"poetry run pytest -k smoke", "pytest", "pytest", "test"},
+		{"python3 -m pytest", "pytest", "pytest", "test"},
+		{"git -C repo push origin main", "git", "git push", "vcs"},
+		{"git status", "git", "git status", "vcs"},
+		{"kubectl -n kube-system get pods", "kubectl", "kubectl get", "k8s"},
+		{"docker compose -f dev.yml up -d", "docker", "docker compose up", "container"},
+		{"npm run build", "npm", "npm run build", "build"},
+		{"npm install lodash", "npm", "npm install", "package"},
+		{"timeout 10s cargo build --release", "cargo", "cargo build", "build"},
+		{"gh pr create --fill", "gh", "gh pr create", "vcs"},
+		{"ls -la", "ls", "ls", "ls"},
+		{"time", "time", "time", "time"},
+		{`echo "a b" 'c d'`, "echo", "echo", "echo"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.command, func(t *testing.T) {
+			result := Default().Categorize(tc.command)
+
+			assert.Equal(t, tc.tool, result.Tool)
+			assert.Equal(t, tc.subcommand, result.Subcommand)
+			assert.Equal(t, tc.category, result.Category)
+		})
+	}
+}
+
+func TestDefaultModes(t *testing.T) {
+	testCases := map[string]string{
+		"vim main.go":                ModeInteractive,
+		"sudo less /var/log/syslog":  ModeInteractive,
+		"ssh dev@example.com":        ModeInteractive,
+		"node":                       ModeInteractive,
+		"node server.js":             "",
+		"docker run -it ubuntu bash": ModeInteractive,
+		"npm run dev":                ModeService,
+		"kubectl -n app port-forward svc/api 8080": ModeService,
+		"docker compose -f dev.yml up":             ModeService,
+		"docker compose -f dev.yml up -d":          ModeBatch,
+		"python3 -m http.server 8000":              ModeService,
+		"tail -f app.log":                          ModeService,
+		"jest --watch":                             ModeService,
+		"go test ./...":                            "",
+	}
+
+	for command, mode := range testCases {
+		t.Run(command, func(t *testing.T) {
+			assert.Equal(t, mode, Default().Categorize(command).Mode)
+		})
+	}
+}
+
+func TestTokenize(t *testing.T) {
+	assert.Equal(t, []string{"echo", "a b", "c d", "e f", `"`}, tokenize(`echo "a b" 'c d' e\ f '"'`))
+	assert.Empty(t, tokenize("   "))
+}
+
+func TestLoadUserRules(t *testing.T) {
+	dir := t.TempDir()
+	rules := `
+[[wrappers]]
+command = "with-env"
+
+[[tools]]
+command = "just"
+depth = 1
+
+[[rules]]
+category = "deploy"
+match = '^(just deploy|git push)\b'
+`
+	assert.NoError(t, os.WriteFile(filepath.Join(dir, FileName), []byte(rules), 0644))
+
+	categorizer, err := Load(dir)
+	assert.NoError(t, err)
+
+	result := categorizer.Categorize("with-env just deploy prod")
+	assert.Equal(t, "just deploy", result.Subcommand)
+	assert.Equal(t, "deploy", result.Category)
+
+	// User rules take precedence over the built-in ones
+	assert.Equal(t, "deploy", categorizer.Categorize("git push").Category)
+	// Built-in rules are still applied
+	assert.Equal(t, "vcs", categorizer.Categorize("git status").Category)
+}
+
+func TestLoadReplaceDefaults(t *testing.T) {
+	dir := t.TempDir()
+	rules := `
+replace_defaults = true
+
+[[rules]]
+category = "anything"
+match = '.*'
+`
+	assert.NoError(t, os.WriteFile(filepath.Join(dir, FileName), []byte(rules), 0644))
+
+	categorizer, err := Load(dir)
+	assert.NoError(t, err)
+
+	result := categorizer.Categorize("sudo git status")
+	assert.Equal(t, "sudo", result.Tool)
+	assert.Equal(t, "anything", result.Category)
+}
+
+func TestLoadInvalidRules(t *testing.T) {
+	testCases := map[string]string{
+		"invalid pattern": "[[rules]]\ncategory = \"x\"\nmatch = '('\n",
+		"empty category":  "[[rules]]\nmatch = 'x'\n",
+		"unknown key":     "[[rules]]\ncategory = \"x\"\nmatch = 'x'\npattern = 'y'\n",
+		"invalid toml":    "[[rules]\n",
+		"invalid mode":    "[[modes]]\nmode = \"daemon\"\nmatch = 'x'\n",
+	}
+
+	for name, rules := range testCases {
+		t.Run(name, func(t *testing.T) {
+			dir := t.TempDir()
+			assert.NoError(t, os.WriteFile(filepath.Join(dir, FileName), []byte(rules), 0644))
+
+			_, err := Load(dir)
+			assert.Error(t, err)
+		})
+	}
+}
+
+func TestLoadMissingFile(t *testing.T) {
+	categorizer, err := Load(t.TempDir())
+	assert.NoError(t, err)
+	assert.Equal(t, Default(), categorizer)
+}
@@ -0,0 +1,63 @@
+package cmd
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"github.com/devzero-inc/local-developer-analytics/category"
+	"github.com/devzero-inc/local-developer-analytics/config"
+	"github.com/devzero-inc/local-developer-analytics/logging"
+	"github.com/devzero-inc/local-developer-analytics/user"
+
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+)
+
+// newCategorizeCmd creates a new categorize command
+func newCategorizeCmd() *cobra.Command {
+	categorizeCmd := &cobra.Command{
+		Use:   "categorize \"<command>\"",
+		Short: "Show how a command is categorized",
+		Long: `Show how a command is categorized by the built-in rules and the rules in ~/.lda/categories.toml.
+Useful for debugging custom wrappers, tools and category rules.`,
+		Args: cobra.MinimumNArgs(1),
+		RunE: categorize,
+	}
+
+	return categorizeCmd
+}
+
+func categorize(_ *cobra.Command, args []string) error {
+	setupConfig()
+
+	categorizer, err := category.Load(user.Conf.LdaDir)
+	if err != nil {
+		logging.Log.Error().Err(err).Msg("Failed to load categorization rules")
+		return errors.Wrap(err, "failed to load categorization rules")
+	}
+
+	command := strings.Join(args, " ")
+	result := categorizer.Categorize(command)
+
+	rule := result.Rule
+	if rule == "" {
+		rule = "(none, categorized by tool)"
+	}
+
+	mode := result.Mode
+	if mode == "" {
+		mode = "(none, classified by duration and CPU usage)"
+	}
+
+	fmt.Fprintf(config.SysConfig.Out, "Rules:      %s (if present) and built-in defaults\n", filepath.Join(user.Conf.LdaDir, category.FileName))
+	fmt.Fprintf(config.SysConfig.Out, "Command:    %s\n", command)
+	fmt.Fprintf(config.SysConfig.Out, "Stripped:   %s\n", result.Stripped)
+	fmt.Fprintf(config.SysConfig.Out, "Tool:       %s\n", result.Tool)
+	fmt.Fprintf(config.SysConfig.Out, "Subcommand: %s\n", result.Subcommand)
+	fmt.Fprintf(config.SysConfig.Out, "Category:   %s\n", result.Category)
+	fmt.Fprintf(config.SysConfig.Out, "Rule:       %s\n", rule)
+	fmt.Fprintf(config.SysConfig.Out, "Mode:       %s\n", mode)
+
+	return nil
+}
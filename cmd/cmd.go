@@ -38,7 +38,7 @@ func newInstallCmd() *cobra.Command {
 		RunE:  install,
 	}
 
-	installCmd.Flags().StringSliceVarP(&installFlags.shells, "shell", "s", []string{}, fmt.Sprintf("Shells to instrument %+v; --shell=all for all shells", config.SupportedShells))
+	installCmd.Flags().StringSliceVarP(&installFlags.shells, "shell", "s", []string{}, fmt.Sprintf("Shells to instrument %+v; --shell=all for all the installed shells", config.SupportedShells))
 	installCmd.Flags().BoolVarP(&installFlags.nonInteractive, "non-interactive", "n", false, "Run installation in non-interactive mode")
 	installCmd.Flags().BoolP("auto-credentials", "a", false, "Try to automatically generate the credentails")
 	installCmd.Flags().BoolP("workspace", "w", false, "Is collection executed in a DevZero workspace")
@@ -67,6 +67,13 @@ func NewLdaCmd() *cobra.Command {
 		newServeCmd(),
 		newReloadCmd(),
 		newConfigCmd(),
+		newCategorizeCmd(),
+		newRedactCmd(),
+		newPauseCmd(),
+		newResumeCmd(),
+		newCollectorCmd(),
+		newImportCmd(),
+		newEmitCmd(),
 	)
 
 	return ldaCmd
@@ -313,7 +320,8 @@ func install(cmd *cobra.Command, _ []string) error {
 	if len(installFlags.shells) > 0 {
 		for _, shellType := range installFlags.shells {
 			if strings.EqualFold(shellType, "all") {
-				installFlags.shells = config.SupportedShells
+				// Only the installed shells, the configuration of the others would be created for nothing
+				installFlags.shells = config.InstalledShells()
 				break
 			} else if config.GetShellType(shellType) == -1 {
 				fmt.Fprintf(config.SysConfig.ErrOut, "Unsupported shell: %s\nPlease choose one of: %+v\n", shellType, config.SupportedShells)
@@ -356,6 +364,16 @@ func install(cmd *cobra.Command, _ []string) error {
 	}
 	dmn := daemon.NewDaemon(daemonConf, logging.Log)
 
+	// The socket is resolved once and persisted, the daemon doesn't run in the environment of this session
+	socketPath := config.AppConfig.SocketPath
+	if socketPath == "" {
+		socketPath = config.ResolveSocketPath(user.Conf.LdaDir, user.Conf.User, user.Conf.IsRoot)
+		if err := config.SaveSocketPath(user.Conf.LdaDir, socketPath, user.Conf.User); err != nil {
+			logging.Log.Error().Err(err).Msg("Failed to save socket path")
+			return errors.Wrap(err, "failed to save the socket path in the configuration")
+		}
+	}
+
 	fmt.Fprintln(config.SysConfig.Out, "Installing LDA daemon...")
 	if err := dmn.InstallDaemonConfiguration(); err != nil {
 		logging.Log.Error().Err(err).Msg("Failed to install daemon configuration")
@@ -370,6 +388,9 @@ func install(cmd *cobra.Command, _ []string) error {
 			SudoExecUser:  user.Conf.User,
 			LdaDir:        user.Conf.LdaDir,
 			HomeDir:       user.Conf.HomeDir,
+			SocketPath:    socketPath,
+			ExePath:       user.Conf.ExePath,
+			Os:            config.OSType(user.Conf.Os),
 		}
 
 		shl, err := shell.NewShell(shellConfig, logging.Log)
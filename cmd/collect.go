@@ -1,13 +1,24 @@
 package cmd
 
 import (
+	"context"
+	"fmt"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"syscall"
 	"time"
 
+	"github.com/devzero-inc/local-developer-analytics/category"
 	"github.com/devzero-inc/local-developer-analytics/client"
 	"github.com/devzero-inc/local-developer-analytics/collector"
 	"github.com/devzero-inc/local-developer-analytics/config"
+	"github.com/devzero-inc/local-developer-analytics/database"
+	"github.com/devzero-inc/local-developer-analytics/hook"
 	"github.com/devzero-inc/local-developer-analytics/logging"
+	"github.com/devzero-inc/local-developer-analytics/notify"
 	"github.com/devzero-inc/local-developer-analytics/process"
+	"github.com/devzero-inc/local-developer-analytics/redact"
 	"github.com/devzero-inc/local-developer-analytics/user"
 
 	"github.com/pkg/errors"
@@ -26,6 +37,8 @@ func newCollectCmd() *cobra.Command {
 
 	collectCmd.Flags().BoolP("auto-credentials", "a", false, "Try to automatically generate the credentails")
 	collectCmd.Flags().BoolP("workspace", "w", false, "Is collection executed in a DevZero workspace")
+	collectCmd.Flags().String("record", "", "Append every message received over the socket to the file, the messages contain unredacted commands")
+	collectCmd.Flags().String("replay", "", "Replay the messages recorded with --record into the database with a simulated clock, instead of listening on the socket")
 
 	return collectCmd
 }
@@ -45,6 +58,22 @@ func collect(cmd *cobra.Command, _ []string) error {
 		return errors.Wrap(err, "failed to get workspace flag")
 	}
 
+	recordPath, err := cmd.Flags().GetString("record")
+	if err != nil {
+		logging.Log.Error().Err(err).Msg("Failed to get record flag")
+		return errors.Wrap(err, "failed to get record flag")
+	}
+
+	replayPath, err := cmd.Flags().GetString("replay")
+	if err != nil {
+		logging.Log.Error().Err(err).Msg("Failed to get replay flag")
+		return errors.Wrap(err, "failed to get replay flag")
+	}
+
+	if recordPath != "" && replayPath != "" {
+		return errors.New("--record and --replay can't be used together")
+	}
+
 	user.Conf, err = user.GetConfig()
 	if err != nil {
 		logging.Log.Error().Err(err).Msg("Failed to get os config")
@@ -73,6 +102,41 @@ func collect(cmd *cobra.Command, _ []string) error {
 		CommandIntervalMultiplier: config.AppConfig.CommandIntervalMultiplier,
 		MaxConcurrentCommands:     config.AppConfig.MaxConcurrentCommands,
 		MaxDuration:               time.Duration(config.AppConfig.MaxDuration) * time.Second,
+		AbandonedCommandTimeout:   time.Duration(config.AppConfig.AbandonedCommandTimeout) * time.Second,
+		LongRunningThreshold:      time.Duration(config.AppConfig.LongRunningThreshold) * time.Second,
+		ShutdownTimeout:           time.Duration(config.AppConfig.ShutdownTimeout) * time.Second,
+	}
+
+	intervalConfig.BackgroundPolicy, err = collector.NewSamplingPolicy(config.AppConfig.Sampling.Background, collector.ExponentialPolicy{
+		Initial: intervalConfig.ProcessInterval,
+		Factor:  3,
+		Max:     intervalConfig.MaxDuration,
+	}, nil)
+	if err != nil {
+		logging.Log.Error().Err(err).Msg("Invalid background sampling configuration")
+		return errors.Wrap(err, "invalid background sampling configuration")
+	}
+
+	intervalConfig.CommandPolicy, err = collector.NewSamplingPolicy(config.AppConfig.Sampling.Command, collector.ExponentialPolicy{
+		Initial: intervalConfig.CommandInterval,
+		Factor:  intervalConfig.CommandIntervalMultiplier,
+		Max:     intervalConfig.MaxDuration,
+	}, nil)
+	if err != nil {
+		logging.Log.Error().Err(err).Msg("Invalid command sampling configuration")
+		return errors.Wrap(err, "invalid command sampling configuration")
+	}
+
+	overflow, err := collector.ParseOverflowPolicy(config.AppConfig.EventQueueOverflow)
+	if err != nil {
+		logging.Log.Error().Err(err).Msg("Invalid event queue configuration")
+		return errors.Wrap(err, "invalid event queue configuration")
+	}
+
+	queueConfig := collector.QueueConfig{
+		Size:      config.AppConfig.EventQueueSize,
+		Overflow:  overflow,
+		SpillPath: filepath.Join(user.Conf.LdaDir, "events.spill"),
 	}
 
 	procCol, err := process.NewFactory(logging.Log).Create(config.AppConfig.ProcessCollectionType)
@@ -112,17 +176,125 @@ func collect(cmd *cobra.Command, _ []string) error {
 		logging.Log.Debug().Msgf("Auth: %+v", auth)
 	}
 
+	categorizer, err := category.Load(user.Conf.LdaDir)
+	if err != nil {
+		logging.Log.Error().Err(err).Msg("Failed to load categorization rules")
+		return errors.Wrap(err, "failed to load categorization rules")
+	}
+
+	redactor, err := redact.New(config.AppConfig.RedactPatterns, config.AppConfig.RedactEntropyThreshold)
+	if err != nil {
+		logging.Log.Error().Err(err).Msg("Failed to setup secret redaction")
+		return errors.Wrap(err, "failed to setup secret redaction")
+	}
+
+	filter, err := collector.NewFilter(config.AppConfig.Filters, config.AppConfig.ExcludeRegex, user.Conf.HomeDir)
+	if err != nil {
+		logging.Log.Error().Err(err).Msg("Invalid filter configuration")
+		return errors.Wrap(err, "invalid filter configuration")
+	}
+
+	hooks, err := hook.New(config.AppConfig.Hooks, config.AppConfig.HookConcurrency, user.Conf.HomeDir, logging.Log)
+	if err != nil {
+		logging.Log.Error().Err(err).Msg("Invalid hook configuration")
+		return errors.Wrap(err, "invalid hook configuration")
+	}
+
+	notifier, err := notify.FromConfig(config.AppConfig.Notifications, user.Conf.HomeDir, logging.Log)
+	if err != nil {
+		logging.Log.Error().Err(err).Msg("Invalid notification configuration")
+		return errors.Wrap(err, "invalid notification configuration")
+	}
+
+	if replayPath != "" {
+		// Replays only write to the local database, the hooks, notifications and processes of the host don't
+		// belong to the recorded commands
+		replayCollector := collector.NewCollector(
+			"",
+			nil,
+			logging.Log,
+			intervalConfig,
+			collector.QueueConfig{},
+			auth,
+			filter,
+			collector.NoProcesses{},
+			categorizer,
+			redactor,
+			nil,
+			nil,
+			collector.NewSimulatedClock(time.Time{}),
+			nil,
+		)
+		return replay(replayCollector, replayPath)
+	}
+
 	collectorInstance := collector.NewCollector(
-		collector.SocketPath,
+		config.GetSocketPath(user.Conf.LdaDir),
 		grpcClient,
 		logging.Log,
 		intervalConfig,
+		queueConfig,
 		auth,
-		config.AppConfig.ExcludeRegex,
+		filter,
 		procCol,
+		categorizer,
+		redactor,
+		hooks,
+		notifier,
+		nil,
+		nil,
 	)
 
-	collectorInstance.Collect()
+	// SIGTERM from the service manager and Ctrl-C shut the collector down in order
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGTERM, syscall.SIGINT)
+	defer stop()
+
+	if recordPath != "" {
+		recording, err := os.OpenFile(recordPath, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0600)
+		if err != nil {
+			logging.Log.Error().Err(err).Msg("Failed to open recording")
+			return errors.Wrap(err, "failed to open recording")
+		}
+		defer recording.Close()
+
+		logging.Log.Info().Msgf("Recording socket messages to %s", recordPath)
+		collectorInstance.Record(collector.NewRecorder(recording))
+	}
+
+	collectorInstance.Collect(ctx)
+
+	if err := database.DB.Close(); err != nil {
+		logging.Log.Error().Err(err).Msg("Failed to close database")
+		return errors.Wrap(err, "failed to close database")
+	}
+
+	return nil
+}
+
+// replay feeds the recorded socket messages through the collector
+func replay(collectorInstance *collector.Collector, replayPath string) error {
+	recording, err := os.Open(replayPath)
+	if err != nil {
+		logging.Log.Error().Err(err).Msg("Failed to open recording")
+		return errors.Wrap(err, "failed to open recording")
+	}
+	defer recording.Close()
+
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGTERM, syscall.SIGINT)
+	defer stop()
+
+	stats, err := collectorInstance.Replay(ctx, recording)
+	if err != nil {
+		logging.Log.Error().Err(err).Msg("Failed to replay recording")
+		return errors.Wrap(err, "failed to replay recording")
+	}
+
+	fmt.Fprintf(config.SysConfig.Out, "Replayed %d messages, %d failed\n", stats.Messages, stats.Failed)
+
+	if err := database.DB.Close(); err != nil {
+		logging.Log.Error().Err(err).Msg("Failed to close database")
+		return errors.Wrap(err, "failed to close database")
+	}
 
 	return nil
 }
@@ -0,0 +1,168 @@
+package cmd
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/devzero-inc/local-developer-analytics/collector"
+	"github.com/devzero-inc/local-developer-analytics/config"
+	"github.com/devzero-inc/local-developer-analytics/logging"
+	"github.com/devzero-inc/local-developer-analytics/user"
+
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+)
+
+// newPauseCmd creates a new pause command
+func newPauseCmd() *cobra.Command {
+	pauseCmd := &cobra.Command{
+		Use:   "pause",
+		Short: "Pause the collection",
+		Long: `Pause the collection of commands and processes, e.g. during a screen share.
+Commands that are already running are still finished. Without --for the collection is paused until 'lda resume'.`,
+		RunE: pause,
+	}
+
+	pauseCmd.Flags().Duration("for", 0, "Resume the collection automatically after the duration, e.g. 30m or 1h")
+
+	return pauseCmd
+}
+
+// newResumeCmd creates a new resume command
+func newResumeCmd() *cobra.Command {
+	resumeCmd := &cobra.Command{
+		Use:   "resume",
+		Short: "Resume the collection",
+		Long:  `Resume the collection of commands and processes paused with 'lda pause'.`,
+		RunE:  resume,
+	}
+
+	return resumeCmd
+}
+
+// newCollectorCmd creates a new collector command
+func newCollectorCmd() *cobra.Command {
+	collectorCmd := &cobra.Command{
+		Use:   "collector",
+		Short: "Control the running collector",
+		Long:  `Inspect and control the running collector.`,
+	}
+
+	collectorCmd.AddCommand(
+		&cobra.Command{
+			Use:   "status",
+			Short: "Show the collector status",
+			Long:  `Show the active commands, the sampling interval, the last event time and the remote send backlog of the collector.`,
+			RunE:  collectorStatus,
+		},
+		&cobra.Command{
+			Use:   "flush",
+			Short: "Resend the remote send backlog",
+			Long:  `Resend the commands and processes that failed to be sent to the remote server.`,
+			RunE:  collectorFlush,
+		},
+	)
+
+	return collectorCmd
+}
+
+func pause(cmd *cobra.Command, _ []string) error {
+	setupConfig()
+
+	duration, err := cmd.Flags().GetDuration("for")
+	if err != nil {
+		logging.Log.Error().Err(err).Msg("Failed to get for flag")
+		return errors.Wrap(err, "failed to get for flag")
+	}
+
+	if duration < 0 {
+		return fmt.Errorf("pause duration must be positive, got %s", duration)
+	}
+
+	status, err := sendControlRequest(collector.Event{Type: collector.PauseRequest, PauseFor: duration})
+	if err != nil {
+		return err
+	}
+
+	printStatus(status)
+
+	return nil
+}
+
+func resume(_ *cobra.Command, _ []string) error {
+	setupConfig()
+
+	status, err := sendControlRequest(collector.Event{Type: collector.ResumeRequest})
+	if err != nil {
+		return err
+	}
+
+	printStatus(status)
+
+	return nil
+}
+
+func collectorStatus(_ *cobra.Command, _ []string) error {
+	setupConfig()
+
+	status, err := sendControlRequest(collector.Event{Type: collector.StatusRequest})
+	if err != nil {
+		return err
+	}
+
+	printStatus(status)
+
+	return nil
+}
+
+func collectorFlush(_ *cobra.Command, _ []string) error {
+	setupConfig()
+
+	status, err := sendControlRequest(collector.Event{Type: collector.FlushRequest})
+	if err != nil {
+		return err
+	}
+
+	printStatus(status)
+
+	return nil
+}
+
+// sendControlRequest sends the request to the collector of the current user
+func sendControlRequest(request collector.Event) (collector.Status, error) {
+	status, err := collector.SendControlRequest(config.GetSocketPath(user.Conf.LdaDir), request)
+	if err != nil {
+		logging.Log.Error().Err(err).Msgf("Failed to send %s request", request.Type)
+		return status, errors.Wrapf(err, "failed to send %s request", request.Type)
+	}
+
+	return status, nil
+}
+
+func printStatus(status collector.Status) {
+	state := "collecting"
+	if status.Paused {
+		state = "paused"
+		if status.PausedUntil > 0 {
+			state = fmt.Sprintf("paused until %s", time.UnixMilli(status.PausedUntil).Format(time.Kitchen))
+		}
+	}
+
+	lastEvent := "never"
+	if status.LastEventTime > 0 {
+		lastEventTime := time.UnixMilli(status.LastEventTime)
+		lastEvent = fmt.Sprintf("%s (%s ago)", lastEventTime.Format(time.DateTime), time.Since(lastEventTime).Round(time.Second))
+	}
+
+	backlog := fmt.Sprintf("%d", status.SendBacklog)
+	if !status.RemoteCollection {
+		backlog += " (remote collection is disabled)"
+	}
+
+	fmt.Fprintf(config.SysConfig.Out, "State:             %s\n", state)
+	fmt.Fprintf(config.SysConfig.Out, "Active commands:   %d\n", status.ActiveCommands)
+	fmt.Fprintf(config.SysConfig.Out, "Sampling interval: %s\n", status.SamplingInterval)
+	fmt.Fprintf(config.SysConfig.Out, "Last event:        %s\n", lastEvent)
+	fmt.Fprintf(config.SysConfig.Out, "Send backlog:      %s\n", backlog)
+	fmt.Fprintf(config.SysConfig.Out, "Event queue:       %s\n", status.Queue)
+}
@@ -0,0 +1,93 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+
+	"github.com/devzero-inc/local-developer-analytics/collector"
+
+	"github.com/spf13/cobra"
+)
+
+// newEmitCmd creates a new emit command
+func newEmitCmd() *cobra.Command {
+	emitCmd := &cobra.Command{
+		Use:   "emit start|end <command> <directory> <user> <uuid> [result] [status] [shell pid]",
+		Short: "Send a shell hook event to the collector",
+		Long: `Send a shell hook event to the collector, the shell hooks run it before and after every command.
+The development context and the terminal session are read from the environment of the shell.
+Nothing is printed and the exit status is 0 when the collector isn't running, so the prompt is never disturbed,
+run with --verbose to see why an event wasn't sent.`,
+		Args:   cobra.RangeArgs(5, 8),
+		Hidden: true,
+		RunE:   emit,
+		// The hooks run on every prompt, the configuration and the database are not loaded
+		SilenceUsage:  true,
+		SilenceErrors: true,
+	}
+
+	emitCmd.Flags().String("socket", "", "Path to the collector socket")
+	emitCmd.Flags().Duration("timeout", collector.EmitTimeout, "Maximum time to wait on the collector")
+	_ = emitCmd.MarkFlagRequired("socket")
+
+	return emitCmd
+}
+
+func emit(cmd *cobra.Command, args []string) error {
+	socketPath, err := cmd.Flags().GetString("socket")
+	if err != nil {
+		return err
+	}
+
+	timeout, err := cmd.Flags().GetDuration("timeout")
+	if err != nil {
+		return err
+	}
+
+	event := eventFromHook(args)
+	if event.Type != collector.StartEvent && event.Type != collector.EndEvent {
+		return fmt.Errorf("unsupported event type %q, expected %s or %s", event.Type, collector.StartEvent, collector.EndEvent)
+	}
+
+	if err := collector.Emit(socketPath, event, os.Stdout, timeout); err != nil && Verbose {
+		fmt.Fprintf(os.Stderr, "Failed to emit %s event: %s\n", event.Type, err)
+	}
+
+	return nil
+}
+
+// eventFromHook builds the event from the arguments of the shell hook and the environment of the shell
+func eventFromHook(args []string) collector.Event {
+	arg := func(i int) string {
+		if i < len(args) {
+			return args[i]
+		}
+		return ""
+	}
+
+	shellPID, _ := strconv.ParseInt(arg(7), 10, 64)
+	shellStartTime, _ := strconv.ParseInt(os.Getenv("LDA_SHELL_START"), 10, 64)
+
+	return collector.Event{
+		Type:           arg(0),
+		Command:        arg(1),
+		Directory:      arg(2),
+		User:           arg(3),
+		UUID:           arg(4),
+		Result:         arg(5),
+		Status:         arg(6),
+		ShellPID:       shellPID,
+		TTY:            os.Getenv("LDA_TTY"),
+		VirtualEnv:     os.Getenv("VIRTUAL_ENV"),
+		NvmBin:         os.Getenv("NVM_BIN"),
+		Kubeconfig:     os.Getenv("KUBECONFIG"),
+		DirenvDir:      os.Getenv("DIRENV_DIR"),
+		ShellStartTime: shellStartTime,
+		Tmux:           os.Getenv("TMUX"),
+		TmuxPane:       os.Getenv("TMUX_PANE"),
+		Screen:         os.Getenv("STY"),
+		SSHConnection:  os.Getenv("SSH_CONNECTION"),
+		TermProgram:    os.Getenv("TERM_PROGRAM"),
+	}
+}
@@ -0,0 +1,153 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	osuser "os/user"
+
+	"github.com/devzero-inc/local-developer-analytics/category"
+	"github.com/devzero-inc/local-developer-analytics/collector"
+	"github.com/devzero-inc/local-developer-analytics/config"
+	"github.com/devzero-inc/local-developer-analytics/database"
+	"github.com/devzero-inc/local-developer-analytics/history"
+	"github.com/devzero-inc/local-developer-analytics/logging"
+	"github.com/devzero-inc/local-developer-analytics/redact"
+	"github.com/devzero-inc/local-developer-analytics/user"
+
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+)
+
+// newImportCmd creates a new import command
+func newImportCmd() *cobra.Command {
+	importCmd := &cobra.Command{
+		Use:   "import",
+		Short: "Import existing data",
+		Long:  `Import commands recorded before LDA was installed.`,
+	}
+
+	historyCmd := &cobra.Command{
+		Use:   "history",
+		Short: "Import the shell history",
+		Long: `Import the commands of a zsh, bash or fish history into the commands table.
+The zsh history needs the extended format and the bash history HISTTIMEFORMAT for the commands to have timestamps,
+commands without one are skipped. Importing the same history again skips the commands that are already stored.`,
+		RunE: importHistory,
+	}
+
+	historyCmd.Flags().String("shell", "", "Shell of the history, zsh, bash or fish")
+	historyCmd.Flags().String("file", "", "Path to the history file, defaults to the location of the shell")
+	_ = historyCmd.MarkFlagRequired("shell")
+
+	importCmd.AddCommand(historyCmd)
+
+	return importCmd
+}
+
+func importHistory(cmd *cobra.Command, _ []string) error {
+	setupConfig()
+
+	shell, err := cmd.Flags().GetString("shell")
+	if err != nil {
+		logging.Log.Error().Err(err).Msg("Failed to get shell flag")
+		return errors.Wrap(err, "failed to get shell flag")
+	}
+
+	path, err := cmd.Flags().GetString("file")
+	if err != nil {
+		logging.Log.Error().Err(err).Msg("Failed to get file flag")
+		return errors.Wrap(err, "failed to get file flag")
+	}
+
+	if path == "" {
+		path, err = history.DefaultPath(shell, user.Conf.HomeDir)
+		if err != nil {
+			return err
+		}
+	}
+
+	file, err := os.Open(path)
+	if err != nil {
+		logging.Log.Error().Err(err).Msg("Failed to open history")
+		return errors.Wrap(err, "failed to open history")
+	}
+	defer file.Close()
+
+	entries, err := history.Parse(shell, file)
+	if err != nil {
+		logging.Log.Error().Err(err).Msg("Failed to parse history")
+		return errors.Wrap(err, "failed to parse history")
+	}
+
+	categorizer, err := category.Load(user.Conf.LdaDir)
+	if err != nil {
+		logging.Log.Error().Err(err).Msg("Failed to load categorization rules")
+		return errors.Wrap(err, "failed to load categorization rules")
+	}
+
+	redactor, err := redact.New(config.AppConfig.RedactPatterns, config.AppConfig.RedactEntropyThreshold)
+	if err != nil {
+		logging.Log.Error().Err(err).Msg("Failed to setup secret redaction")
+		return errors.Wrap(err, "failed to setup secret redaction")
+	}
+
+	filter, err := collector.NewFilter(config.AppConfig.Filters, config.AppConfig.ExcludeRegex, user.Conf.HomeDir)
+	if err != nil {
+		logging.Log.Error().Err(err).Msg("Invalid filter configuration")
+		return errors.Wrap(err, "invalid filter configuration")
+	}
+
+	userName, err := historyUser()
+	if err != nil {
+		logging.Log.Error().Err(err).Msg("Failed to get the current user")
+		return errors.Wrap(err, "failed to get the current user")
+	}
+
+	// Imports only write to the local database, the commands are not sent remotely
+	importer := collector.NewCollector(
+		"",
+		nil,
+		logging.Log,
+		collector.IntervalConfig{},
+		collector.QueueConfig{},
+		collector.AuthConfig{},
+		filter,
+		collector.NoProcesses{},
+		categorizer,
+		redactor,
+		nil,
+		nil,
+		nil,
+		nil,
+	)
+
+	stats, err := importer.ImportHistory(shell, entries, userName)
+	if err != nil {
+		logging.Log.Error().Err(err).Msg("Failed to import history")
+		return errors.Wrap(err, "failed to import history")
+	}
+
+	fmt.Fprintf(config.SysConfig.Out, "Imported %d commands from %s, skipped %d duplicates, %d excluded and %d without a timestamp\n",
+		stats.Imported, path, stats.Duplicates, stats.Excluded, stats.Untimed)
+
+	if err := database.DB.Close(); err != nil {
+		logging.Log.Error().Err(err).Msg("Failed to close database")
+		return errors.Wrap(err, "failed to close database")
+	}
+
+	return nil
+}
+
+// historyUser returns the user owning the history, the user running sudo when imported as root
+func historyUser() (string, error) {
+	if user.Conf.User != nil {
+		return user.Conf.User.Username, nil
+	}
+
+	current, err := osuser.Current()
+	if err != nil {
+		return "", err
+	}
+
+	return current.Username, nil
+}
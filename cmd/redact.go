@@ -0,0 +1,71 @@
+package cmd
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+
+	"github.com/devzero-inc/local-developer-analytics/config"
+	"github.com/devzero-inc/local-developer-analytics/logging"
+	"github.com/devzero-inc/local-developer-analytics/redact"
+
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+)
+
+// newRedactCmd creates a new redact command
+func newRedactCmd() *cobra.Command {
+	redactCmd := &cobra.Command{
+		Use:   "redact",
+		Short: "Preview secret redaction",
+		Long: `Preview which parts of a command are masked before it is stored or sent.
+Commands are read from standard input, one per line, when --test is not provided.`,
+		Args: cobra.NoArgs,
+		RunE: redactCommand,
+	}
+
+	redactCmd.Flags().StringP("test", "t", "", "Command to test the redaction with")
+
+	return redactCmd
+}
+
+func redactCommand(cmd *cobra.Command, _ []string) error {
+	setupConfig()
+
+	redactor, err := redact.New(config.AppConfig.RedactPatterns, config.AppConfig.RedactEntropyThreshold)
+	if err != nil {
+		logging.Log.Error().Err(err).Msg("Failed to setup secret redaction")
+		return errors.Wrap(err, "failed to setup secret redaction")
+	}
+
+	if cmd.Flags().Changed("test") {
+		command, err := cmd.Flags().GetString("test")
+		if err != nil {
+			logging.Log.Error().Err(err).Msg("Failed to get test flag")
+			return errors.Wrap(err, "failed to get test flag")
+		}
+		printRedaction(redactor, command)
+		return nil
+	}
+
+	scanner := bufio.NewScanner(os.Stdin)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		printRedaction(redactor, scanner.Text())
+	}
+
+	if err := scanner.Err(); err != nil {
+		return errors.Wrap(err, "failed to read commands")
+	}
+
+	return nil
+}
+
+func printRedaction(redactor *redact.Redactor, command string) {
+	redacted, findings := redactor.Redact(command)
+
+	fmt.Fprintf(config.SysConfig.Out, "%s\n", redacted)
+	for _, finding := range findings {
+		fmt.Fprintf(config.SysConfig.Out, "  %-22s %s\n", finding.Detector, finding.Value)
+	}
+}
@@ -0,0 +1,106 @@
+package collector
+
+import (
+	"sort"
+	"sync"
+	"time"
+)
+
+// Clock tells the time of the collector, a simulated clock makes the collection deterministic
+type Clock interface {
+	Now() time.Time
+	// After sends the time on the channel once the duration has passed
+	After(d time.Duration) <-chan time.Time
+}
+
+// systemClock is the wall clock
+type systemClock struct{}
+
+func (systemClock) Now() time.Time {
+	return time.Now()
+}
+
+func (systemClock) After(d time.Duration) <-chan time.Time {
+	return time.After(d)
+}
+
+// SimulatedClock is a clock that only moves when it is advanced, timers fire in the order they are due
+type SimulatedClock struct {
+	mutex  sync.Mutex
+	now    time.Time
+	timers []simulatedTimer
+}
+
+// simulatedTimer is a pending After of the simulated clock
+type simulatedTimer struct {
+	at      time.Time
+	channel chan time.Time
+}
+
+// NewSimulatedClock creates a simulated clock starting at the given time
+func NewSimulatedClock(now time.Time) *SimulatedClock {
+	return &SimulatedClock{now: now}
+}
+
+// Now returns the simulated time
+func (s *SimulatedClock) Now() time.Time {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	return s.now
+}
+
+// After fires once the clock is advanced past the duration
+func (s *SimulatedClock) After(d time.Duration) <-chan time.Time {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	// Buffered, so firing never blocks on a receiver that has gone away
+	channel := make(chan time.Time, 1)
+	if d <= 0 {
+		channel <- s.now
+		return channel
+	}
+
+	s.timers = append(s.timers, simulatedTimer{at: s.now.Add(d), channel: channel})
+
+	return channel
+}
+
+// Set moves the clock to the time and fires the timers due by then, the clock never moves backwards
+func (s *SimulatedClock) Set(now time.Time) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if now.Before(s.now) {
+		return
+	}
+	s.now = now
+
+	sort.SliceStable(s.timers, func(i, j int) bool {
+		return s.timers[i].at.Before(s.timers[j].at)
+	})
+
+	pending := s.timers[:0]
+	for _, timer := range s.timers {
+		if timer.at.After(now) {
+			pending = append(pending, timer)
+			continue
+		}
+		timer.channel <- timer.at
+	}
+	s.timers = pending
+}
+
+// Advance moves the clock forward by the duration
+func (s *SimulatedClock) Advance(d time.Duration) {
+	s.Set(s.Now().Add(d))
+}
+
+// Pending returns the number of timers that haven't fired yet
+func (s *SimulatedClock) Pending() int {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	return len(s.timers)
+}
@@ -1,15 +1,22 @@
 package collector
 
 import (
+	"bytes"
 	"context"
 	"fmt"
+	"io"
 	"net"
-	"strings"
+	"os"
+	"path/filepath"
 	"sync"
 
+	"github.com/devzero-inc/local-developer-analytics/category"
 	"github.com/devzero-inc/local-developer-analytics/client"
 	gen "github.com/devzero-inc/local-developer-analytics/gen/api/v1"
+	"github.com/devzero-inc/local-developer-analytics/hook"
+	"github.com/devzero-inc/local-developer-analytics/notify"
 	"github.com/devzero-inc/local-developer-analytics/process"
+	"github.com/devzero-inc/local-developer-analytics/redact"
 	"github.com/devzero-inc/local-developer-analytics/util"
 
 	"github.com/rs/zerolog"
@@ -17,20 +24,57 @@ import (
 	"time"
 )
 
-// TODO move this to /var/run or other appropriate location based on OS,
-// TODO /var/run has issues with permisisons os have to explore a bit more.
-const SocketPath = "/tmp/lda.socket"
+const (
+	// readTimeout is the maximum time a shell hook can take to send a single event
+	readTimeout = 5 * time.Second
+	// socketPermissions only allow the owner of the collector to connect to the socket
+	socketPermissions = 0600
+	// DefaultShutdownTimeout is how long the shutdown waits for hooks and remote sends by default
+	DefaultShutdownTimeout = 10 * time.Second
+	// maxConnections is the number of connections read at the same time, reading is quick as the events
+	// are processed by the workers
+	maxConnections = 256
+)
 
 // Collector collects command and system information
 type Collector struct {
-	socketPath       string
-	client           *client.Client
-	logger           zerolog.Logger
-	excludeRegex     string
-	collectionConfig collectionConfig
-	authConfig       AuthConfig
-	protoAuthConfig  *gen.Auth
-	intervalConfig   IntervalConfig
+	socketPath      string
+	client          remoteClient
+	logger          zerolog.Logger
+	authConfig      AuthConfig
+	protoAuthConfig *gen.Auth
+	intervalConfig  IntervalConfig
+	// state is owned by the state loop, operations on it are sent through the operations channel
+	state      collectorState
+	operations chan func(state *collectorState)
+	// stopped is closed once the shutdown is done, it ends the state loop
+	stopped  chan struct{}
+	stopOnce sync.Once
+	// process is the system process collector
+	process process.SystemProcess
+	// clock tells the time of the collection and store persists what is collected
+	clock Clock
+	store Store
+	// filter decides which commands are collected
+	filter *Filter
+	// categorizer assigns the category and subcommand to the commands
+	categorizer *category.Categorizer
+	// redactor masks secrets in the commands before they are stored or sent
+	redactor *redact.Redactor
+	// hooks are run on the command start and end events
+	hooks *hook.Runner
+	// notifier notifies about long-running commands, nil when notifications are disabled
+	notifier *notify.Manager
+	// ownerUID is the UID allowed to send events over the socket
+	ownerUID int
+	// backlog tracks the data that hasn't been sent to the remote server yet
+	backlog remoteBacklog
+	// queue decouples receiving the shell events from processing them
+	queue *eventQueue
+	// recorder tees the received messages to a recording, nil when not recording
+	recorder *Recorder
+	// replaying is set while a recording is replayed, the development context then only comes from the recording
+	replaying bool
 }
 
 // IntervalConfig contains the configuration for the collection intervals
@@ -38,8 +82,18 @@ type IntervalConfig struct {
 	ProcessInterval           time.Duration
 	CommandInterval           time.Duration
 	CommandIntervalMultiplier float64
-	MaxConcurrentCommands     int
-	MaxDuration               time.Duration
+	// MaxConcurrentCommands is the number of workers processing the events of the shells
+	MaxConcurrentCommands   int
+	MaxDuration             time.Duration
+	AbandonedCommandTimeout time.Duration
+	// LongRunningThreshold is the duration after which an idle command without a mode rule is interactive or a service
+	LongRunningThreshold time.Duration
+	// ShutdownTimeout is how long the shutdown waits for hooks and remote sends, zero is the default timeout
+	ShutdownTimeout time.Duration
+	// BackgroundPolicy and CommandPolicy decide the intervals of the process sampling loops, when nil
+	// the intervals back off exponentially from the process and command intervals
+	BackgroundPolicy SamplingPolicy
+	CommandPolicy    SamplingPolicy
 }
 
 // AuthConfig contains the configuration for the command processing and authentication
@@ -50,38 +104,48 @@ type AuthConfig struct {
 	UserEmail   string
 }
 
-// collectionConfig contains the configuration for the collection process
-type collectionConfig struct {
-	// ongoingCommands is a map of currently running commands
-	ongoingCommands map[string]Command
-	// collectionMutex is a mutex to protect the ongoingCommands map
-	collectionMutex sync.Mutex
-	// activeCommandsCounter is a counter for the number of active commands
-	activeCommandsCounter int
-	// collectionContext is the context for the collection process
-	collectionContext context.Context
-	// collectionCancelFunc is the cancel function for the collection context
-	collectionCancelFunc context.CancelFunc
-	// isCollectionRunning is a flag to indicate if the collection is running
-	isCollectionRunning bool
-	// process is the system process collector
-	process process.SystemProcess
-}
-
-// NewCollector creates a new collector instance
-func NewCollector(socketPath string, client *client.Client, logger zerolog.Logger, config IntervalConfig, auth AuthConfig, excludeRegex string, process process.SystemProcess) *Collector {
+// NewCollector creates a new collector instance, a nil clock is the wall clock and a nil store is the local database
+func NewCollector(socketPath string, client *client.Client, logger zerolog.Logger, config IntervalConfig, queue QueueConfig, auth AuthConfig, filter *Filter, process process.SystemProcess, categorizer *category.Categorizer, redactor *redact.Redactor, hooks *hook.Runner, notifier *notify.Manager, clock Clock, store Store) *Collector {
+	if categorizer == nil {
+		categorizer = category.Default()
+	}
+	if redactor == nil {
+		redactor = redact.Default()
+	}
+	if clock == nil {
+		clock = systemClock{}
+	}
+	if store == nil {
+		store = DatabaseStore{}
+	}
 
 	collector := &Collector{
 		socketPath: socketPath,
-		client:     client,
 		logger:     logger,
-		collectionConfig: collectionConfig{
-			ongoingCommands: make(map[string]Command),
-			process:         process,
+		state: collectorState{
+			ongoingCommands:  make(map[string]Command),
+			excludedCommands: make(map[string]time.Time),
+			recordedContexts: make(map[string]DevContext),
 		},
+		operations:     make(chan func(state *collectorState)),
+		stopped:        make(chan struct{}),
+		process:        process,
+		clock:          clock,
+		store:          store,
 		intervalConfig: config,
 		authConfig:     auth,
-		excludeRegex:   excludeRegex,
+		filter:         filter,
+		categorizer:    categorizer,
+		redactor:       redactor,
+		hooks:          hooks,
+		notifier:       notifier,
+		ownerUID:       os.Getuid(),
+		queue:          newEventQueue(queue, logger),
+	}
+
+	// A nil client must not end up in the interface, as it wouldn't compare equal to nil
+	if client != nil {
+		collector.client = client
 	}
 
 	if auth.TeamID != "" && auth.UserID != "" {
@@ -93,58 +157,175 @@ func NewCollector(socketPath string, client *client.Client, logger zerolog.Logge
 		}
 	}
 
+	go collector.runStateLoop()
+
 	return collector
 }
 
-// Collect starts the collection of command and system information
-func (c *Collector) Collect() {
+// Collect starts the collection of command and system information, it stops once the context is canceled.
+//
+// The shutdown is orderly: no new connections are accepted, the queued events are processed, the command
+// sampling loop is stopped and the in-flight commands are persisted for the next run, the running hooks
+// and the remote sends are waited for until the shutdown timeout, and finally the socket is removed.
+func (c *Collector) Collect(ctx context.Context) {
 	c.logger.Info().Msg("Collecting command and system information")
 
-	ctx, cancel := context.WithCancel(context.Background())
+	ctx, cancel := context.WithCancel(ctx)
 	defer cancel()
 
+	c.restoreOngoingCommands()
+
 	var wg sync.WaitGroup
 
 	wg.Add(1)
 	go func() {
 		defer wg.Done()
-		c.collectSystemInformation(ctx, c.intervalConfig.ProcessInterval, 3, c.intervalConfig.MaxDuration)
+		c.collectSystemInformation(ctx, c.backgroundPolicy(), func(state *collectorState, interval time.Duration) {
+			state.backgroundInterval = interval
+		})
 	}()
 
 	wg.Add(1)
 	go func() {
 		defer wg.Done()
-		if err := c.collectCommandInformation(); err != nil {
-			c.logger.Error().Err(err).Msg("Failed to collect command information")
-			cancel()
-		}
+		c.sweepAbandonedCommands(ctx)
 	}()
 
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		c.logQueueStats(ctx)
+	}()
+
+	var workers sync.WaitGroup
+	workerCount := c.intervalConfig.MaxConcurrentCommands
+	if workerCount <= 0 {
+		workerCount = 1
+	}
+	for i := 0; i < workerCount; i++ {
+		workers.Add(1)
+		go func() {
+			defer workers.Done()
+			c.processEvents()
+		}()
+	}
+
+	// Returns once the context is canceled and every accepted connection has been read
+	if err := c.collectCommandInformation(ctx); err != nil {
+		c.logger.Error().Err(err).Msg("Failed to collect command information")
+	}
+	cancel()
+
+	c.logger.Info().Msgf("Shutting down, processing the queued events: %s", c.queue.snapshot())
+
+	// Closing the queue lets the workers finish the queued events and stop
+	c.queue.close()
+	workers.Wait()
 	wg.Wait()
 
+	c.shutdown(time.Now().Add(c.shutdownTimeout()))
+
 	c.logger.Info().Msg("Collection stopped")
 }
 
-// collectSystemInformation uses exponential backoff for intervals between collections.
-func (c *Collector) collectSystemInformation(ctx context.Context, initialDuration time.Duration, increaseFactor float64, maxDuration time.Duration) {
-	currentDuration := initialDuration
+// shutdownTimeout is the time the shutdown waits for hooks and remote sends
+func (c *Collector) shutdownTimeout() time.Duration {
+	if c.intervalConfig.ShutdownTimeout > 0 {
+		return c.intervalConfig.ShutdownTimeout
+	}
+	return DefaultShutdownTimeout
+}
+
+// shutdown stops the command sampling, persists the in-flight commands, waits for the hooks and the
+// remote sends until the deadline and finally stops the state loop
+func (c *Collector) shutdown(deadline time.Time) {
+	var ongoing []Command
+	c.do(func(state *collectorState) {
+		if state.stopSampling != nil {
+			state.stopSampling()
+			state.stopSampling = nil
+		}
+		for _, command := range state.ongoingCommands {
+			ongoing = append(ongoing, command)
+		}
+	})
+
+	// Replayed commands aren't running anywhere, they are never persisted as in-flight
+	if c.replaying {
+		ongoing = nil
+	}
+
+	// The commands are still running in their shells, their end events are matched after a restart
+	for _, command := range ongoing {
+		if err := c.store.InsertOngoingCommand(command); err != nil {
+			c.logger.Error().Err(err).Msgf("Failed to persist in-flight command %s", command.UUID)
+		}
+	}
+	if len(ongoing) > 0 {
+		c.logger.Info().Msgf("Persisted %d in-flight commands", len(ongoing))
+	}
+
+	hooksDone := make(chan struct{})
+	go func() {
+		c.hooks.Wait()
+		close(hooksDone)
+	}()
+
+	select {
+	case <-hooksDone:
+	case <-time.After(time.Until(deadline)):
+		c.logger.Warn().Msg("Hooks are still running at the shutdown deadline")
+	}
+
+	if err := c.flushRemote(deadline); err != nil {
+		c.logger.Error().Err(err).Msg("Failed to send the remaining data")
+	}
+
+	c.stopStateLoop()
+}
+
+// backgroundPolicy is the sampling policy of the loop running while the collector runs
+func (c *Collector) backgroundPolicy() SamplingPolicy {
+	if c.intervalConfig.BackgroundPolicy != nil {
+		return c.intervalConfig.BackgroundPolicy
+	}
+	return ExponentialPolicy{Initial: c.intervalConfig.ProcessInterval, Factor: 3, Max: c.intervalConfig.MaxDuration}
+}
+
+// commandPolicy is the sampling policy of the loop running while commands are running
+func (c *Collector) commandPolicy() SamplingPolicy {
+	if c.intervalConfig.CommandPolicy != nil {
+		return c.intervalConfig.CommandPolicy
+	}
+	return ExponentialPolicy{
+		Initial: c.intervalConfig.CommandInterval,
+		Factor:  c.intervalConfig.CommandIntervalMultiplier,
+		Max:     c.intervalConfig.MaxDuration,
+	}
+}
+
+// collectSystemInformation samples the processes at the intervals decided by the sampling policy.
+// The current interval is reported in the interval field for the collector status.
+func (c *Collector) collectSystemInformation(ctx context.Context, policy SamplingPolicy, report func(state *collectorState, interval time.Duration)) {
+	currentDuration := policy.Next(c.clock.Now(), 0)
 
 	for {
+		interval := currentDuration
+		c.do(func(state *collectorState) {
+			report(state, interval)
+		})
+
 		select {
 		case <-ctx.Done():
 			c.logger.Debug().Msg("Shutting down collection of system information")
 			return
-		case <-time.After(currentDuration):
+		case <-c.clock.After(currentDuration):
 			// Perform the collection on each tick
 			if err := c.collectOnce(); err != nil {
 				c.logger.Error().Err(err).Msg("Failed to collect system information")
 			}
 
-			// Calculate the next interval with exponential backoff
-			currentDuration = time.Duration(float64(currentDuration) * increaseFactor)
-			if currentDuration > maxDuration {
-				currentDuration = maxDuration
-			}
+			currentDuration = policy.Next(c.clock.Now(), currentDuration)
 
 			c.logger.Debug().Msgf("Next collection in %s", currentDuration)
 		}
@@ -152,19 +333,25 @@ func (c *Collector) collectSystemInformation(ctx context.Context, initialDuratio
 }
 
 func (c *Collector) collectOnce() error {
+	if c.isPaused(c.clock.Now()) {
+		c.logger.Debug().Msg("Collection is paused, skipping process collection")
+		return nil
+	}
 
 	c.logger.Debug().Msg("Collecting process")
 
-	processes, err := c.collectionConfig.process.Collect()
+	processes, err := c.process.Collect()
 	if err != nil {
 		c.logger.Err(err).Msg("Failed to collect processes")
 		return err
 	}
 
-	if err := process.InsertProcesses(processes); err != nil {
+	if err := c.store.InsertProcesses(processes); err != nil {
 		c.logger.Error().Err(err).Msg("Failed to insert processes")
 	}
 
+	c.linkCommandProcesses(processes)
+
 	if c.client != nil {
 		var processMetrics []*gen.Process
 		for _, p := range processes {
@@ -175,78 +362,74 @@ func (c *Collector) collectOnce() error {
 			)
 		}
 
-		go func() {
-			if err := c.client.SendProcesses(processMetrics, c.protoAuthConfig); err != nil {
-				c.logger.Error().Err(err).Msg("Failed to send processes")
-			}
-		}()
+		c.sendProcesses(processMetrics)
 	}
 
 	return nil
 }
 
-func (c *Collector) onStartCommand() {
-	c.collectionConfig.collectionMutex.Lock()
-	defer c.collectionConfig.collectionMutex.Unlock()
+// sampleCommands samples the processes while commands are running, for at most the maximum duration
+func (c *Collector) sampleCommands(ctx context.Context) {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
 
-	// Perform initial collection for every command
-	if err := c.collectOnce(); err != nil {
-		c.logger.Error().Err(err).Msg("Failed to collect system information")
-	}
+	go func() {
+		select {
+		case <-c.clock.After(c.intervalConfig.MaxDuration):
+			cancel()
+		case <-ctx.Done():
+		}
+	}()
 
-	c.collectionConfig.activeCommandsCounter++
-	// If the collection is not running, start it with a timeout
-	if !c.collectionConfig.isCollectionRunning {
-		c.logger.Debug().Msg("Starting collection")
-		c.collectionConfig.collectionContext, c.collectionConfig.collectionCancelFunc =
-			context.WithTimeout(context.Background(), c.intervalConfig.MaxDuration)
-		go c.collectSystemInformation(
-			c.collectionConfig.collectionContext,
-			c.intervalConfig.CommandInterval,
-			c.intervalConfig.CommandIntervalMultiplier,
-			c.intervalConfig.MaxDuration,
-		)
-		c.collectionConfig.isCollectionRunning = true
-	}
+	c.collectSystemInformation(ctx, c.commandPolicy(), func(state *collectorState, interval time.Duration) {
+		state.commandInterval = interval
+	})
 }
 
-func (c *Collector) onEndCommand() {
-	c.collectionConfig.collectionMutex.Lock()
-	defer c.collectionConfig.collectionMutex.Unlock()
-
-	c.collectionConfig.activeCommandsCounter--
-	// If there are no more active commands, stop the collection
-	if c.collectionConfig.activeCommandsCounter == 0 && c.collectionConfig.isCollectionRunning {
-		c.logger.Debug().Msg("Stopping collection")
-		c.collectionConfig.collectionCancelFunc()
-		c.collectionConfig.isCollectionRunning = false
+// collectCommandInformation accepts the connections of the shells until the context is canceled,
+// the socket is removed once every accepted connection has been read
+func (c *Collector) collectCommandInformation(ctx context.Context) error {
+	if err := util.Fs.RemoveAll(c.socketPath); err != nil {
+		c.logger.Error().Err(err).Msg("Failed to clean up existing socket")
+		return err
 	}
-}
 
-func (c *Collector) collectCommandInformation() error {
-	if err := util.Fs.RemoveAll(SocketPath); err != nil {
-		c.logger.Error().Err(err).Msg("Failed to clean up existing socket")
+	if err := util.Fs.MkdirAll(filepath.Dir(c.socketPath), 0700); err != nil {
+		c.logger.Error().Err(err).Msg("Failed to create socket directory")
 		return err
 	}
 
-	listener, err := net.Listen("unix", SocketPath)
+	listener, err := net.Listen("unix", c.socketPath)
 	if err != nil {
 		c.logger.Error().Err(err).Msg("Failed to listen on UNIX socket")
 		return err
 	}
-	defer listener.Close()
+	defer func() {
+		listener.Close()
+		if err := util.Fs.Remove(c.socketPath); err != nil && !os.IsNotExist(err) {
+			c.logger.Error().Err(err).Msg("Failed to remove socket")
+		}
+	}()
 
-	// Limit the number of concurrent goroutines handling connections
-	semaphore := make(chan struct{}, c.intervalConfig.MaxConcurrentCommands)
+	if err := util.Fs.Chmod(c.socketPath, socketPermissions); err != nil {
+		c.logger.Error().Err(err).Msg("Failed to change socket permissions")
+		return err
+	}
 
-	// Context for graceful shutdown
-	ctx, cancel := context.WithCancel(context.Background())
-	defer cancel()
+	c.logger.Info().Msgf("Listening for events on %s", c.socketPath)
 
-	go func() {
-		<-ctx.Done() // Wait for context cancellation
+	// Limit the number of concurrent goroutines reading connections
+	semaphore := make(chan struct{}, maxConnections)
+
+	// Connections being read, their events have to be queued before the queue is closed
+	var connections sync.WaitGroup
+	defer connections.Wait()
+
+	// Closing the listener stops accepting new connections
+	stop := context.AfterFunc(ctx, func() {
 		listener.Close()
-	}()
+	})
+	defer stop()
 
 	for {
 		conn, err := listener.Accept()
@@ -262,9 +445,11 @@ func (c *Collector) collectCommandInformation() error {
 		}
 
 		semaphore <- struct{}{} // Acquire
+		connections.Add(1)
 		go func(conn net.Conn) {
 			defer func() {
 				<-semaphore // Release
+				connections.Done()
 			}()
 			if err := c.handleSocketCollection(conn); err != nil {
 				c.logger.Error().Err(err).Msg("Error handling socket collection")
@@ -275,103 +460,294 @@ func (c *Collector) collectCommandInformation() error {
 
 func (c *Collector) handleSocketCollection(con net.Conn) error {
 	defer con.Close()
-	var buf [1024]byte
-	n, err := con.Read(buf[:])
-	if err != nil {
-		c.logger.Error().Err(err).Msg("Error reading from socket")
+
+	if err := c.verifyPeer(con); err != nil {
+		c.logger.Error().Err(err).Msg("Rejected socket connection")
+		return err
+	}
+
+	if err := con.SetReadDeadline(time.Now().Add(readTimeout)); err != nil {
+		c.logger.Error().Err(err).Msg("Failed to set socket read deadline")
 		return err
 	}
 
-	data := string(buf[:n])
-	parts := strings.Split(data, "|")
+	var reader io.Reader = con
+	var raw bytes.Buffer
+	if c.recorder != nil {
+		reader = io.TeeReader(con, &raw)
+	}
 
-	c.logger.Debug().Msgf("Received: %s", string(buf[:n]))
+	event, err := ReadEvent(reader)
 
-	if len(parts) != 7 {
-		c.logger.Error().Msg("Invalid command format")
-		return fmt.Errorf("invalid command format")
+	// Messages that fail to decode are recorded too, so the failure can be reproduced
+	if c.recorder != nil {
+		var recorded *Event
+		var devContext *DevContext
+		if err == nil {
+			recorded = &event
+			if event.Type == StartEvent {
+				devContext = c.recordContext(event)
+			}
+		}
+		if recordErr := c.recorder.record(c.clock.Now(), raw.Bytes(), recorded, devContext); recordErr != nil {
+			c.logger.Error().Err(recordErr).Msg("Failed to record message")
+		}
+	}
+
+	if err != nil {
+		c.logger.Error().Err(err).Msg("Error reading from socket")
+		return err
 	}
 
-	if parts[0] == "start" {
-		if err := c.handleStartCommand(parts); err != nil {
-			c.logger.Error().Err(err).Msg("Error handling start command")
+	// The raw event is not logged, as the command can contain secrets that are only redacted later
+	c.logger.Debug().Msgf("Received %s event for %s", event.Type, event.UUID)
+	event.ReceivedAt = c.clock.Now().UnixMilli()
+
+	switch event.Type {
+	case StartEvent:
+		c.recordEvent()
+		c.queue.push(&queuedEvent{event: event})
+	case EndEvent:
+		c.recordEvent()
+		// Without a terminal notification nothing is written back, the shell is released once the event is queued
+		if !c.notifier.WritesToTerminal() {
+			c.queue.push(&queuedEvent{event: event})
+			break
 		}
-	} else if parts[0] == "end" {
-		if err := c.handleEndCommand(parts); err != nil {
-			c.logger.Error().Err(err).Msg("Error handling end command")
+		// The shell hook briefly waits for the end to be processed, so the terminal notification can be written back
+		item := &queuedEvent{event: event, terminal: con, done: make(chan struct{})}
+		if c.queue.push(item) {
+			select {
+			case <-item.done:
+			case <-time.After(replyTimeout):
+				c.logger.Debug().Msgf("End event %s is still queued, releasing the shell", event.UUID)
+			}
 		}
-	} else {
+	case StatusRequest, PauseRequest, ResumeRequest, FlushRequest:
+		if err := c.handleControl(con, event); err != nil {
+			c.logger.Error().Err(err).Msg("Error handling control request")
+			return err
+		}
+	default:
 		c.logger.Error().Msg("Invalid command format")
-		return err
+		return fmt.Errorf("invalid event type: %s", event.Type)
 	}
 
 	return nil
 }
 
-func (c *Collector) handleStartCommand(parts []string) error {
-	if !IsCommandAcceptable(parts[1], c.excludeRegex) {
-		c.logger.Debug().Msg("Command is not acceptable")
-		return fmt.Errorf("command is not acceptable")
+// processEvents processes the queued events until the queue is closed and drained
+func (c *Collector) processEvents() {
+	for {
+		item, ok := c.queue.pop()
+		if !ok {
+			return
+		}
+
+		switch item.event.Type {
+		case StartEvent:
+			if err := c.handleStartCommand(item.event); err != nil {
+				c.logger.Error().Err(err).Msg("Error handling start command")
+			}
+		case EndEvent:
+			if err := c.handleEndCommand(item.event, item.terminal); err != nil {
+				c.logger.Error().Err(err).Msg("Error handling end command")
+			}
+		}
+
+		c.queue.done(item)
 	}
+}
 
-	c.logger.Debug().Msgf("Parsing command: %s", parts[0])
+// logQueueStats periodically logs the counters of the event queue while events are received
+func (c *Collector) logQueueStats(ctx context.Context) {
+	ticker := time.NewTicker(queueStatsInterval)
+	defer ticker.Stop()
 
-	repo, err := util.GetRepoNameFromConfig(parts[2])
-	if err != nil {
-		c.logger.Error().Err(err).Msg("Failed to get repository name")
+	var lastReceived int64
+	for {
+		select {
+		case <-ctx.Done():
+			c.logger.Info().Msgf("Event queue: %s", c.queue.snapshot())
+			return
+		case <-ticker.C:
+			stats := c.queue.snapshot()
+			if stats.Received != lastReceived {
+				c.logger.Info().Msgf("Event queue: %s", stats)
+				lastReceived = stats.Received
+			}
+		}
+	}
+}
+
+func (c *Collector) handleStartCommand(event Event) error {
+	c.logger.Debug().Msgf("Parsing command: %s", event.Type)
+
+	now := c.receivedAt(event)
+
+	var paused bool
+	c.do(func(state *collectorState) {
+		if paused = state.isPaused(now); paused {
+			state.exclude(event.UUID, now)
+			delete(state.recordedContexts, event.UUID)
+		}
+	})
+	if paused {
+		c.logger.Debug().Msgf("Collection is paused, ignoring command %s", event.UUID)
+		return nil
 	}
 
 	command := Command{
-		Category:   ParseCommand(parts[1]),
-		Command:    parts[1],
-		Directory:  parts[2],
-		User:       parts[3],
-		StartTime:  time.Now().UnixMilli(), // TODO: there are some issues with sending time through shell because of ms support on MAC, explore more
-		Repository: repo,
+		Command:   event.Command,
+		Directory: event.Directory,
+		User:      event.User,
+		UUID:      event.UUID,
+		ShellPID:  event.ShellPID,
+		TTY:       event.TTY,
+		StartTime: now.UnixMilli(), // TODO: there are some issues with sending time through shell because of ms support on MAC, explore more
 	}
 
-	c.collectionConfig.ongoingCommands[parts[4]] = command
+	c.enrichContext(&command, event)
 
-	c.onStartCommand()
+	// Filters are evaluated on the original command, as users write their rules against what they type
+	if !c.filter.Accepts(command) {
+		c.logger.Debug().Msgf("Command %s is excluded from collection", event.UUID)
+		c.do(func(state *collectorState) {
+			state.exclude(event.UUID, now)
+		})
+		return nil
+	}
+
+	c.redactAndCategorize(&command)
+
+	if session, ok := sessionFromEvent(event, time.UnixMilli(command.StartTime)); ok {
+		command.SessionID = session.SessionID
+		if err := c.store.UpsertSession(session); err != nil {
+			c.logger.Error().Err(err).Msg("Failed to record session")
+		}
+	}
+
+	if err := c.store.InsertOngoingCommand(command); err != nil {
+		c.logger.Error().Err(err).Msg("Failed to persist ongoing command")
+	}
+
+	c.hooks.Dispatch(hook.StartEvent, command)
+
+	c.do(func(state *collectorState) {
+		c.startTracking(state, command)
+	})
+
+	// Initial sample for every command, so short commands get linked to their processes too
+	if err := c.collectOnce(); err != nil {
+		c.logger.Error().Err(err).Msg("Failed to collect system information")
+	}
 
 	return nil
 }
 
-func (c *Collector) handleEndCommand(parts []string) error {
+// redactAndCategorize masks the secrets of the command and assigns its category, subcommand and mode rule.
+// Secrets are masked first, so they never reach the database, the remote server or the categories.
+func (c *Collector) redactAndCategorize(command *Command) {
+	commandText, findings := c.redactor.Redact(command.Command)
+	if len(findings) > 0 {
+		c.logger.Debug().Msgf("Redacted %d secrets from command %s", len(findings), command.UUID)
+	}
+
+	categorized := c.categorizer.Categorize(commandText)
+	command.Command = commandText
+	command.Category = categorized.Category
+	command.Subcommand = categorized.Subcommand
+	command.Mode = categorized.Mode
+}
 
-	if !IsCommandAcceptable(parts[1], c.excludeRegex) {
-		c.logger.Debug().Msg("Command is not acceptable")
-		return fmt.Errorf("command is not acceptable")
+// handleEndCommand finishes the command, terminal notifications are written to the terminal writer
+func (c *Collector) handleEndCommand(event Event, terminal io.Writer) error {
+	c.logger.Debug().Msgf("Parsing command: %s", event.Type)
+
+	var excluded, exists bool
+	var command Command
+	c.do(func(state *collectorState) {
+		_, excluded = state.excludedCommands[event.UUID]
+		delete(state.excludedCommands, event.UUID)
+		command, exists = state.ongoingCommands[event.UUID]
+	})
+
+	if excluded {
+		c.logger.Debug().Msgf("Command %s is excluded from collection", event.UUID)
+		return nil
 	}
 
-	c.logger.Debug().Msgf("Parsing command: %s", parts[0])
+	if !exists {
+		c.logger.Error().Msg("Matching start command not found")
+		return fmt.Errorf("matching start command not found")
+	}
 
-	if command, exists := c.collectionConfig.ongoingCommands[parts[4]]; exists {
-		command.EndTime = time.Now().UnixMilli()
-		command.ExecutionTime = command.EndTime - command.StartTime
-		command.Result = parts[5]
-		command.Status = parts[6]
+	command.Status = event.Status
+	command.Result, command.ExitCode, command.Signal = classifyExit(event.Status, event.Result)
 
-		c.logger.Debug().Msgf("Command: %+v", command)
-		if err := InsertCommand(command); err != nil {
-			c.logger.Error().Err(err).Msg("Failed to insert command")
-			return err
-		}
+	return c.finishCommand(event.UUID, command, c.receivedAt(event), terminal)
+}
 
-		delete(c.collectionConfig.ongoingCommands, parts[4])
-		c.onEndCommand()
+// receivedAt is when the collector received the event, events that waited in the queue or in the spill file
+// are timed by it instead of by the time they are processed
+func (c *Collector) receivedAt(event Event) time.Time {
+	if event.ReceivedAt > 0 {
+		return time.UnixMilli(event.ReceivedAt)
+	}
 
-		if c.client != nil {
-			go func() {
-				if err := c.client.SendCommands([]*gen.Command{MapCommandToProto(command)}, c.protoAuthConfig); err != nil {
-					c.logger.Error().Err(err).Msg("Failed to send command")
-				}
-			}()
+	return c.clock.Now()
+}
+
+// finishCommand stores the finished command, removes it from the in-flight commands and sends it remotely
+func (c *Collector) finishCommand(uuid string, command Command, endTime time.Time, terminal io.Writer) error {
+	var exists bool
+	c.do(func(state *collectorState) {
+		exists = c.stopTracking(state, uuid)
+	})
+	if !exists {
+		return fmt.Errorf("command %s already finished", uuid)
+	}
+
+	command.EndTime = endTime.UnixMilli()
+	command.ExecutionTime = command.EndTime - command.StartTime
+
+	sampled := c.applyResourceUsage(&command)
+	command.Mode = c.classifyMode(command, sampled)
+
+	c.logger.Debug().Msgf("Command: %+v", command)
+
+	if err := c.store.DeleteOngoingCommand(uuid); err != nil {
+		c.logger.Error().Err(err).Msg("Failed to delete ongoing command")
+	}
+
+	c.hooks.Dispatch(hook.EndEvent, command)
+	// Notified before the command is stored, so it isn't part of its own history
+	c.notifyCompletion(command, terminal)
+
+	if err := c.store.InsertCommand(command); err != nil {
+		c.logger.Error().Err(err).Msg("Failed to insert command")
+		return err
+	}
+
+	components := c.decomposeCommand(command)
+	if err := c.store.InsertCommandComponents(components); err != nil {
+		c.logger.Error().Err(err).Msg("Failed to insert command components")
+	}
+
+	if command.SessionID != "" {
+		if err := c.store.TouchSession(command.SessionID, command.EndTime); err != nil {
+			c.logger.Error().Err(err).Msg("Failed to update session")
+		}
+	}
+
+	protoCommand := MapCommandToProto(command)
+	protoCommand.Components = MapComponentsToProto(components)
+	if c.client != nil && command.SessionID != "" {
+		if session, err := c.store.GetSession(command.SessionID); err == nil {
+			protoCommand.Session = MapSessionToProto(*session)
 		}
-	} else {
-		c.logger.Error().Msg("Matching start command not found")
-		return fmt.Errorf("matching start command not found")
 	}
+	c.sendCommands([]*gen.Command{protoCommand})
 
 	return nil
 }
@@ -1,19 +1,32 @@
 package collector
 
 import (
-	"regexp"
+	"fmt"
 	"time"
 
-	"github.com/devzero-inc/local-developer-analytics/config"
+	"github.com/devzero-inc/local-developer-analytics/category"
 	"github.com/devzero-inc/local-developer-analytics/database"
 	gen "github.com/devzero-inc/local-developer-analytics/gen/api/v1"
 	"github.com/devzero-inc/local-developer-analytics/logging"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// ResultAbandoned is the result of a command that never reported its end, e.g. because the shell was killed
+const ResultAbandoned = "abandoned"
+
+const (
+	// SourceShell is the source of the commands reported by the shell hooks
+	SourceShell = "shell"
+	// SourceHistory is the source of the commands imported from a shell history file
+	SourceHistory = "history"
 )
 
 // Command is the model for command
 type Command struct {
 	Id            int64  `json:"id" db:"id"`
 	Category      string `json:"category" db:"category"`
+	Subcommand    string `json:"subcommand" db:"subcommand"`
 	Command       string `json:"command" db:"command"`
 	User          string `json:"user" db:"user"`
 	Directory     string `json:"directory" db:"directory"`
@@ -23,6 +36,32 @@ type Command struct {
 	Status        string `json:"status" db:"status"`
 	Result        string `json:"result" db:"result"`
 	Repository    string `json:"repository" db:"repository"`
+	GitBranch     string `json:"git_branch" db:"git_branch"`
+	GitCommit     string `json:"git_commit" db:"git_commit"`
+	GitDirty      bool   `json:"git_dirty" db:"git_dirty"`
+	VirtualEnv    string `json:"virtual_env" db:"virtual_env"`
+	NodeVersion   string `json:"node_version" db:"node_version"`
+	KubeContext   string `json:"kube_context" db:"kube_context"`
+	DirenvDir     string `json:"direnv_dir" db:"direnv_dir"`
+	UUID          string `json:"uuid" db:"uuid"`
+	ShellPID      int64  `json:"shell_pid" db:"shell_pid"`
+	// CPUTime, PeakRSS, ReadBytes and WriteBytes are the resource usage totals of the spawned processes
+	CPUTime    float64 `json:"cpu_time" db:"cpu_time"`
+	PeakRSS    int64   `json:"peak_rss" db:"peak_rss"`
+	ReadBytes  int64   `json:"read_bytes" db:"read_bytes"`
+	WriteBytes int64   `json:"write_bytes" db:"write_bytes"`
+	// Mode is batch, interactive or service, see the category modes
+	Mode string `json:"mode" db:"mode"`
+	// TTY is the terminal of the shell that executed the command, empty without a terminal
+	TTY string `json:"tty" db:"tty"`
+	// ExitCode is the exit code reported by the shell, Status keeps it as it was reported
+	ExitCode int `json:"exit_code" db:"exit_code"`
+	// Signal is the name of the signal that killed the command, e.g. SIGINT
+	Signal string `json:"signal" db:"signal"`
+	// SessionID is the terminal session that executed the command, empty when the shell didn't report its PID
+	SessionID string `json:"session_id" db:"session_id"`
+	// Source is where the command comes from, imported commands have no end time when their duration is unknown
+	Source string `json:"source" db:"source"`
 }
 
 // GetCommandById fetches a command by its ID
@@ -38,17 +77,20 @@ func GetCommandById(id int64) (*Command, error) {
 	return &command, nil
 }
 
-// GetAllCommandsForPeriod fetches all commands for a given period
-func GetAllCommandsForPeriod(start int64, end int64) ([]*Command, error) {
+// GetAllCommandsForPeriod fetches all commands for a given period, limited to the given modes if there are any
+func GetAllCommandsForPeriod(start int64, end int64, modes ...string) ([]*Command, error) {
 	var commands []*Command
 
-	query := `SELECT id, category, SUM(execution_time) AS execution_time 
+	query, args, err := withModes(`SELECT id, category, SUM(execution_time) AS execution_time 
               FROM commands 
-              WHERE start_time BETWEEN ? AND ? 
+              WHERE start_time BETWEEN ? AND ? %s
               GROUP BY category 
-              ORDER BY category ASC, SUM(execution_time) DESC;`
+              ORDER BY category ASC, SUM(execution_time) DESC;`, []interface{}{start, end}, modes)
+	if err != nil {
+		return nil, err
+	}
 
-	if err := database.DB.Select(&commands, query, start, end); err != nil {
+	if err := database.DB.Select(&commands, query, args...); err != nil {
 		logging.Log.Err(err).Msg("Failed to get aggregated commands with start and end times")
 		return nil, err
 	}
@@ -56,17 +98,21 @@ func GetAllCommandsForPeriod(start int64, end int64) ([]*Command, error) {
 	return commands, nil
 }
 
-// GetAllCommandsForCategoryForPeriod fetches all commands for a given category and period
-func GetAllCommandsForCategoryForPeriod(category string, start int64, end int64) ([]Command, error) {
+// GetAllCommandsForCategoryForPeriod fetches all commands for a given category and period, limited to the given modes if there are any
+func GetAllCommandsForCategoryForPeriod(category string, start int64, end int64, modes ...string) ([]Command, error) {
 	var commands []Command
 
-	query := `SELECT id, category, command, SUM(execution_time) AS execution_time 
+	query, args, err := withModes(`SELECT id, category, command, SUM(execution_time) AS execution_time,
+              SUM(cpu_time) AS cpu_time, MAX(peak_rss) AS peak_rss, SUM(read_bytes) AS read_bytes, SUM(write_bytes) AS write_bytes
               FROM commands 
-              WHERE category = ? AND start_time BETWEEN ? AND ? 
+              WHERE category = ? AND start_time BETWEEN ? AND ? %s
               GROUP BY command 
-              ORDER BY command ASC, SUM(execution_time) DESC;`
+              ORDER BY command ASC, SUM(execution_time) DESC;`, []interface{}{category, start, end}, modes)
+	if err != nil {
+		return nil, err
+	}
 
-	if err := database.DB.Select(&commands, query, category, start, end); err != nil {
+	if err := database.DB.Select(&commands, query, args...); err != nil {
 		logging.Log.Err(err).Msg("Failed to get aggregated commands with start and end times")
 		return nil, err
 	}
@@ -74,12 +120,77 @@ func GetAllCommandsForCategoryForPeriod(category string, start int64, end int64)
 	return commands, nil
 }
 
+// ResultCount is the number of commands that finished with the result
+type ResultCount struct {
+	Result string `json:"result" db:"result"`
+	Count  int64  `json:"count" db:"count"`
+}
+
+// GetResultCountsForPeriod counts the commands of a given period by result, limited to the given modes if there are any
+func GetResultCountsForPeriod(start int64, end int64, modes ...string) ([]ResultCount, error) {
+	var counts []ResultCount
+
+	query, args, err := withModes(`SELECT result, COUNT(*) AS count
+              FROM commands
+              WHERE start_time BETWEEN ? AND ? %s
+              GROUP BY result
+              ORDER BY COUNT(*) DESC;`, []interface{}{start, end}, modes)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := database.DB.Select(&counts, query, args...); err != nil {
+		logging.Log.Err(err).Msg("Failed to get result counts with start and end times")
+		return nil, err
+	}
+
+	return counts, nil
+}
+
+// GetExecutionTimes fetches the execution times of the latest runs of the subcommand, newest first
+func GetExecutionTimes(subcommand string, limit int) ([]int64, error) {
+	var executionTimes []int64
+
+	query := `SELECT execution_time FROM commands WHERE subcommand = ? AND ` + knownDuration + ` ORDER BY start_time DESC LIMIT ?`
+
+	if err := database.DB.Select(&executionTimes, query, subcommand, limit); err != nil {
+		logging.Log.Err(err).Msg("Failed to get execution times")
+		return nil, err
+	}
+
+	return executionTimes, nil
+}
+
+// knownDuration is the condition for commands with a known execution time, timing statistics like percentiles
+// leave out the imported commands whose duration wasn't recorded by the shell and the abandoned commands,
+// which only ran until their processes were last seen
+const knownDuration = `(source != '` + SourceHistory + `' OR end_time > 0) AND result != '` + ResultAbandoned + `'`
+
+// withModes fills the `%s` placeholder of the query with a mode condition, no modes means all modes
+func withModes(query string, args []interface{}, modes []string) (string, []interface{}, error) {
+	if len(modes) == 0 {
+		return fmt.Sprintf(query, ""), args, nil
+	}
+
+	query, args, err := sqlx.In(fmt.Sprintf(query, "AND mode IN (?)"), append(args, modes)...)
+	if err != nil {
+		return "", nil, err
+	}
+
+	return database.DB.Rebind(query), args, nil
+}
+
 // DeleteCommandsByDays deletes records older than n days
 func DeleteCommandsByDays(days int) error {
 	// Calculate the time when old records will be deleted
-	timeToDelete := time.Now().AddDate(0, 0, -days).Unix()
+	timeToDelete := time.Now().AddDate(0, 0, -days)
 
-	result, err := database.DB.Exec("DELETE FROM commands WHERE stored_time < ?", timeToDelete)
+	// Components store the start time of their command in milliseconds
+	if _, err := database.DB.Exec("DELETE FROM command_components WHERE start_time < ?", timeToDelete.UnixMilli()); err != nil {
+		return err
+	}
+
+	result, err := database.DB.Exec("DELETE FROM commands WHERE stored_time < ?", timeToDelete.Unix())
 	if err != nil {
 		return err
 	}
@@ -89,47 +200,76 @@ func DeleteCommandsByDays(days int) error {
 	return err
 }
 
+// insertCommandQuery inserts a single command, commands without a source come from the shell hooks
+const insertCommandQuery = `INSERT INTO commands (category, subcommand, command, user, directory, execution_time, start_time, end_time, status, result, repository,
+		git_branch, git_commit, git_dirty, virtual_env, node_version, kube_context, direnv_dir, uuid, shell_pid,
+		cpu_time, peak_rss, read_bytes, write_bytes, mode, tty, exit_code, signal, session_id, source)
+	VALUES (:category, :subcommand, :command, :user, :directory, :execution_time, :start_time, :end_time, :status, :result, :repository,
+		:git_branch, :git_commit, :git_dirty, :virtual_env, :node_version, :kube_context, :direnv_dir, :uuid, :shell_pid,
+		:cpu_time, :peak_rss, :read_bytes, :write_bytes, :mode, :tty, :exit_code, :signal, :session_id, :source)`
+
 // InsertCommand inserts a command into the database
 func InsertCommand(command Command) error {
-	query := `INSERT INTO commands (category, command, user, directory, execution_time, start_time, end_time, status, result, repository)
-	VALUES (:category, :command, :user, :directory, :execution_time, :start_time, :end_time, :status, :result, :repository)`
+	if command.Source == "" {
+		command.Source = SourceShell
+	}
 
-	_, err := database.DB.NamedExec(query, command)
+	_, err := database.DB.NamedExec(insertCommandQuery, command)
 
 	return err
 }
 
-// ParseCommand extracts the command name from a command string.
-func ParseCommand(command string) string {
+// InsertCommands inserts the commands in a single transaction
+func InsertCommands(commands []Command) error {
+	tx, err := database.DB.Beginx()
+	if err != nil {
+		return err
+	}
 
-	// TODO: there might be some other cases as well like: watch, time etc
-	var pattern = regexp.MustCompile(`^(?:sudo|nohup)?\s*(?:\./|/usr/bin/|/bin/|/usr/local/bin/)?([^/ ]+?)(?:\s|$)`)
+	stmt, err := tx.PrepareNamed(insertCommandQuery)
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+	defer stmt.Close()
 
-	matches := pattern.FindStringSubmatch(command)
-	if len(matches) > 1 {
-		return matches[1]
+	for _, command := range commands {
+		if command.Source == "" {
+			command.Source = SourceShell
+		}
+		if _, err := stmt.Exec(command); err != nil {
+			tx.Rollback()
+			return err
+		}
 	}
 
-	return command
+	return tx.Commit()
 }
 
-// IsCommandAcceptable checks if a command string matches a configured regex pattern.
-// Commands that match the regex are considered unacceptable, and it returns false.
-// If the regex is empty or the command does not match, it returns true.
-func IsCommandAcceptable(command string, excludeRegex string) bool {
-	if excludeRegex != "" {
-		logging.Log.Debug().Msgf("Checking if command %s is acceptable for regex: %s", command, config.AppConfig.ExcludeRegex)
-		var pattern = regexp.MustCompile(excludeRegex)
-		return !pattern.MatchString(command)
+// GetCommandsStartedBetween fetches the command lines and start times of the commands started in the period
+func GetCommandsStartedBetween(start int64, end int64) ([]Command, error) {
+	var commands []Command
+
+	query := `SELECT command, start_time FROM commands WHERE start_time BETWEEN ? AND ?`
+
+	if err := database.DB.Select(&commands, query, start, end); err != nil {
+		logging.Log.Err(err).Msg("Failed to get commands started in the period")
+		return nil, err
 	}
 
-	return true
+	return commands, nil
+}
+
+// ParseCommand extracts the command name from a command string using the built-in categorization rules.
+func ParseCommand(command string) string {
+	return category.Default().Categorize(command).Tool
 }
 
 func MapCommandToProto(command Command) *gen.Command {
 	return &gen.Command{
 		Id:            command.Id,
 		Category:      command.Category,
+		Subcommand:    command.Subcommand,
 		Command:       command.Command,
 		User:          command.User,
 		Directory:     command.Directory,
@@ -139,5 +279,19 @@ func MapCommandToProto(command Command) *gen.Command {
 		Status:        command.Status,
 		Result:        command.Result,
 		Repository:    command.Repository,
+		GitBranch:     command.GitBranch,
+		GitCommit:     command.GitCommit,
+		GitDirty:      command.GitDirty,
+		VirtualEnv:    command.VirtualEnv,
+		NodeVersion:   command.NodeVersion,
+		KubeContext:   command.KubeContext,
+		DirenvDir:     command.DirenvDir,
+		CpuTime:       command.CPUTime,
+		PeakRss:       command.PeakRSS,
+		ReadBytes:     command.ReadBytes,
+		WriteBytes:    command.WriteBytes,
+		Mode:          command.Mode,
+		ExitCode:      int32(command.ExitCode),
+		Signal:        command.Signal,
 	}
 }
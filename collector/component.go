@@ -0,0 +1,193 @@
+package collector
+
+import (
+	"github.com/devzero-inc/local-developer-analytics/database"
+	"github.com/devzero-inc/local-developer-analytics/decompose"
+	gen "github.com/devzero-inc/local-developer-analytics/gen/api/v1"
+	"github.com/devzero-inc/local-developer-analytics/logging"
+)
+
+// CommandComponent is a simple command of a compound command line, e.g. `go test ./...` of `go vet && go test ./...`
+type CommandComponent struct {
+	Id          int64  `json:"id" db:"id"`
+	CommandUUID string `json:"command_uuid" db:"command_uuid"`
+	Position    int    `json:"position" db:"position"`
+	// Operator connects the component to the previous one, e.g. `&&` or `|`, empty for the first component
+	Operator string `json:"operator" db:"operator"`
+	// StartOffset and EndOffset are the byte offsets of the component in the command line
+	StartOffset int    `json:"start_offset" db:"start_offset"`
+	EndOffset   int    `json:"end_offset" db:"end_offset"`
+	Command     string `json:"command" db:"command"`
+	Category    string `json:"category" db:"category"`
+	Subcommand  string `json:"subcommand" db:"subcommand"`
+	// ExecutionTime is the share of the command execution time attributed to the component
+	ExecutionTime int64 `json:"execution_time" db:"execution_time"`
+	StartTime     int64 `json:"start_time" db:"start_time"`
+}
+
+// decomposeCommand splits the finished command into its components and categorizes them.
+// Lines that aren't valid bash, like fish syntax, are a single component.
+func (c *Collector) decomposeCommand(command Command) []CommandComponent {
+	parts, err := decompose.Split(command.Command)
+	if err != nil || len(parts) == 0 {
+		c.logger.Debug().Err(err).Msgf("Command is not decomposed: %s", command.Command)
+		parts = decompose.Whole(command.Command)
+	}
+
+	components := make([]CommandComponent, 0, len(parts))
+	for _, part := range parts {
+		categorized := c.categorizer.Categorize(part.Command)
+		components = append(components, CommandComponent{
+			CommandUUID: command.UUID,
+			Position:    part.Position,
+			Operator:    part.Operator,
+			StartOffset: part.Start,
+			EndOffset:   part.End,
+			Command:     part.Command,
+			Category:    categorized.Category,
+			Subcommand:  categorized.Subcommand,
+			StartTime:   command.StartTime,
+		})
+	}
+
+	attributeExecutionTime(components, command.ExecutionTime)
+
+	return components
+}
+
+// attributeExecutionTime splits the execution time between the components. The shells only report the time
+// of the whole line, so every pipeline gets an equal share, which is split between the commands of the pipeline
+// as they run at the same time. The shares add up to the execution time.
+func attributeExecutionTime(components []CommandComponent, executionTime int64) {
+	var pipelines [][]int
+	for i, component := range components {
+		if i > 0 && (component.Operator == decompose.OperatorPipe || component.Operator == decompose.OperatorPipeAll) {
+			pipelines[len(pipelines)-1] = append(pipelines[len(pipelines)-1], i)
+			continue
+		}
+		pipelines = append(pipelines, []int{i})
+	}
+
+	remaining := executionTime
+	for i, pipeline := range pipelines {
+		share := remaining / int64(len(pipelines)-i)
+		remaining -= share
+
+		for j, index := range pipeline {
+			componentShare := share / int64(len(pipeline)-j)
+			share -= componentShare
+			components[index].ExecutionTime = componentShare
+		}
+	}
+}
+
+// InsertCommandComponents inserts the components of a command into the database
+func InsertCommandComponents(components []CommandComponent) error {
+	query := `INSERT INTO command_components (command_uuid, position, operator, start_offset, end_offset, command,
+		category, subcommand, execution_time, start_time)
+	VALUES (:command_uuid, :position, :operator, :start_offset, :end_offset, :command,
+		:category, :subcommand, :execution_time, :start_time)`
+
+	tx, err := database.DB.Beginx()
+	if err != nil {
+		return err
+	}
+
+	stmt, err := tx.PrepareNamed(query)
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+	defer stmt.Close()
+
+	for _, component := range components {
+		if _, err := stmt.Exec(component); err != nil {
+			tx.Rollback()
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
+
+// GetCommandComponents fetches the components of a command in execution order
+func GetCommandComponents(uuid string) ([]CommandComponent, error) {
+	var components []CommandComponent
+
+	query := `SELECT * FROM command_components WHERE command_uuid = ? ORDER BY position ASC`
+
+	if err := database.DB.Select(&components, query, uuid); err != nil {
+		logging.Log.Err(err).Msg("Failed to get command components")
+		return nil, err
+	}
+
+	return components, nil
+}
+
+// GetAllComponentsForPeriod fetches the execution time of the components by category for a given period,
+// limited to the commands of the given modes if there are any
+func GetAllComponentsForPeriod(start int64, end int64, modes ...string) ([]*Command, error) {
+	var commands []*Command
+
+	query, args, err := withModes(`SELECT MIN(command_components.id) AS id, command_components.category AS category,
+              SUM(command_components.execution_time) AS execution_time
+              FROM command_components
+              JOIN commands ON commands.uuid = command_components.command_uuid
+              WHERE command_components.start_time BETWEEN ? AND ? %s
+              GROUP BY command_components.category
+              ORDER BY command_components.category ASC, SUM(command_components.execution_time) DESC;`, []interface{}{start, end}, modes)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := database.DB.Select(&commands, query, args...); err != nil {
+		logging.Log.Err(err).Msg("Failed to get aggregated components with start and end times")
+		return nil, err
+	}
+
+	return commands, nil
+}
+
+// GetAllComponentsForCategoryForPeriod fetches the components of a category for a given period aggregated by
+// their command, limited to the commands of the given modes if there are any. The ID is the one of a command
+// the component is part of.
+func GetAllComponentsForCategoryForPeriod(category string, start int64, end int64, modes ...string) ([]Command, error) {
+	var commands []Command
+
+	query, args, err := withModes(`SELECT MIN(commands.id) AS id, command_components.category AS category,
+              command_components.command AS command, SUM(command_components.execution_time) AS execution_time
+              FROM command_components
+              JOIN commands ON commands.uuid = command_components.command_uuid
+              WHERE command_components.category = ? AND command_components.start_time BETWEEN ? AND ? %s
+              GROUP BY command_components.command
+              ORDER BY command_components.command ASC, SUM(command_components.execution_time) DESC;`, []interface{}{category, start, end}, modes)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := database.DB.Select(&commands, query, args...); err != nil {
+		logging.Log.Err(err).Msg("Failed to get aggregated components with start and end times")
+		return nil, err
+	}
+
+	return commands, nil
+}
+
+// MapComponentsToProto maps the components of a command to their proto messages
+func MapComponentsToProto(components []CommandComponent) []*gen.CommandComponent {
+	protos := make([]*gen.CommandComponent, 0, len(components))
+	for _, component := range components {
+		protos = append(protos, &gen.CommandComponent{
+			Position:      int32(component.Position),
+			Operator:      component.Operator,
+			StartOffset:   int32(component.StartOffset),
+			EndOffset:     int32(component.EndOffset),
+			Command:       component.Command,
+			Category:      component.Category,
+			Subcommand:    component.Subcommand,
+			ExecutionTime: component.ExecutionTime,
+		})
+	}
+
+	return protos
+}
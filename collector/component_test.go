@@ -0,0 +1,75 @@
+package collector
+
+import (
+	"testing"
+
+	"github.com/devzero-inc/local-developer-analytics/database"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAttributeExecutionTime(t *testing.T) {
+	components := []CommandComponent{
+		{Operator: ""},
+		{Operator: "&&"},
+		{Operator: "|"},
+		{Operator: "|"},
+	}
+
+	attributeExecutionTime(components, 1000)
+
+	// Two pipelines get half of the time each, the second one shares it between its three commands
+	assert.Equal(t, []int64{500, 166, 167, 167}, []int64{
+		components[0].ExecutionTime, components[1].ExecutionTime, components[2].ExecutionTime, components[3].ExecutionTime,
+	})
+}
+
+func TestCompoundCommandIsDecomposed(t *testing.T) {
+	setupTestDatabase(t)
+
+	c := newTestCollector()
+	line := "go generate ./... && go test ./... | tee out.log"
+	assert.NoError(t, c.handleStartCommand(Event{Type: StartEvent, Command: line, UUID: "1"}))
+	assert.NoError(t, c.handleEndCommand(Event{Type: EndEvent, UUID: "1", Result: "success", Status: "0"}, nil))
+
+	var stored Command
+	assert.NoError(t, database.DB.Get(&stored, "SELECT * FROM commands WHERE uuid = ?", "1"))
+	assert.Equal(t, line, stored.Command, "the original line is kept")
+
+	components, err := GetCommandComponents("1")
+	assert.NoError(t, err)
+	if assert.Len(t, components, 3) {
+		assert.Equal(t, "go generate ./...", components[0].Command)
+		assert.Equal(t, "", components[0].Operator)
+		assert.Equal(t, "go test", components[1].Subcommand)
+		assert.Equal(t, "&&", components[1].Operator)
+		assert.Equal(t, "tee", components[2].Category)
+		assert.Equal(t, "|", components[2].Operator)
+		assert.Equal(t, line[components[2].StartOffset:components[2].EndOffset], components[2].Command)
+
+		var total int64
+		for _, component := range components {
+			total += component.ExecutionTime
+		}
+		assert.Equal(t, stored.ExecutionTime, total)
+	}
+
+	byCategory, err := GetAllComponentsForPeriod(0, stored.EndTime)
+	assert.NoError(t, err)
+	categories := make(map[string]bool)
+	for _, command := range byCategory {
+		categories[command.Category] = true
+	}
+	assert.True(t, categories["tee"])
+	assert.True(t, categories[components[0].Category])
+
+	// Lines that aren't valid bash are a single component
+	assert.NoError(t, c.handleStartCommand(Event{Type: StartEvent, Command: "for f in *.go; gofmt -l $f; end", UUID: "2"}))
+	assert.NoError(t, c.handleEndCommand(Event{Type: EndEvent, UUID: "2", Result: "success", Status: "0"}, nil))
+
+	components, err = GetCommandComponents("2")
+	assert.NoError(t, err)
+	if assert.Len(t, components, 1) {
+		assert.Equal(t, "for f in *.go; gofmt -l $f; end", components[0].Command)
+	}
+}
@@ -0,0 +1,128 @@
+package collector
+
+import (
+	"bufio"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/devzero-inc/local-developer-analytics/util"
+)
+
+// DevContext is the development context read from the host when a command starts. It is recorded with the
+// start events, so replaying a recording doesn't depend on the state of the host it is replayed on.
+type DevContext struct {
+	Repository  string `json:"repository,omitempty"`
+	GitBranch   string `json:"git_branch,omitempty"`
+	GitCommit   string `json:"git_commit,omitempty"`
+	GitDirty    bool   `json:"git_dirty,omitempty"`
+	KubeContext string `json:"kube_context,omitempty"`
+}
+
+// enrichContext adds the development context (git state and toolchain environment) to the command. The host
+// is only read when the context wasn't recorded already, replays never read it.
+func (c *Collector) enrichContext(command *Command, event Event) {
+	var devContext DevContext
+	var recorded bool
+	c.do(func(state *collectorState) {
+		devContext, recorded = state.recordedContexts[event.UUID]
+		delete(state.recordedContexts, event.UUID)
+	})
+	if !recorded && !c.replaying {
+		devContext = c.hostContext(event)
+	}
+
+	command.Repository = devContext.Repository
+	command.GitBranch = devContext.GitBranch
+	command.GitCommit = devContext.GitCommit
+	command.GitDirty = devContext.GitDirty
+	command.KubeContext = devContext.KubeContext
+
+	command.VirtualEnv = event.VirtualEnv
+	command.NodeVersion = nodeVersionFromNvmBin(event.NvmBin)
+	command.DirenvDir = strings.TrimPrefix(event.DirenvDir, "-")
+}
+
+// hostContext reads the git state of the directory and the kube context of the command from the host
+func (c *Collector) hostContext(event Event) DevContext {
+	var devContext DevContext
+
+	if gitInfo, err := util.GetGitInfo(event.Directory); err != nil {
+		c.logger.Debug().Err(err).Msg("Failed to get git information")
+	} else {
+		devContext.GitBranch = gitInfo.Branch
+		devContext.GitCommit = gitInfo.Commit
+		devContext.GitDirty = gitInfo.Dirty
+
+		// Repositories without a remote have no name, which is common enough not to be an error
+		repo, err := util.GetRepoNameFromConfig(gitInfo.CommonDir)
+		if err != nil {
+			c.logger.Debug().Err(err).Msg("Failed to get repository name")
+		}
+		devContext.Repository = repo
+	}
+
+	kubeContext, err := currentKubeContext(event.Kubeconfig)
+	if err != nil {
+		c.logger.Debug().Err(err).Msg("Failed to get kube context")
+	}
+	devContext.KubeContext = kubeContext
+
+	return devContext
+}
+
+// recordContext reads the development context of the start event when it is received, so it can be recorded
+// and the command uses the same context as the recording
+func (c *Collector) recordContext(event Event) *DevContext {
+	devContext := c.hostContext(event)
+	c.do(func(state *collectorState) {
+		state.recordedContexts[event.UUID] = devContext
+	})
+
+	return &devContext
+}
+
+// nodeVersionFromNvmBin extracts the node version from the NVM_BIN path, e.g. ~/.nvm/versions/node/v20.11.0/bin
+func nodeVersionFromNvmBin(nvmBin string) string {
+	if nvmBin == "" {
+		return ""
+	}
+
+	return filepath.Base(filepath.Dir(filepath.Clean(nvmBin)))
+}
+
+// currentKubeContext reads the current-context from the kubeconfig files, KUBECONFIG
+// can contain multiple files and the first one that sets the current context wins
+func currentKubeContext(kubeconfig string) (string, error) {
+	if kubeconfig == "" {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return "", err
+		}
+		kubeconfig = filepath.Join(home, ".kube", "config")
+	}
+
+	for _, path := range filepath.SplitList(kubeconfig) {
+		file, err := util.Fs.Open(path)
+		if err != nil {
+			continue
+		}
+
+		scanner := bufio.NewScanner(file)
+		for scanner.Scan() {
+			value, ok := strings.CutPrefix(scanner.Text(), "current-context:")
+			if !ok {
+				continue
+			}
+
+			value = strings.Trim(strings.TrimSpace(value), `"'`)
+			if value != "" {
+				file.Close()
+				return value, nil
+			}
+		}
+		file.Close()
+	}
+
+	return "", nil
+}
@@ -0,0 +1,35 @@
+package collector
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/devzero-inc/local-developer-analytics/util"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNodeVersionFromNvmBin(t *testing.T) {
+	assert.Equal(t, "v20.11.0", nodeVersionFromNvmBin("/home/dev/.nvm/versions/node/v20.11.0/bin"))
+	assert.Equal(t, "v18.19.1", nodeVersionFromNvmBin("/home/dev/.nvm/versions/node/v18.19.1/bin/"))
+	assert.Empty(t, nodeVersionFromNvmBin(""))
+}
+
+func TestCurrentKubeContext(t *testing.T) {
+	util.SetupFS()
+
+	dir := t.TempDir()
+	empty := filepath.Join(dir, "empty")
+	assert.NoError(t, os.WriteFile(empty, []byte("apiVersion: v1\nkind: Config\n"), 0600))
+	staging := filepath.Join(dir, "staging")
+	assert.NoError(t, os.WriteFile(staging, []byte("apiVersion: v1\ncurrent-context: \"staging-eu\"\nkind: Config\n"), 0600))
+
+	kubeContext, err := currentKubeContext(empty + string(filepath.ListSeparator) + staging)
+	assert.NoError(t, err)
+	assert.Equal(t, "staging-eu", kubeContext)
+
+	kubeContext, err = currentKubeContext(filepath.Join(dir, "missing"))
+	assert.NoError(t, err)
+	assert.Empty(t, kubeContext)
+}
@@ -0,0 +1,176 @@
+package collector
+
+import (
+	"fmt"
+	"net"
+	"time"
+)
+
+const (
+	// StatusRequest asks the collector for its status
+	StatusRequest = "status"
+	// PauseRequest pauses the collection of commands and processes, optionally for a limited time
+	PauseRequest = "pause"
+	// ResumeRequest resumes a paused collection
+	ResumeRequest = "resume"
+	// FlushRequest resends everything that failed to be sent to the remote server
+	FlushRequest = "flush"
+)
+
+// controlTimeout is the maximum time a control request can take, flushing waits on the remote server
+const controlTimeout = 30 * time.Second
+
+// Status is the reply of the collector to every control request
+type Status struct {
+	// Paused is true while the collection is paused
+	Paused bool `json:"paused"`
+	// PausedUntil is the time in milliseconds the collection resumes by itself, zero when paused until resumed
+	PausedUntil int64 `json:"paused_until,omitempty"`
+	// ActiveCommands is the number of commands in flight
+	ActiveCommands int `json:"active_commands"`
+	// SamplingInterval is the current process sampling interval, the command interval while commands are running
+	SamplingInterval time.Duration `json:"sampling_interval"`
+	// LastEventTime is the time in milliseconds of the last event received from the shells, zero without events
+	LastEventTime int64 `json:"last_event_time,omitempty"`
+	// SendBacklog is the number of commands and processes that haven't been sent to the remote server yet
+	SendBacklog int `json:"send_backlog"`
+	// RemoteCollection is true when the collected data is sent to the remote server
+	RemoteCollection bool `json:"remote_collection"`
+	// Queue are the counters of the event queue
+	Queue QueueStats `json:"queue"`
+	// Error is set when the request failed
+	Error string `json:"error,omitempty"`
+}
+
+// SendControlRequest sends a control request to the collector listening on the socket and waits for its status
+func SendControlRequest(socketPath string, request Event) (Status, error) {
+	conn, err := net.DialTimeout("unix", socketPath, readTimeout)
+	if err != nil {
+		return Status{}, fmt.Errorf("failed to connect to the collector, is it running? %w", err)
+	}
+	defer conn.Close()
+
+	if err := conn.SetDeadline(time.Now().Add(controlTimeout)); err != nil {
+		return Status{}, err
+	}
+
+	frame, err := EncodeFrame(request)
+	if err != nil {
+		return Status{}, err
+	}
+
+	if _, err := conn.Write(frame); err != nil {
+		return Status{}, fmt.Errorf("failed to send %s request: %w", request.Type, err)
+	}
+
+	var status Status
+	if err := readFrame(conn, &status); err != nil {
+		return Status{}, fmt.Errorf("failed to read the collector status: %w", err)
+	}
+
+	if status.Error != "" {
+		return status, fmt.Errorf("%s request failed: %s", request.Type, status.Error)
+	}
+
+	return status, nil
+}
+
+// handleControl executes the control request and replies with the status of the collector
+func (c *Collector) handleControl(con net.Conn, request Event) error {
+	c.logger.Info().Msgf("Received %s request", request.Type)
+
+	var requestErr error
+	switch request.Type {
+	case PauseRequest:
+		c.pause(c.clock.Now(), request.PauseFor)
+	case ResumeRequest:
+		c.resume()
+	case FlushRequest:
+		requestErr = c.flush()
+	}
+
+	status := c.status(c.clock.Now())
+	if requestErr != nil {
+		status.Error = requestErr.Error()
+	}
+
+	frame, err := encodeFrame(status)
+	if err != nil {
+		return err
+	}
+
+	if err := con.SetWriteDeadline(time.Now().Add(readTimeout)); err != nil {
+		return err
+	}
+
+	_, err = con.Write(frame)
+
+	return err
+}
+
+// pause stops the collection of new commands and processes, a zero duration pauses until resumed
+func (c *Collector) pause(now time.Time, duration time.Duration) {
+	c.do(func(state *collectorState) {
+		state.paused = true
+		state.pausedUntil = time.Time{}
+		if duration > 0 {
+			state.pausedUntil = now.Add(duration)
+		}
+	})
+}
+
+// resume continues a paused collection
+func (c *Collector) resume() {
+	c.do(func(state *collectorState) {
+		state.paused = false
+		state.pausedUntil = time.Time{}
+	})
+}
+
+// isPaused checks if the collection is paused, expired pauses are resumed
+func (c *Collector) isPaused(now time.Time) bool {
+	var paused, expired bool
+	c.do(func(state *collectorState) {
+		wasPaused := state.paused
+		paused = state.isPaused(now)
+		expired = wasPaused && !paused
+	})
+
+	if expired {
+		c.logger.Info().Msg("Pause expired, resuming collection")
+	}
+
+	return paused
+}
+
+// recordEvent remembers the time of the last event received from the shells
+func (c *Collector) recordEvent() {
+	now := c.clock.Now()
+	c.do(func(state *collectorState) {
+		state.lastEventTime = now
+	})
+}
+
+// status reports the current state of the collector
+func (c *Collector) status(now time.Time) Status {
+	status := Status{RemoteCollection: c.client != nil}
+	c.do(func(state *collectorState) {
+		status.Paused = state.isPaused(now)
+		status.ActiveCommands = state.activeCommands
+		status.SamplingInterval = state.backgroundInterval
+		if !state.pausedUntil.IsZero() {
+			status.PausedUntil = state.pausedUntil.UnixMilli()
+		}
+		if state.stopSampling != nil {
+			status.SamplingInterval = state.commandInterval
+		}
+		if !state.lastEventTime.IsZero() {
+			status.LastEventTime = state.lastEventTime.UnixMilli()
+		}
+	})
+
+	status.SendBacklog = c.backlogSize()
+	status.Queue = c.queue.snapshot()
+
+	return status
+}
@@ -0,0 +1,139 @@
+package collector
+
+import (
+	"errors"
+	"net"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	gen "github.com/devzero-inc/local-developer-analytics/gen/api/v1"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// fakeClient is a remote client that fails while failing is set
+type fakeClient struct {
+	mutex     sync.Mutex
+	failing   bool
+	commands  []*gen.Command
+	processes []*gen.Process
+}
+
+func (f *fakeClient) SendCommands(commands []*gen.Command, _ *gen.Auth) error {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+
+	if f.failing {
+		return errors.New("server unavailable")
+	}
+	f.commands = append(f.commands, commands...)
+	return nil
+}
+
+func (f *fakeClient) SendProcesses(processes []*gen.Process, _ *gen.Auth) error {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+
+	if f.failing {
+		return errors.New("server unavailable")
+	}
+	f.processes = append(f.processes, processes...)
+	return nil
+}
+
+func TestPauseIgnoresNewCommands(t *testing.T) {
+	setupTestDatabase(t)
+
+	c := newTestCollector()
+	c.pause(time.Now(), 0)
+
+	assert.NoError(t, c.handleStartCommand(Event{Type: StartEvent, Command: "make", UUID: "paused"}))
+	assert.NotContains(t, c.ongoingCommands(), "paused")
+
+	c.resume()
+
+	// The end of a command started while paused is ignored after resuming
+	assert.NoError(t, c.handleEndCommand(Event{Type: EndEvent, Command: "make", UUID: "paused", Status: "0"}, nil))
+
+	assert.NoError(t, c.handleStartCommand(Event{Type: StartEvent, Command: "make", UUID: "resumed"}))
+	assert.Contains(t, c.ongoingCommands(), "resumed")
+}
+
+func TestPauseExpires(t *testing.T) {
+	c := newTestCollector()
+
+	now := time.Now()
+	c.pause(now, time.Hour)
+
+	status := c.status(now)
+	assert.True(t, status.Paused)
+	assert.Equal(t, now.Add(time.Hour).UnixMilli(), status.PausedUntil)
+
+	assert.False(t, c.isPaused(now.Add(2*time.Hour)))
+	assert.Zero(t, c.status(now).PausedUntil)
+}
+
+func TestFlushResendsBacklog(t *testing.T) {
+	remote := &fakeClient{failing: true}
+
+	c := newTestCollector()
+	c.client = remote
+
+	// Failed sends end up in the backlog
+	c.sendCommands([]*gen.Command{{Command: "make"}})
+	c.sendProcesses([]*gen.Process{{Name: "make"}})
+	assert.Eventually(t, func() bool {
+		c.backlog.mutex.Lock()
+		defer c.backlog.mutex.Unlock()
+		return c.backlog.inFlight == 0
+	}, time.Second, 10*time.Millisecond)
+	assert.Equal(t, 2, c.status(time.Now()).SendBacklog)
+
+	assert.Error(t, c.flush())
+	assert.Equal(t, 2, c.backlogSize())
+
+	remote.mutex.Lock()
+	remote.failing = false
+	remote.mutex.Unlock()
+	assert.NoError(t, c.flush())
+	assert.Zero(t, c.backlogSize())
+	assert.Len(t, remote.commands, 1)
+	assert.Len(t, remote.processes, 1)
+}
+
+func TestControlRequestOverSocket(t *testing.T) {
+	socketPath := filepath.Join(t.TempDir(), "lda.sock")
+	listener, err := net.Listen("unix", socketPath)
+	if !assert.NoError(t, err) {
+		return
+	}
+	defer listener.Close()
+
+	c := newTestCollector()
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			_ = c.handleSocketCollection(conn)
+		}
+	}()
+
+	status, err := SendControlRequest(socketPath, Event{Type: PauseRequest, PauseFor: time.Minute})
+	assert.NoError(t, err)
+	assert.True(t, status.Paused)
+	assert.NotZero(t, status.PausedUntil)
+	assert.False(t, status.RemoteCollection)
+
+	status, err = SendControlRequest(socketPath, Event{Type: ResumeRequest})
+	assert.NoError(t, err)
+	assert.False(t, status.Paused)
+
+	status, err = SendControlRequest(socketPath, Event{Type: StatusRequest})
+	assert.NoError(t, err)
+	assert.Zero(t, status.ActiveCommands)
+	assert.Zero(t, status.LastEventTime)
+}
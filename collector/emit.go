@@ -0,0 +1,59 @@
+package collector
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"time"
+)
+
+// EmitTimeout is the default time a shell hook waits on the collector, the prompt is drawn after it
+const EmitTimeout = 500 * time.Millisecond
+
+// Emit sends the event of a shell hook to the collector listening on the socket. The whole exchange is bounded
+// by the timeout, so a stuck collector never holds the prompt. The reply to an end event, a bell and an OSC 9
+// notification when a long-running command finished, is copied to the reply writer until the collector closes
+// the connection or the timeout expires.
+func Emit(socketPath string, event Event, reply io.Writer, timeout time.Duration) error {
+	deadline := time.Now().Add(timeout)
+
+	conn, err := net.DialTimeout("unix", socketPath, timeout)
+	if err != nil {
+		return fmt.Errorf("failed to connect to the collector: %w", err)
+	}
+	defer conn.Close()
+
+	if err := conn.SetDeadline(deadline); err != nil {
+		return err
+	}
+
+	frame, err := EncodeFrame(event)
+	if err != nil {
+		return err
+	}
+
+	if _, err := conn.Write(frame); err != nil {
+		return fmt.Errorf("failed to send %s event: %w", event.Type, err)
+	}
+
+	// Only the end events are answered
+	if event.Type != EndEvent || reply == nil {
+		return nil
+	}
+
+	if unixConn, ok := conn.(*net.UnixConn); ok {
+		if err := unixConn.CloseWrite(); err != nil {
+			return err
+		}
+	}
+
+	// The collector releases the shell after a while even when the event is still queued, a timeout means
+	// the notification is skipped, the event itself was sent
+	if _, err := io.Copy(reply, conn); err != nil && !errors.Is(err, os.ErrDeadlineExceeded) {
+		return fmt.Errorf("failed to read the collector reply: %w", err)
+	}
+
+	return nil
+}
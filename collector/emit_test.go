@@ -0,0 +1,84 @@
+package collector
+
+import (
+	"bytes"
+	"net"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// listen accepts the connections on a socket in the test directory and hands them to the handler
+func listen(t *testing.T, handle func(net.Conn)) string {
+	t.Helper()
+
+	socketPath := filepath.Join(t.TempDir(), "lda.sock")
+	listener, err := net.Listen("unix", socketPath)
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	t.Cleanup(func() { listener.Close() })
+
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			go handle(conn)
+		}
+	}()
+
+	return socketPath
+}
+
+func TestEmitSendsTheEventAndCopiesTheReply(t *testing.T) {
+	received := make(chan Event, 2)
+	socketPath := listen(t, func(conn net.Conn) {
+		defer conn.Close()
+		event, err := ReadEvent(conn)
+		if err != nil {
+			return
+		}
+		received <- event
+		if event.Type == EndEvent {
+			_, _ = conn.Write([]byte("\a\x1b]9;make build finished\x07"))
+		}
+	})
+
+	// Quotes, newlines and shell syntax are sent as they are
+	command := "echo 'it'\"'\"'s' $(whoami)\nprint(\"done\")"
+
+	var reply bytes.Buffer
+	assert.NoError(t, Emit(socketPath, Event{Type: StartEvent, Command: command, UUID: "1"}, &reply, time.Second))
+	assert.Equal(t, command, (<-received).Command)
+	assert.Empty(t, reply.String(), "start events aren't answered")
+
+	assert.NoError(t, Emit(socketPath, Event{Type: EndEvent, Command: command, UUID: "1", Status: "0"}, &reply, time.Second))
+	assert.Equal(t, "1", (<-received).UUID)
+	assert.Equal(t, "\a\x1b]9;make build finished\x07", reply.String())
+}
+
+func TestEmitWithoutCollector(t *testing.T) {
+	start := time.Now()
+	err := Emit(filepath.Join(t.TempDir(), "lda.sock"), Event{Type: StartEvent, UUID: "1"}, nil, time.Second)
+	assert.Error(t, err)
+	assert.Less(t, time.Since(start), 500*time.Millisecond, "a missing collector fails right away")
+}
+
+func TestEmitGivesUpOnAStuckCollector(t *testing.T) {
+	release := make(chan struct{})
+	defer close(release)
+	socketPath := listen(t, func(conn net.Conn) {
+		defer conn.Close()
+		<-release
+	})
+
+	start := time.Now()
+	var reply bytes.Buffer
+	assert.NoError(t, Emit(socketPath, Event{Type: EndEvent, UUID: "1"}, &reply, 100*time.Millisecond))
+	assert.Less(t, time.Since(start), time.Second)
+	assert.Empty(t, reply.String())
+}
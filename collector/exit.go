@@ -0,0 +1,73 @@
+package collector
+
+import (
+	"strconv"
+	"strings"
+	"syscall"
+)
+
+const (
+	// ResultSuccess is the result of a command that exited with 0
+	ResultSuccess = "success"
+	// ResultFailure is the result of a command that exited with any other code
+	ResultFailure = "failure"
+	// ResultInterrupted is the result of a command stopped with Ctrl-C (SIGINT)
+	ResultInterrupted = "interrupted"
+	// ResultKilled is the result of a command killed by any other signal
+	ResultKilled = "killed"
+	// ResultNotFound is the result of a command the shell couldn't find
+	ResultNotFound = "not_found"
+	// ResultPermissionDenied is the result of a command the shell couldn't execute
+	ResultPermissionDenied = "permission_denied"
+	// ResultUnknown is the result of an imported command, the shell histories don't keep the exit codes
+	ResultUnknown = "unknown"
+)
+
+const (
+	// exitNotExecutable is the exit code of the shells for commands that can't be executed
+	exitNotExecutable = 126
+	// exitNotFound is the exit code of the shells for commands that can't be found
+	exitNotFound = 127
+	// exitSignalBase is added by the shells to the number of the signal that killed the command
+	exitSignalBase = 128
+	// maxSignal is the highest signal number, higher exit codes are ordinary failures
+	maxSignal = 64
+)
+
+// Results are all the results a finished command can have
+var Results = []string{
+	ResultSuccess, ResultFailure, ResultInterrupted, ResultKilled, ResultNotFound, ResultPermissionDenied, ResultAbandoned, ResultUnknown,
+}
+
+// classifyExit classifies the exit status reported by the shell. Shells report commands killed by a signal
+// with 128 plus the signal number. Statuses that aren't a number fall back to the result of the shell.
+func classifyExit(status string, result string) (string, int, string) {
+	exitCode, err := strconv.Atoi(strings.TrimSpace(status))
+	if err != nil {
+		if result == "" {
+			result = ResultFailure
+		}
+		return result, 0, ""
+	}
+
+	switch {
+	case exitCode == 0:
+		return ResultSuccess, exitCode, ""
+	case exitCode == exitNotExecutable:
+		return ResultPermissionDenied, exitCode, ""
+	case exitCode == exitNotFound:
+		return ResultNotFound, exitCode, ""
+	case exitCode > exitSignalBase && exitCode <= exitSignalBase+maxSignal:
+		signal := syscall.Signal(exitCode - exitSignalBase)
+		name := signalName(signal)
+		if name == "" {
+			name = "SIG" + strconv.Itoa(int(signal))
+		}
+		if signal == syscall.SIGINT {
+			return ResultInterrupted, exitCode, name
+		}
+		return ResultKilled, exitCode, name
+	default:
+		return ResultFailure, exitCode, ""
+	}
+}
@@ -0,0 +1,60 @@
+package collector
+
+import (
+	"testing"
+
+	"github.com/devzero-inc/local-developer-analytics/database"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestClassifyExit(t *testing.T) {
+	tests := []struct {
+		status   string
+		result   string
+		expected string
+		exitCode int
+		signal   string
+	}{
+		{"0", "success", ResultSuccess, 0, ""},
+		{"1", "failure", ResultFailure, 1, ""},
+		{"2", "failure", ResultFailure, 2, ""},
+		{"126", "failure", ResultPermissionDenied, 126, ""},
+		{"127", "failure", ResultNotFound, 127, ""},
+		{"128", "failure", ResultFailure, 128, ""},
+		{"130", "failure", ResultInterrupted, 130, "SIGINT"},
+		{"137", "failure", ResultKilled, 137, "SIGKILL"},
+		{"143", "failure", ResultKilled, 143, "SIGTERM"},
+		{"255", "failure", ResultFailure, 255, ""},
+		{" 130\n", "failure", ResultInterrupted, 130, "SIGINT"},
+		// Statuses that aren't a number keep the result of the shell
+		{"", "success", ResultSuccess, 0, ""},
+		{"", "", ResultFailure, 0, ""},
+	}
+
+	for _, test := range tests {
+		result, exitCode, signal := classifyExit(test.status, test.result)
+		assert.Equal(t, test.expected, result, test.status)
+		assert.Equal(t, test.exitCode, exitCode, test.status)
+		assert.Equal(t, test.signal, signal, test.status)
+	}
+}
+
+func TestInterruptedCommandIsNotAFailure(t *testing.T) {
+	setupTestDatabase(t)
+
+	c := newTestCollector()
+	assert.NoError(t, c.handleStartCommand(Event{Type: StartEvent, Command: "make build", UUID: "1"}))
+	assert.NoError(t, c.handleEndCommand(Event{Type: EndEvent, UUID: "1", Result: "failure", Status: "130"}, nil))
+
+	var stored Command
+	assert.NoError(t, database.DB.Get(&stored, "SELECT * FROM commands WHERE uuid = ?", "1"))
+	assert.Equal(t, ResultInterrupted, stored.Result)
+	assert.Equal(t, "130", stored.Status)
+	assert.Equal(t, 130, stored.ExitCode)
+	assert.Equal(t, "SIGINT", stored.Signal)
+
+	counts, err := GetResultCountsForPeriod(0, stored.EndTime)
+	assert.NoError(t, err)
+	assert.Equal(t, []ResultCount{{Result: ResultInterrupted, Count: 1}}, counts)
+}
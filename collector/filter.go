@@ -0,0 +1,172 @@
+package collector
+
+import (
+	"fmt"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/devzero-inc/local-developer-analytics/config"
+
+	"github.com/pkg/errors"
+)
+
+const (
+	// FilterInclude collects the commands matching the rule
+	FilterInclude = "include"
+	// FilterExclude drops the commands matching the rule
+	FilterExclude = "exclude"
+)
+
+// filterRule is a compiled include or exclude rule, empty matchers match everything
+type filterRule struct {
+	include    bool
+	command    *regexp.Regexp
+	directory  *regexp.Regexp
+	repository *regexp.Regexp
+	user       string
+}
+
+// Filter decides which commands are collected. Rules are evaluated in order and the first
+// matching rule decides, commands that don't match any rule are collected.
+type Filter struct {
+	rules []filterRule
+}
+
+// NewFilter compiles and validates the rules. The legacy exclude regex is appended as the last exclude rule,
+// and `~` at the beginning of directory globs is expanded to the home directory.
+func NewFilter(rules []config.FilterRule, excludeRegex string, homeDir string) (*Filter, error) {
+	if excludeRegex != "" {
+		rules = append(rules, config.FilterRule{Action: FilterExclude, Command: excludeRegex})
+	}
+
+	filter := &Filter{}
+	for i, rule := range rules {
+		compiled, err := compileFilterRule(rule, homeDir)
+		if err != nil {
+			return nil, errors.Wrapf(err, "invalid filter rule %d", i+1)
+		}
+		filter.rules = append(filter.rules, compiled)
+	}
+
+	return filter, nil
+}
+
+func compileFilterRule(rule config.FilterRule, homeDir string) (filterRule, error) {
+	var compiled filterRule
+
+	switch strings.ToLower(rule.Action) {
+	case FilterInclude:
+		compiled.include = true
+	case FilterExclude:
+	default:
+		return compiled, fmt.Errorf("action must be %q or %q, got %q", FilterInclude, FilterExclude, rule.Action)
+	}
+
+	if rule.Command == "" && rule.Directory == "" && rule.Repository == "" && rule.User == "" {
+		return compiled, fmt.Errorf("at least one of command, directory, repository or user is required")
+	}
+
+	var err error
+	if rule.Command != "" {
+		if compiled.command, err = regexp.Compile(rule.Command); err != nil {
+			return compiled, errors.Wrap(err, "invalid command pattern")
+		}
+	}
+
+	if rule.Directory != "" {
+		directory := rule.Directory
+		if homeDir != "" && (directory == "~" || strings.HasPrefix(directory, "~/")) {
+			directory = filepath.Join(homeDir, strings.TrimPrefix(directory, "~"))
+		}
+		if compiled.directory, err = globToRegexp(directory); err != nil {
+			return compiled, errors.Wrap(err, "invalid directory glob")
+		}
+	}
+
+	if rule.Repository != "" {
+		if compiled.repository, err = globToRegexp(rule.Repository); err != nil {
+			return compiled, errors.Wrap(err, "invalid repository glob")
+		}
+	}
+
+	compiled.user = rule.User
+
+	return compiled, nil
+}
+
+// Accepts checks if the command should be collected
+func (f *Filter) Accepts(command Command) bool {
+	if f == nil {
+		return true
+	}
+
+	for _, rule := range f.rules {
+		if rule.matches(command) {
+			return rule.include
+		}
+	}
+
+	return true
+}
+
+func (r filterRule) matches(command Command) bool {
+	if r.command != nil && !r.command.MatchString(command.Command) {
+		return false
+	}
+	if r.directory != nil && !r.directory.MatchString(filepath.Clean(command.Directory)) {
+		return false
+	}
+	if r.repository != nil && !r.repository.MatchString(command.Repository) {
+		return false
+	}
+	if r.user != "" && r.user != command.User {
+		return false
+	}
+
+	return true
+}
+
+// globToRegexp converts a glob to an anchored regular expression. `*` and `?` don't match `/`,
+// `**` matches across directories, and a trailing `/**` also matches the directory itself.
+func globToRegexp(glob string) (*regexp.Regexp, error) {
+	var builder strings.Builder
+	builder.WriteString("^")
+
+	suffix := "$"
+	if strings.HasSuffix(glob, "/**") {
+		suffix = "(/.*)?$"
+		glob = strings.TrimSuffix(glob, "/**")
+	}
+
+	for i := 0; i < len(glob); i++ {
+		switch c := glob[i]; c {
+		case '*':
+			if i+1 < len(glob) && glob[i+1] == '*' {
+				builder.WriteString(".*")
+				i++
+			} else {
+				builder.WriteString("[^/]*")
+			}
+		case '?':
+			builder.WriteString("[^/]")
+		case '[':
+			end := strings.IndexByte(glob[i:], ']')
+			if end == -1 {
+				return nil, fmt.Errorf("unterminated character class in %q", glob)
+			}
+			class := glob[i+1 : i+end]
+			if strings.HasPrefix(class, "!") {
+				class = "^" + class[1:]
+			}
+			builder.WriteString("[" + class + "]")
+			i += end
+		default:
+			builder.WriteString(regexp.QuoteMeta(glob[i : i+1]))
+		}
+	}
+
+	builder.WriteString(suffix)
+
+	return regexp.Compile(builder.String())
+}
@@ -0,0 +1,103 @@
+package collector
+
+import (
+	"testing"
+	"time"
+
+	"github.com/devzero-inc/local-developer-analytics/config"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGlobToRegexp(t *testing.T) {
+	tests := []struct {
+		glob    string
+		value   string
+		matches bool
+	}{
+		{"/home/dev/private/**", "/home/dev/private", true},
+		{"/home/dev/private/**", "/home/dev/private/a/b", true},
+		{"/home/dev/private/**", "/home/dev/private-other", false},
+		{"/home/dev/*/src", "/home/dev/app/src", true},
+		{"/home/dev/*/src", "/home/dev/app/nested/src", false},
+		{"/home/dev/**/src", "/home/dev/app/nested/src", true},
+		{"api-?", "api-1", true},
+		{"api-?", "api-12", false},
+		{"client-[!x]*", "client-web", true},
+		{"client-[!x]*", "client-xyz", false},
+		{"my.repo", "myXrepo", false},
+	}
+
+	for _, tt := range tests {
+		pattern, err := globToRegexp(tt.glob)
+		if assert.NoError(t, err, tt.glob) {
+			assert.Equal(t, tt.matches, pattern.MatchString(tt.value), "%s against %s", tt.glob, tt.value)
+		}
+	}
+}
+
+func TestFilterFirstMatchingRuleDecides(t *testing.T) {
+	filter, err := NewFilter([]config.FilterRule{
+		{Action: FilterInclude, Directory: "~/private/shared/**"},
+		{Action: FilterExclude, Directory: "~/private/**"},
+		{Action: FilterExclude, Repository: "secret-*"},
+		{Action: FilterExclude, Command: "^ls\\b", User: "dev"},
+	}, "", "/home/dev")
+	assert.NoError(t, err)
+
+	assert.True(t, filter.Accepts(Command{Command: "make", Directory: "/home/dev/private/shared/app"}))
+	assert.False(t, filter.Accepts(Command{Command: "make", Directory: "/home/dev/private/notes"}))
+	assert.False(t, filter.Accepts(Command{Command: "make", Directory: "/work", Repository: "secret-api"}))
+	assert.False(t, filter.Accepts(Command{Command: "ls -la", User: "dev"}))
+	assert.True(t, filter.Accepts(Command{Command: "ls -la", User: "ci"}))
+	assert.True(t, filter.Accepts(Command{Command: "make", Directory: "/work", Repository: "api"}))
+}
+
+func TestFilterLegacyExcludeRegex(t *testing.T) {
+	filter, err := NewFilter([]config.FilterRule{
+		{Action: FilterInclude, Command: "^ssh prod"},
+	}, "^ssh", "")
+	assert.NoError(t, err)
+
+	assert.True(t, filter.Accepts(Command{Command: "ssh prod-1"}))
+	assert.False(t, filter.Accepts(Command{Command: "ssh staging"}))
+	assert.True(t, filter.Accepts(Command{Command: "make"}))
+}
+
+func TestFilterRejectsInvalidRules(t *testing.T) {
+	invalid := []config.FilterRule{
+		{Action: "drop", Command: "make"},
+		{Action: FilterExclude},
+		{Action: FilterExclude, Command: "(unclosed"},
+		{Action: FilterExclude, Directory: "/home/[abc"},
+	}
+
+	for _, rule := range invalid {
+		_, err := NewFilter([]config.FilterRule{rule}, "", "")
+		assert.Error(t, err, "%+v", rule)
+	}
+
+	_, err := NewFilter(nil, "(unclosed", "")
+	assert.Error(t, err)
+}
+
+func TestExcludedCommandsAreNotStored(t *testing.T) {
+	setupTestDatabase(t)
+
+	filter, err := NewFilter([]config.FilterRule{{Action: FilterExclude, Command: "^vault "}}, "", "")
+	assert.NoError(t, err)
+
+	c := newTestCollector()
+	c.filter = filter
+
+	assert.NoError(t, c.handleStartCommand(Event{Type: StartEvent, Command: "vault read secret/db", UUID: "uuid-1"}))
+	assert.NotContains(t, c.ongoingCommands(), "uuid-1")
+	assert.Equal(t, 0, activeCommands(c))
+
+	assert.NoError(t, c.handleEndCommand(Event{Type: EndEvent, Command: "vault read secret/db", UUID: "uuid-1", Status: "0"}, nil))
+	assert.NotContains(t, excludedCommands(c), "uuid-1")
+
+	commands, err := GetAllCommandsForPeriod(0, time.Now().UnixMilli())
+	assert.NoError(t, err)
+	assert.Empty(t, commands)
+}
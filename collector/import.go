@@ -0,0 +1,124 @@
+package collector
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+
+	"github.com/devzero-inc/local-developer-analytics/history"
+)
+
+// ImportStats are the counters of a history import
+type ImportStats struct {
+	// Imported is the number of stored commands, Duplicates the number of commands that were already stored
+	Imported   int
+	Duplicates int
+	// Excluded is the number of commands rejected by the filters, Untimed the number of commands without a timestamp
+	Excluded int
+	Untimed  int
+}
+
+// ImportHistory stores the commands of a shell history. Commands go through the same filters, redaction and
+// categorization as the collected ones, commands that are already stored with the same start second are skipped,
+// so importing the same history twice doesn't duplicate it. Histories don't record the exit codes, and the
+// durations only when the shell keeps them, so the commands without a duration are left out of the timing stats.
+func (c *Collector) ImportHistory(shell string, entries []history.Entry, userName string) (ImportStats, error) {
+	var stats ImportStats
+
+	var commands []Command
+	var minStart, maxStart int64
+	for _, entry := range entries {
+		if entry.Time.IsZero() {
+			stats.Untimed++
+			continue
+		}
+
+		command := Command{
+			Command:   entry.Command,
+			User:      userName,
+			UUID:      importUUID(shell, entry),
+			StartTime: entry.Time.UnixMilli(),
+			Result:    ResultUnknown,
+			Source:    SourceHistory,
+		}
+
+		if !c.filter.Accepts(command) {
+			stats.Excluded++
+			continue
+		}
+
+		c.redactAndCategorize(&command)
+
+		if entry.HasDuration {
+			command.ExecutionTime = entry.Duration.Milliseconds()
+			command.EndTime = command.StartTime + command.ExecutionTime
+		}
+		command.Mode = c.classifyMode(command, false)
+
+		if len(commands) == 0 || command.StartTime < minStart {
+			minStart = command.StartTime
+		}
+		if command.StartTime > maxStart {
+			maxStart = command.StartTime
+		}
+		commands = append(commands, command)
+	}
+
+	if len(commands) == 0 {
+		return stats, nil
+	}
+
+	// Shells report the start with a second precision, the collector with milliseconds
+	existing, err := c.store.GetCommandsStartedBetween(minStart, maxStart+999)
+	if err != nil {
+		c.logger.Error().Err(err).Msg("Failed to get existing commands")
+		return stats, err
+	}
+
+	seen := make(map[string]struct{}, len(existing)+len(commands))
+	for _, command := range existing {
+		seen[importKey(command)] = struct{}{}
+	}
+
+	imported := make([]Command, 0, len(commands))
+	for _, command := range commands {
+		key := importKey(command)
+		if _, ok := seen[key]; ok {
+			stats.Duplicates++
+			continue
+		}
+		seen[key] = struct{}{}
+		imported = append(imported, command)
+	}
+
+	if len(imported) == 0 {
+		return stats, nil
+	}
+
+	if err := c.store.InsertCommands(imported); err != nil {
+		c.logger.Error().Err(err).Msg("Failed to insert imported commands")
+		return stats, err
+	}
+	stats.Imported = len(imported)
+
+	var components []CommandComponent
+	for _, command := range imported {
+		components = append(components, c.decomposeCommand(command)...)
+	}
+	if err := c.store.InsertCommandComponents(components); err != nil {
+		c.logger.Error().Err(err).Msg("Failed to insert command components")
+	}
+
+	return stats, nil
+}
+
+// importKey identifies a command by its line and start second
+func importKey(command Command) string {
+	return fmt.Sprintf("%d|%s", command.StartTime/1000, command.Command)
+}
+
+// importUUID derives the UUID of an imported command from the history entry, so it is stable across imports
+func importUUID(shell string, entry history.Entry) string {
+	sum := sha256.Sum256([]byte(fmt.Sprintf("%s|%d|%s", shell, entry.Time.Unix(), entry.Command)))
+	return "history-" + hex.EncodeToString(sum[:16])
+}
@@ -0,0 +1,73 @@
+package collector
+
+import (
+	"testing"
+	"time"
+
+	"github.com/devzero-inc/local-developer-analytics/config"
+	"github.com/devzero-inc/local-developer-analytics/database"
+	"github.com/devzero-inc/local-developer-analytics/history"
+	"github.com/devzero-inc/local-developer-analytics/redact"
+
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/assert"
+)
+
+func importedCommands(t *testing.T) []Command {
+	t.Helper()
+
+	var commands []Command
+	assert.NoError(t, database.DB.Select(&commands, `SELECT command, user, category, subcommand, result, source, start_time FROM commands ORDER BY start_time`))
+	return commands
+}
+
+func TestImportHistory(t *testing.T) {
+	setupTestDatabase(t)
+
+	filter, err := NewFilter([]config.FilterRule{{Action: FilterExclude, Command: "^secret-tool"}}, "", "/home/dev")
+	assert.NoError(t, err)
+
+	c := NewCollector("", nil, zerolog.Nop(), IntervalConfig{}, QueueConfig{}, AuthConfig{}, filter, noopProcess{},
+		nil, redact.Default(), nil, nil, nil, nil)
+
+	start := time.Unix(1700000000, 0)
+	entries := []history.Entry{
+		{Command: "go test ./...", Time: start, Duration: 12 * time.Second, HasDuration: true},
+		{Command: "go test ./...", Time: start.Add(30 * time.Minute), HasDuration: true},
+		{Command: "go test ./...", Time: start.Add(time.Hour)},
+		{Command: "secret-tool lookup token", Time: start.Add(2 * time.Hour)},
+		{Command: "ls -la"},
+		// The same command twice in the same second is a single command
+		{Command: "go test ./...", Time: start},
+	}
+
+	stats, err := c.ImportHistory(history.Zsh, entries, "dev")
+	assert.NoError(t, err)
+	assert.Equal(t, ImportStats{Imported: 3, Duplicates: 1, Excluded: 1, Untimed: 1}, stats)
+
+	commands := importedCommands(t)
+	if assert.Len(t, commands, 3) {
+		for _, command := range commands {
+			assert.Equal(t, SourceHistory, command.Source)
+			assert.Equal(t, ResultUnknown, command.Result)
+			assert.Equal(t, "dev", command.User)
+			assert.Equal(t, "test", command.Category)
+		}
+	}
+
+	// Only the imported commands with a duration count towards the timing stats, including the ones zsh
+	// recorded as shorter than a second
+	times, err := GetExecutionTimes(commands[0].Subcommand, 10)
+	assert.NoError(t, err)
+	assert.Equal(t, []int64{0, 12000}, times)
+
+	// Importing again and importing commands collected by the shell hooks doesn't duplicate them
+	assert.NoError(t, InsertCommand(Command{Command: "make build", UUID: "live", StartTime: start.Add(3*time.Hour).UnixMilli() + 250}))
+
+	stats, err = c.ImportHistory(history.Zsh, append(entries, history.Entry{Command: "make build", Time: start.Add(3 * time.Hour)}), "dev")
+	assert.NoError(t, err)
+	assert.Equal(t, ImportStats{Duplicates: 5, Excluded: 1, Untimed: 1}, stats)
+
+	commands = importedCommands(t)
+	assert.Len(t, commands, 4)
+}
@@ -0,0 +1,101 @@
+package collector
+
+import (
+	"github.com/devzero-inc/local-developer-analytics/process"
+)
+
+// linkCommandProcesses links the sampled processes to the ongoing commands whose shell spawned them
+func (c *Collector) linkCommandProcesses(processes []process.Process) {
+	commands := make(map[string]Command)
+	c.do(func(state *collectorState) {
+		for uuid, command := range state.ongoingCommands {
+			if command.ShellPID > 0 {
+				commands[uuid] = command
+			}
+		}
+	})
+
+	if len(commands) == 0 {
+		return
+	}
+
+	var links []process.CommandProcess
+	for uuid, command := range commands {
+		for _, p := range descendantProcesses(processes, command.ShellPID, commandStartSecond(command)) {
+			links = append(links, process.CommandProcess{
+				CommandUUID: uuid,
+				PID:         p.PID,
+				StoredTime:  p.StoredTime,
+			})
+		}
+	}
+
+	if len(links) == 0 {
+		return
+	}
+
+	if err := c.store.InsertCommandProcesses(links); err != nil {
+		c.logger.Error().Err(err).Msg("Failed to link processes to commands")
+	}
+}
+
+// commandStartSecond is the start of the command truncated to the second, as ps only reports the
+// creation time of the processes to the second
+func commandStartSecond(command Command) int64 {
+	return command.StartTime - command.StartTime%1000
+}
+
+// descendantProcesses walks the PPID tree and returns every process descending from the root PID that was
+// created since the given time in milliseconds, the root itself is not included. Processes created before,
+// like background jobs of previous commands or prompt helpers, are skipped together with their children.
+func descendantProcesses(processes []process.Process, rootPID int64, since int64) []process.Process {
+	children := make(map[int64][]process.Process)
+	for _, p := range processes {
+		// PID 0 and self-parented processes would make the tree cyclic
+		if p.PID == p.PPID {
+			continue
+		}
+		children[p.PPID] = append(children[p.PPID], p)
+	}
+
+	var descendants []process.Process
+	visited := map[int64]bool{rootPID: true}
+	queue := []int64{rootPID}
+
+	for len(queue) > 0 {
+		pid := queue[0]
+		queue = queue[1:]
+
+		for _, child := range children[pid] {
+			if visited[child.PID] || child.CreatedTime < since {
+				continue
+			}
+			visited[child.PID] = true
+			descendants = append(descendants, child)
+			queue = append(queue, child.PID)
+		}
+	}
+
+	return descendants
+}
+
+// applyResourceUsage sets the resource usage totals of the processes spawned by the command, it tells if any
+// of them was sampled
+func (c *Collector) applyResourceUsage(command *Command) bool {
+	if command.UUID == "" || command.ShellPID <= 0 {
+		return false
+	}
+
+	usage, err := c.store.GetResourceUsageForCommand(command.UUID)
+	if err != nil {
+		c.logger.Error().Err(err).Msg("Failed to compute command resource usage")
+		return false
+	}
+
+	command.CPUTime = usage.CPUTime
+	command.PeakRSS = usage.PeakRSS
+	command.ReadBytes = usage.ReadBytes
+	command.WriteBytes = usage.WriteBytes
+
+	return usage.Samples > 0
+}
@@ -0,0 +1,162 @@
+package collector
+
+import (
+	"testing"
+	"time"
+
+	"github.com/devzero-inc/local-developer-analytics/database"
+	"github.com/devzero-inc/local-developer-analytics/process"
+
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/assert"
+)
+
+// staticProcess is a system process collector that always returns the same processes
+type staticProcess struct {
+	processes []process.Process
+}
+
+func (s staticProcess) Collect() ([]process.Process, error) {
+	return s.processes, nil
+}
+
+// sampleProcessTree is a shell running make, started at the created time, next to processes that existed before
+func sampleProcessTree(storedTime int64, createdTime int64) []process.Process {
+	return []process.Process{
+		{PID: 1, PPID: 0, Name: "init", StoredTime: storedTime},
+		{PID: 100, PPID: 1, Name: "zsh", StoredTime: storedTime},
+		{PID: 101, PPID: 100, Name: "make", CreatedTime: createdTime, StoredTime: storedTime},
+		{PID: 102, PPID: 101, Name: "cc", CreatedTime: createdTime, StoredTime: storedTime},
+		{PID: 103, PPID: 102, Name: "ld", CreatedTime: createdTime, StoredTime: storedTime},
+		{PID: 104, PPID: 100, Name: "gitstatusd", CreatedTime: 1000, StoredTime: storedTime},
+		{PID: 105, PPID: 104, Name: "git", CreatedTime: createdTime, StoredTime: storedTime},
+		{PID: 200, PPID: 1, Name: "slack", StoredTime: storedTime},
+		{PID: 201, PPID: 200, Name: "slack-helper", StoredTime: storedTime},
+	}
+}
+
+func TestDescendantProcesses(t *testing.T) {
+	pids := func(processes []process.Process) []int64 {
+		var pids []int64
+		for _, p := range processes {
+			pids = append(pids, p.PID)
+		}
+		return pids
+	}
+
+	assert.ElementsMatch(t, []int64{101, 102, 103, 104, 105}, pids(descendantProcesses(sampleProcessTree(1, 5000), 100, 0)))
+	// The processes that existed before the command, and their children, are not part of it
+	assert.ElementsMatch(t, []int64{101, 102, 103}, pids(descendantProcesses(sampleProcessTree(1, 5000), 100, 5000)))
+	assert.Empty(t, descendantProcesses(sampleProcessTree(1, 5000), 100, 6000))
+	assert.Empty(t, descendantProcesses(sampleProcessTree(1, 5000), 999, 0))
+}
+
+func TestDescendantProcessesIgnoresCycles(t *testing.T) {
+	processes := []process.Process{
+		{PID: 10, PPID: 11},
+		{PID: 11, PPID: 10},
+		{PID: 12, PPID: 12},
+	}
+
+	assert.Len(t, descendantProcesses(processes, 10, 0), 1)
+	assert.Empty(t, descendantProcesses(processes, 12, 0))
+}
+
+func TestCommandStartSecond(t *testing.T) {
+	// ps reports the creation time to the second, a process created right after the start can be before it
+	assert.Equal(t, int64(5000), commandStartSecond(Command{StartTime: 5999}))
+	assert.Equal(t, int64(5000), commandStartSecond(Command{StartTime: 5000}))
+}
+
+func TestCommandIsLinkedToSpawnedProcesses(t *testing.T) {
+	setupTestDatabase(t)
+
+	storedTime := time.Now().UnixMilli()
+	c := NewCollector("", nil, zerolog.Nop(), IntervalConfig{
+		ProcessInterval:         time.Hour,
+		CommandInterval:         time.Hour,
+		MaxDuration:             time.Hour,
+		AbandonedCommandTimeout: time.Hour,
+	}, QueueConfig{}, AuthConfig{}, nil, staticProcess{processes: sampleProcessTree(storedTime, storedTime+time.Second.Milliseconds())}, nil, nil, nil, nil, nil, nil)
+
+	err := c.handleStartCommand(Event{Type: StartEvent, Command: "make build", UUID: "uuid-1", ShellPID: 100})
+	assert.NoError(t, err)
+
+	err = c.handleEndCommand(Event{Type: EndEvent, Command: "make build", UUID: "uuid-1", Result: "success", Status: "0", ShellPID: 100}, nil)
+	assert.NoError(t, err)
+
+	processes, err := process.GetAllProcessesForCommand("uuid-1")
+	assert.NoError(t, err)
+
+	var names []string
+	for _, p := range processes {
+		names = append(names, p.Name)
+	}
+	assert.ElementsMatch(t, []string{"make", "cc", "ld"}, names)
+
+	metrics, err := process.GetTopProcessesAndMetricsForCommand("uuid-1")
+	assert.NoError(t, err)
+	assert.Len(t, metrics, 3)
+	assert.NotContains(t, metrics, int64(200))
+	assert.NotContains(t, metrics, int64(104))
+}
+
+func TestCommandResourceUsageTotals(t *testing.T) {
+	setupTestDatabase(t)
+
+	c := newTestCollector()
+	err := c.handleStartCommand(Event{Type: StartEvent, Command: "make build", UUID: "uuid-1", ShellPID: 100})
+	assert.NoError(t, err)
+
+	created := time.Now().Add(time.Second).UnixMilli()
+	samples := [][]process.Process{
+		{
+			{PID: 101, PPID: 100, Name: "make", CreatedTime: created, StoredTime: 1000, CPUTime: 1, RSS: 100, ReadBytes: 10, WriteBytes: 1},
+			{PID: 102, PPID: 101, Name: "cc", CreatedTime: created, StoredTime: 1000, CPUTime: 2, RSS: 300, ReadBytes: 20, WriteBytes: 2},
+		},
+		{
+			{PID: 101, PPID: 100, Name: "make", CreatedTime: created, StoredTime: 2000, CPUTime: 1.5, RSS: 150, ReadBytes: 15, WriteBytes: 1},
+			{PID: 102, PPID: 101, Name: "cc", CreatedTime: created, StoredTime: 2000, CPUTime: 4, RSS: 200, ReadBytes: 40, WriteBytes: 5},
+		},
+	}
+	for _, sample := range samples {
+		assert.NoError(t, process.InsertProcesses(sample))
+		c.linkCommandProcesses(sample)
+	}
+
+	err = c.handleEndCommand(Event{Type: EndEvent, Command: "make build", UUID: "uuid-1", Result: "success", Status: "0"}, nil)
+	assert.NoError(t, err)
+
+	commands, err := GetAllCommandsForCategoryForPeriod("build", 0, time.Now().UnixMilli())
+	assert.NoError(t, err)
+	if assert.Len(t, commands, 1) {
+		assert.InDelta(t, 5.5, commands[0].CPUTime, 0.001)
+		assert.Equal(t, int64(400), commands[0].PeakRSS)
+		assert.Equal(t, int64(55), commands[0].ReadBytes)
+		assert.Equal(t, int64(6), commands[0].WriteBytes)
+	}
+}
+
+func TestOldProcessesAndLinksAreDeleted(t *testing.T) {
+	setupTestDatabase(t)
+
+	old := time.Now().AddDate(0, 0, -6).UnixMilli()
+	recent := time.Now().UnixMilli()
+
+	assert.NoError(t, process.InsertProcesses([]process.Process{
+		{PID: 101, Name: "make", StoredTime: old},
+		{PID: 101, Name: "make", StoredTime: recent},
+	}))
+	assert.NoError(t, process.InsertCommandProcesses([]process.CommandProcess{
+		{CommandUUID: "old", PID: 101, StoredTime: old},
+		{CommandUUID: "recent", PID: 101, StoredTime: recent},
+	}))
+
+	assert.NoError(t, process.DeleteProcessesByDays(5))
+
+	var processes, links []int64
+	assert.NoError(t, database.DB.Select(&processes, "SELECT stored_time FROM processes"))
+	assert.NoError(t, database.DB.Select(&links, "SELECT stored_time FROM command_processes"))
+	assert.Equal(t, []int64{recent}, processes)
+	assert.Equal(t, []int64{recent}, links)
+}
@@ -0,0 +1,38 @@
+package collector
+
+import (
+	"time"
+
+	"github.com/devzero-inc/local-developer-analytics/category"
+)
+
+// idleCPURatio is the share of the wall time below which a long-running command is considered to be waiting,
+// either on the user or on requests
+const idleCPURatio = 0.05
+
+// classifyMode decides the mode of a finished command. The mode rules win, commands that don't match any rule
+// are batch commands, unless they ran longer than the long-running threshold while mostly idle. Idle commands with
+// a terminal are waiting on the user, without a terminal they are serving something. Idle is only inferred from
+// the CPU time of sampled processes, a command whose processes were never sampled stays a batch command.
+func (c *Collector) classifyMode(command Command, sampled bool) string {
+	if command.Mode != "" {
+		return command.Mode
+	}
+
+	threshold := c.intervalConfig.LongRunningThreshold
+	// Without samples of the spawned processes the CPU time can't tell if the command was idle
+	if threshold <= 0 || !sampled || time.Duration(command.ExecutionTime)*time.Millisecond < threshold {
+		return category.ModeBatch
+	}
+
+	wallTime := time.Duration(command.ExecutionTime) * time.Millisecond
+	if command.CPUTime > wallTime.Seconds()*idleCPURatio {
+		return category.ModeBatch
+	}
+
+	if command.TTY != "" {
+		return category.ModeInteractive
+	}
+
+	return category.ModeService
+}
@@ -0,0 +1,88 @@
+package collector
+
+import (
+	"testing"
+	"time"
+
+	"github.com/devzero-inc/local-developer-analytics/category"
+	"github.com/devzero-inc/local-developer-analytics/database"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestClassifyMode(t *testing.T) {
+	c := newTestCollector()
+	c.intervalConfig.LongRunningThreshold = time.Minute
+
+	long := (10 * time.Minute).Milliseconds()
+
+	testCases := map[string]struct {
+		command Command
+		sampled bool
+		mode    string
+	}{
+		"rule wins":             {Command{Mode: category.ModeService, ExecutionTime: 10}, false, category.ModeService},
+		"short command":         {Command{ShellPID: 1, ExecutionTime: 1000, TTY: "/dev/pts/1"}, true, category.ModeBatch},
+		"long busy command":     {Command{ShellPID: 1, ExecutionTime: long, CPUTime: 500, TTY: "/dev/pts/1"}, true, category.ModeBatch},
+		"long idle in terminal": {Command{ShellPID: 1, ExecutionTime: long, CPUTime: 1, TTY: "/dev/pts/1"}, true, category.ModeInteractive},
+		"long idle detached":    {Command{ShellPID: 1, ExecutionTime: long, CPUTime: 1}, true, category.ModeService},
+		"unknown processes":     {Command{ExecutionTime: long, TTY: "/dev/pts/1"}, false, category.ModeBatch},
+		"no process samples":    {Command{ShellPID: 1, ExecutionTime: long, TTY: "/dev/pts/1"}, false, category.ModeBatch},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			assert.Equal(t, tc.mode, c.classifyMode(tc.command, tc.sampled))
+		})
+	}
+}
+
+func TestLongCommandWithoutSamplesIsBatch(t *testing.T) {
+	setupTestDatabase(t)
+
+	c := newTestCollector()
+	c.intervalConfig.LongRunningThreshold = time.Minute
+
+	// The work of the build ran in child processes that were never sampled, so its CPU time is unknown
+	assert.NoError(t, c.handleStartCommand(Event{Type: StartEvent, Command: "make build", UUID: "uuid-1", ShellPID: 4242, TTY: "/dev/pts/1"}))
+	backdateCommand(c, "uuid-1", 10*time.Minute)
+	assert.NoError(t, c.handleEndCommand(Event{Type: EndEvent, Command: "make build", UUID: "uuid-1", Status: "0"}, nil))
+
+	var mode string
+	assert.NoError(t, database.DB.Get(&mode, "SELECT mode FROM commands WHERE command = ?", "make build"))
+	assert.Equal(t, category.ModeBatch, mode)
+}
+
+func TestCommandsCanBeFilteredByMode(t *testing.T) {
+	setupTestDatabase(t)
+
+	c := newTestCollector()
+	for uuid, command := range map[string]string{"uuid-1": "vim main.go", "uuid-2": "npm run dev", "uuid-3": "make build"} {
+		assert.NoError(t, c.handleStartCommand(Event{Type: StartEvent, Command: command, UUID: uuid, TTY: "/dev/pts/1"}))
+		assert.NoError(t, c.handleEndCommand(Event{Type: EndEvent, Command: command, UUID: uuid, Status: "0"}, nil))
+	}
+
+	now := time.Now().UnixMilli()
+
+	all, err := GetAllCommandsForPeriod(0, now)
+	assert.NoError(t, err)
+	assert.Len(t, all, 3)
+
+	batch, err := GetAllCommandsForPeriod(0, now, category.ModeBatch)
+	assert.NoError(t, err)
+	if assert.Len(t, batch, 1) {
+		assert.Equal(t, "build", batch[0].Category)
+	}
+
+	others, err := GetAllCommandsForPeriod(0, now, category.ModeInteractive, category.ModeService)
+	assert.NoError(t, err)
+	assert.Len(t, others, 2)
+
+	commands, err := GetAllCommandsForCategoryForPeriod("vim", 0, now, category.ModeBatch)
+	assert.NoError(t, err)
+	assert.Empty(t, commands)
+
+	var tty string
+	assert.NoError(t, database.DB.Get(&tty, "SELECT tty FROM commands WHERE command = ?", "vim main.go"))
+	assert.Equal(t, "/dev/pts/1", tty)
+}
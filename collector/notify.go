@@ -0,0 +1,48 @@
+package collector
+
+import (
+	"io"
+	"time"
+
+	"github.com/devzero-inc/local-developer-analytics/category"
+	"github.com/devzero-inc/local-developer-analytics/notify"
+)
+
+// learnedHistorySize is the number of previous runs of a command used to learn its usual duration
+const learnedHistorySize = 200
+
+// notifyCompletion notifies about the finished command when it exceeded its duration threshold.
+// The terminal is written to only when the command ran in a terminal.
+func (c *Collector) notifyCompletion(command Command, terminal io.Writer) {
+	// Interactive sessions and services are long by nature, notifying about them would only be noise,
+	// just like about commands the user interrupted
+	if c.notifier == nil || command.Mode != category.ModeBatch || command.Result == ResultAbandoned || command.Result == ResultInterrupted {
+		return
+	}
+
+	duration := time.Duration(command.ExecutionTime) * time.Millisecond
+	reason := c.notifier.Exceeded(command.Category, duration, func() ([]int64, error) {
+		return c.store.GetExecutionTimes(command.Subcommand, learnedHistorySize)
+	})
+	if reason == "" {
+		return
+	}
+
+	c.logger.Debug().Msgf("Notifying about command %s, it %s", command.UUID, reason)
+
+	if command.TTY == "" {
+		terminal = nil
+	}
+
+	c.notifier.Send(notify.Notification{
+		Command:   command.Command,
+		Category:  command.Category,
+		Directory: command.Directory,
+		Result:    command.Result,
+		Status:    command.Status,
+		Duration:  command.ExecutionTime,
+		Reason:    reason,
+		EndTime:   command.EndTime,
+		Terminal:  terminal,
+	})
+}
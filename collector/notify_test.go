@@ -0,0 +1,45 @@
+package collector
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/devzero-inc/local-developer-analytics/notify"
+
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLongRunningCommandIsNotifiedThroughTheTerminal(t *testing.T) {
+	setupTestDatabase(t)
+
+	c := newTestCollector()
+	c.notifier = notify.NewManager(notify.Thresholds{Global: time.Minute}, []notify.Notifier{notify.TerminalNotifier{}}, nil, zerolog.Nop())
+
+	for _, uuid := range []string{"long", "short", "detached", "interactive"} {
+		tty := "/dev/pts/1"
+		if uuid == "detached" {
+			tty = ""
+		}
+		command := "make build"
+		if uuid == "interactive" {
+			command = "vim main.go"
+		}
+		assert.NoError(t, c.handleStartCommand(Event{Type: StartEvent, Command: command, UUID: uuid, TTY: tty}))
+	}
+
+	for _, uuid := range []string{"long", "detached", "interactive"} {
+		backdateCommand(c, uuid, 2*time.Minute)
+	}
+
+	var terminal bytes.Buffer
+	assert.NoError(t, c.handleEndCommand(Event{Type: EndEvent, UUID: "long", Result: "success", Status: "0"}, &terminal))
+	assert.Contains(t, terminal.String(), "\x1b]9;make build: success after 2m")
+
+	for _, uuid := range []string{"short", "detached", "interactive"} {
+		terminal.Reset()
+		assert.NoError(t, c.handleEndCommand(Event{Type: EndEvent, UUID: uuid, Result: "success", Status: "0"}, &terminal))
+		assert.Empty(t, terminal.String(), uuid)
+	}
+}
@@ -0,0 +1,129 @@
+package collector
+
+import (
+	"context"
+	"time"
+
+	"github.com/devzero-inc/local-developer-analytics/database"
+)
+
+// abandonedSweepInterval is how often in-flight commands are checked for being abandoned
+const abandonedSweepInterval = 5 * time.Minute
+
+// InsertOngoingCommand persists an in-flight command, so it survives collector restarts
+func InsertOngoingCommand(command Command) error {
+	query := `INSERT OR REPLACE INTO ongoing_commands (uuid, category, subcommand, command, user, directory, start_time, repository,
+		git_branch, git_commit, git_dirty, virtual_env, node_version, kube_context, direnv_dir, shell_pid, mode, tty, session_id)
+	VALUES (:uuid, :category, :subcommand, :command, :user, :directory, :start_time, :repository,
+		:git_branch, :git_commit, :git_dirty, :virtual_env, :node_version, :kube_context, :direnv_dir, :shell_pid, :mode, :tty, :session_id)`
+
+	_, err := database.DB.NamedExec(query, command)
+
+	return err
+}
+
+// DeleteOngoingCommand removes an in-flight command once it has finished
+func DeleteOngoingCommand(uuid string) error {
+	_, err := database.DB.Exec("DELETE FROM ongoing_commands WHERE uuid = ?", uuid)
+
+	return err
+}
+
+// GetOngoingCommands fetches all persisted in-flight commands by their UUID
+func GetOngoingCommands() (map[string]Command, error) {
+	var rows []Command
+	query := `SELECT uuid, category, subcommand, command, user, directory, start_time, repository,
+		git_branch, git_commit, git_dirty, virtual_env, node_version, kube_context, direnv_dir, shell_pid, mode, tty, session_id
+		FROM ongoing_commands`
+
+	if err := database.DB.Select(&rows, query); err != nil {
+		return nil, err
+	}
+
+	commands := make(map[string]Command, len(rows))
+	for _, row := range rows {
+		commands[row.UUID] = row
+	}
+
+	return commands, nil
+}
+
+// restoreOngoingCommands loads in-flight commands persisted by a previous run of the collector
+func (c *Collector) restoreOngoingCommands() {
+	commands, err := c.store.GetOngoingCommands()
+	if err != nil {
+		c.logger.Error().Err(err).Msg("Failed to restore ongoing commands")
+		return
+	}
+
+	if len(commands) == 0 {
+		return
+	}
+
+	c.logger.Info().Msgf("Restoring %d ongoing commands", len(commands))
+
+	c.do(func(state *collectorState) {
+		for _, command := range commands {
+			if _, exists := state.ongoingCommands[command.UUID]; !exists {
+				c.startTracking(state, command)
+			}
+		}
+	})
+}
+
+// sweepAbandonedCommands periodically closes in-flight commands that never received an end event
+func (c *Collector) sweepAbandonedCommands(ctx context.Context) {
+	for {
+		select {
+		case <-ctx.Done():
+			c.logger.Debug().Msg("Shutting down sweeping of abandoned commands")
+			return
+		case <-c.clock.After(abandonedSweepInterval):
+			c.sweepOnce(c.clock.Now())
+		}
+	}
+}
+
+// sweepOnce closes every in-flight command that started before the abandoned command timeout
+func (c *Collector) sweepOnce(now time.Time) {
+	deadline := now.Add(-c.intervalConfig.AbandonedCommandTimeout).UnixMilli()
+
+	abandoned := make(map[string]Command)
+	c.do(func(state *collectorState) {
+		for uuid, command := range state.ongoingCommands {
+			if command.StartTime <= deadline {
+				abandoned[uuid] = command
+			}
+		}
+		// Excluded commands that never received an end event are forgotten as well
+		for uuid, excludedAt := range state.excludedCommands {
+			if excludedAt.UnixMilli() <= deadline {
+				delete(state.excludedCommands, uuid)
+			}
+		}
+	})
+
+	for uuid, command := range abandoned {
+		c.logger.Debug().Msgf("Closing abandoned command: %s", command.Command)
+
+		command.Result = ResultAbandoned
+		if err := c.finishCommand(uuid, command, c.lastSeenAlive(command), nil); err != nil {
+			c.logger.Error().Err(err).Msg("Failed to close abandoned command")
+		}
+	}
+}
+
+// lastSeenAlive is the end of an abandoned command, the last sample its processes were alive in. Without
+// any process it ended when it started, the timeout would only be a made up duration.
+func (c *Collector) lastSeenAlive(command Command) time.Time {
+	lastSample, err := c.store.GetLastSampleTimeForCommand(command.UUID)
+	if err != nil {
+		c.logger.Error().Err(err).Msg("Failed to get the last sample of the abandoned command")
+	}
+
+	if lastSample < command.StartTime {
+		return time.UnixMilli(command.StartTime)
+	}
+
+	return time.UnixMilli(lastSample)
+}
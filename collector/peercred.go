@@ -0,0 +1,29 @@
+package collector
+
+import (
+	"errors"
+	"fmt"
+	"net"
+)
+
+// errPeerCredUnsupported is returned when the platform can't report the peer credentials
+var errPeerCredUnsupported = errors.New("peer credentials are not supported")
+
+// verifyPeer checks that the connection comes from the same user that owns the collector
+func (c *Collector) verifyPeer(conn net.Conn) error {
+	uid, err := peerUID(conn)
+	if errors.Is(err, errPeerCredUnsupported) {
+		c.logger.Debug().Msg("Peer credentials are not supported, skipping verification")
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to read peer credentials: %w", err)
+	}
+
+	if uid != c.ownerUID {
+		c.logger.Warn().Msgf("Rejected event from UID %d, collector is owned by UID %d", uid, c.ownerUID)
+		return fmt.Errorf("peer UID %d does not match owner UID %d", uid, c.ownerUID)
+	}
+
+	return nil
+}
@@ -0,0 +1,10 @@
+//go:build !linux && !darwin
+
+package collector
+
+import "net"
+
+// peerUID is not supported on this platform
+func peerUID(_ net.Conn) (int, error) {
+	return -1, errPeerCredUnsupported
+}
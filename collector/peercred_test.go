@@ -0,0 +1,37 @@
+package collector
+
+import (
+	"net"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestVerifyPeer(t *testing.T) {
+	if runtime.GOOS != "linux" && runtime.GOOS != "darwin" {
+		t.Skip("peer credentials are not supported on this platform")
+	}
+
+	socketPath := filepath.Join(t.TempDir(), "lda.socket")
+	listener, err := net.Listen("unix", socketPath)
+	assert.NoError(t, err)
+	defer listener.Close()
+
+	client, err := net.Dial("unix", socketPath)
+	assert.NoError(t, err)
+	defer client.Close()
+
+	server, err := listener.Accept()
+	assert.NoError(t, err)
+	defer server.Close()
+
+	c := &Collector{logger: zerolog.Nop(), ownerUID: os.Getuid()}
+	assert.NoError(t, c.verifyPeer(server), "connections from the owner should be accepted")
+
+	c.ownerUID = os.Getuid() + 1
+	assert.Error(t, c.verifyPeer(server), "connections from other users should be rejected")
+}
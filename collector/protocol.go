@@ -0,0 +1,196 @@
+package collector
+
+import (
+	"bufio"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+)
+
+const (
+	// ProtocolVersion is the current version of the framed socket protocol
+	ProtocolVersion byte = 1
+	// MaxFrameSize is the maximum size of a single frame payload, anything bigger is rejected
+	MaxFrameSize = 1 << 20
+	// frameHeaderSize is the size of the version byte and the big-endian payload length
+	frameHeaderSize = 5
+	// legacyFieldCount is the number of fields in the legacy pipe separated format
+	legacyFieldCount = 7
+)
+
+const (
+	// StartEvent is sent by the shell hook before the command is executed
+	StartEvent = "start"
+	// EndEvent is sent by the shell hook after the command has finished
+	EndEvent = "end"
+)
+
+// supportedVersions are the protocol versions that the collector is able to decode
+var supportedVersions = map[byte]bool{
+	ProtocolVersion: true,
+}
+
+// Event is the message sent by the shell hooks over the collector socket
+type Event struct {
+	Type      string `json:"type"`
+	Command   string `json:"command"`
+	Directory string `json:"directory"`
+	User      string `json:"user"`
+	UUID      string `json:"uuid"`
+	Result    string `json:"result,omitempty"`
+	Status    string `json:"status,omitempty"`
+	// ShellPID is the PID of the shell that executed the command
+	ShellPID int64 `json:"shell_pid,omitempty"`
+	// TTY is the terminal of the shell that executed the command, empty without a terminal
+	TTY string `json:"tty,omitempty"`
+	// VirtualEnv is the active Python virtual environment ($VIRTUAL_ENV)
+	VirtualEnv string `json:"virtual_env,omitempty"`
+	// NvmBin is the bin directory of the active nvm node version ($NVM_BIN)
+	NvmBin string `json:"nvm_bin,omitempty"`
+	// Kubeconfig is the list of kubeconfig files in use ($KUBECONFIG)
+	Kubeconfig string `json:"kubeconfig,omitempty"`
+	// DirenvDir is the directory of the loaded direnv environment ($DIRENV_DIR)
+	DirenvDir string `json:"direnv_dir,omitempty"`
+	// ShellStartTime is the time in seconds the shell was started, with the shell PID it identifies the terminal session
+	ShellStartTime int64 `json:"shell_start_time,omitempty"`
+	// Tmux and TmuxPane are the tmux server and pane of the shell ($TMUX and $TMUX_PANE)
+	Tmux     string `json:"tmux,omitempty"`
+	TmuxPane string `json:"tmux_pane,omitempty"`
+	// Screen is the GNU screen session of the shell ($STY)
+	Screen string `json:"screen,omitempty"`
+	// SSHConnection is the SSH connection of the shell ($SSH_CONNECTION)
+	SSHConnection string `json:"ssh_connection,omitempty"`
+	// TermProgram is the terminal emulator running the shell ($TERM_PROGRAM)
+	TermProgram string `json:"term_program,omitempty"`
+	// PauseFor is how long a pause request pauses the collection, zero pauses until resumed
+	PauseFor time.Duration `json:"pause_for,omitempty"`
+	// ReceivedAt is the time in milliseconds the collector received the event, it is set by the collector so
+	// queued and spilled events keep the time the command started or ended
+	ReceivedAt int64 `json:"received_at,omitempty"`
+}
+
+// EncodeFrame encodes the event into a versioned, length-prefixed frame.
+//
+// Frame layout: 1 byte protocol version, 4 bytes big-endian payload length, JSON payload.
+func EncodeFrame(event Event) ([]byte, error) {
+	return encodeFrame(event)
+}
+
+// encodeFrame encodes any message into a frame, the collector uses it to reply to control requests
+func encodeFrame(message interface{}) ([]byte, error) {
+	payload, err := json.Marshal(message)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(payload) > MaxFrameSize {
+		return nil, fmt.Errorf("frame payload too large: %d bytes", len(payload))
+	}
+
+	frame := make([]byte, frameHeaderSize, frameHeaderSize+len(payload))
+	frame[0] = ProtocolVersion
+	binary.BigEndian.PutUint32(frame[1:frameHeaderSize], uint32(len(payload)))
+
+	return append(frame, payload...), nil
+}
+
+// ReadEvent reads a single event from the reader, negotiating the format from the first byte.
+// Frames starting with a supported version byte are decoded as framed messages,
+// anything starting with a printable character is treated as the legacy pipe format.
+func ReadEvent(r io.Reader) (Event, error) {
+	reader := bufio.NewReader(r)
+
+	first, err := reader.Peek(1)
+	if err != nil {
+		return Event{}, err
+	}
+
+	if isLegacyFormat(first[0]) {
+		return readLegacyEvent(reader)
+	}
+
+	return readFramedEvent(reader)
+}
+
+// isLegacyFormat checks if the first byte of a message belongs to the legacy pipe format
+func isLegacyFormat(b byte) bool {
+	return b >= 0x20 && b < 0x7f
+}
+
+func readFramedEvent(reader io.Reader) (Event, error) {
+	var event Event
+	err := readFrame(reader, &event)
+
+	return event, err
+}
+
+// readFrame reads a single frame and decodes its payload into the message
+func readFrame(reader io.Reader, message interface{}) error {
+	var header [frameHeaderSize]byte
+	if _, err := io.ReadFull(reader, header[:]); err != nil {
+		return fmt.Errorf("failed to read frame header: %w", err)
+	}
+
+	if !supportedVersions[header[0]] {
+		return fmt.Errorf("unsupported protocol version: %d", header[0])
+	}
+
+	size := binary.BigEndian.Uint32(header[1:])
+	if size > MaxFrameSize {
+		return fmt.Errorf("frame payload too large: %d bytes", size)
+	}
+
+	payload := make([]byte, size)
+	if _, err := io.ReadFull(reader, payload); err != nil {
+		return fmt.Errorf("failed to read frame payload: %w", err)
+	}
+
+	if err := json.Unmarshal(payload, message); err != nil {
+		return fmt.Errorf("failed to decode frame payload: %w", err)
+	}
+
+	return nil
+}
+
+// readLegacyEvent reads the legacy `phase|command|directory|user|uuid|result|status` line. The line is never
+// buffered beyond the frame limit, so a client can't make the collector allocate without bound.
+func readLegacyEvent(reader io.Reader) (Event, error) {
+	scanner := bufio.NewScanner(reader)
+	scanner.Buffer(make([]byte, 0, 4096), MaxFrameSize)
+
+	if !scanner.Scan() {
+		if err := scanner.Err(); errors.Is(err, bufio.ErrTooLong) {
+			return Event{}, fmt.Errorf("legacy message larger than %d bytes", MaxFrameSize)
+		} else if err != nil {
+			return Event{}, err
+		}
+	}
+
+	return ParseLegacyEvent(strings.TrimRight(scanner.Text(), "\r"))
+}
+
+// ParseLegacyEvent parses the legacy pipe separated format. Commands containing
+// pipes are recovered by treating everything between the phase and the last five
+// fields as the command.
+func ParseLegacyEvent(data string) (Event, error) {
+	parts := strings.Split(data, "|")
+	if len(parts) < legacyFieldCount {
+		return Event{}, fmt.Errorf("invalid command format")
+	}
+
+	tail := parts[len(parts)-5:]
+
+	return Event{
+		Type:      parts[0],
+		Command:   strings.Join(parts[1:len(parts)-5], "|"),
+		Directory: tail[0],
+		User:      tail[1],
+		UUID:      tail[2],
+		Result:    tail[3],
+		Status:    tail[4],
+	}, nil
+}
@@ -0,0 +1,104 @@
+package collector
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEncodeFrameRoundTrip(t *testing.T) {
+	event := Event{
+		Type:      StartEvent,
+		Command:   "ps aux | grep foo\necho 'done'",
+		Directory: "/home/dev/project",
+		User:      "dev",
+		UUID:      "1712-42-1337",
+	}
+
+	frame, err := EncodeFrame(event)
+	assert.NoError(t, err)
+	assert.Equal(t, ProtocolVersion, frame[0])
+
+	decoded, err := ReadEvent(bytes.NewReader(frame))
+	assert.NoError(t, err)
+	assert.Equal(t, event, decoded)
+}
+
+func TestReadEventLegacyFormat(t *testing.T) {
+	testCases := []struct {
+		name     string
+		data     string
+		expected Event
+		wantErr  bool
+	}{
+		{
+			name: "start event",
+			data: "start|ls -la|/tmp|dev|uuid-1||\n",
+			expected: Event{
+				Type: StartEvent, Command: "ls -la", Directory: "/tmp", User: "dev", UUID: "uuid-1",
+			},
+		},
+		{
+			name: "end event with pipes in command",
+			data: "end|ps aux | grep foo|/tmp|dev|uuid-2|failure|1\n",
+			expected: Event{
+				Type: EndEvent, Command: "ps aux | grep foo", Directory: "/tmp", User: "dev", UUID: "uuid-2", Result: "failure", Status: "1",
+			},
+		},
+		{
+			name:    "too few fields",
+			data:    "start|ls|/tmp\n",
+			wantErr: true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			event, err := ReadEvent(bytes.NewBufferString(tc.data))
+			if tc.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tc.expected, event)
+		})
+	}
+}
+
+func TestReadEventRejectsInvalidFrames(t *testing.T) {
+	frame, err := EncodeFrame(Event{Type: StartEvent})
+	assert.NoError(t, err)
+
+	unsupported := append([]byte{}, frame...)
+	unsupported[0] = 0x7
+	_, err = ReadEvent(bytes.NewReader(unsupported))
+	assert.Error(t, err, "unsupported versions should be rejected")
+
+	oversized := []byte{ProtocolVersion, 0xff, 0xff, 0xff, 0xff}
+	_, err = ReadEvent(bytes.NewReader(oversized))
+	assert.Error(t, err, "oversized frames should be rejected")
+
+	_, err = ReadEvent(bytes.NewReader(frame[:len(frame)-2]))
+	assert.Error(t, err, "truncated frames should be rejected")
+}
+
+// endlessReader returns the same byte forever
+type endlessReader byte
+
+func (r endlessReader) Read(p []byte) (int, error) {
+	for i := range p {
+		p[i] = byte(r)
+	}
+	return len(p), nil
+}
+
+func TestReadEventRejectsOversizedLegacyLines(t *testing.T) {
+	// A line without an end is rejected once it reaches the frame limit, instead of being buffered forever
+	_, err := ReadEvent(endlessReader('a'))
+	assert.ErrorContains(t, err, "legacy message larger than")
+
+	event, err := ReadEvent(bytes.NewBufferString("end|make|/tmp|dev|uuid-1|success|0\r\n"))
+	assert.NoError(t, err)
+	assert.Equal(t, "0", event.Status)
+}
@@ -0,0 +1,364 @@
+package collector
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/rs/zerolog"
+)
+
+// OverflowPolicy decides what happens to the events received while the event queue is full
+type OverflowPolicy string
+
+const (
+	// OverflowDropOldest drops the oldest queued event to make room for the new one
+	OverflowDropOldest OverflowPolicy = "drop_oldest"
+	// OverflowDropNewest drops the new event
+	OverflowDropNewest OverflowPolicy = "drop_newest"
+	// OverflowSpill appends the new events to a file on disk, they are processed once the queue has drained
+	OverflowSpill OverflowPolicy = "spill"
+	// DefaultOverflowPolicy is the policy when none is configured
+	DefaultOverflowPolicy = OverflowSpill
+)
+
+const (
+	// DefaultQueueSize is the number of events kept in memory when no size is configured
+	DefaultQueueSize = 1000
+	// maxSpilledEvents is the number of events the spill file can hold, newer events are dropped
+	maxSpilledEvents = 100000
+	// replyTimeout is the maximum time the shell hook waits for its end event to be processed, so
+	// a terminal notification can be written back, before the prompt is released
+	replyTimeout = time.Second
+	// queueStatsInterval is how often the queue counters are logged
+	queueStatsInterval = 5 * time.Minute
+	// dropLogInterval logs only every n-th dropped event, so a flood of events doesn't flood the log
+	dropLogInterval = 100
+)
+
+// ParseOverflowPolicy validates the configured overflow policy, empty means the default policy
+func ParseOverflowPolicy(policy string) (OverflowPolicy, error) {
+	switch OverflowPolicy(policy) {
+	case "":
+		return DefaultOverflowPolicy, nil
+	case OverflowDropOldest, OverflowDropNewest, OverflowSpill:
+		return OverflowPolicy(policy), nil
+	default:
+		return "", fmt.Errorf("invalid event queue overflow policy %q, expected %s, %s or %s",
+			policy, OverflowDropOldest, OverflowDropNewest, OverflowSpill)
+	}
+}
+
+// QueueConfig contains the configuration of the event queue
+type QueueConfig struct {
+	// Size is the number of events kept in memory, zero is the default size
+	Size int
+	// Overflow is the policy for events received while the queue is full, empty is the default policy
+	Overflow OverflowPolicy
+	// SpillPath is the file spilled events are written to, spilling falls back to dropping the oldest event without it
+	SpillPath string
+}
+
+// QueueStats are the counters of the event queue
+type QueueStats struct {
+	// Queued is the number of events waiting in memory and Spilled the number of events waiting on disk
+	Queued  int `json:"queued"`
+	Spilled int `json:"spilled"`
+	// Received, Processed and Dropped count the start and end events since the collector started
+	Received  int64 `json:"received"`
+	Processed int64 `json:"processed"`
+	Dropped   int64 `json:"dropped"`
+}
+
+// queuedEvent is an event waiting to be processed
+type queuedEvent struct {
+	event Event
+	// terminal receives the terminal notification of an end event, nil when nobody waits for it
+	terminal io.Writer
+	// done is closed once the event has been processed or dropped, nil when nobody waits for it
+	done chan struct{}
+}
+
+// finish releases whoever waits for the event
+func (q *queuedEvent) finish() {
+	if q.done != nil {
+		close(q.done)
+	}
+}
+
+// eventQueue is a bounded queue of shell events, the events of the same command are processed in order.
+// The condition is always broadcast, as the workers and waitIdle wait on it for different reasons.
+type eventQueue struct {
+	mutex  sync.Mutex
+	cond   *sync.Cond
+	size   int
+	policy OverflowPolicy
+	logger zerolog.Logger
+
+	events []*queuedEvent
+	// inFlight are the UUIDs of the commands with an event being processed
+	inFlight map[string]bool
+	closed   bool
+
+	// spillPath is appended to while spilling, spillOffset is how far it has been read back
+	spillPath   string
+	spilled     int
+	spillOffset int64
+
+	stats QueueStats
+}
+
+func newEventQueue(config QueueConfig, logger zerolog.Logger) *eventQueue {
+	size := config.Size
+	if size <= 0 {
+		size = DefaultQueueSize
+	}
+
+	policy := config.Overflow
+	if policy == "" {
+		policy = DefaultOverflowPolicy
+	}
+	if policy == OverflowSpill && config.SpillPath == "" {
+		policy = OverflowDropOldest
+		if config.Overflow == OverflowSpill {
+			logger.Warn().Msg("No spill file configured, dropping the oldest events when the queue is full")
+		}
+	}
+
+	queue := &eventQueue{
+		size:      size,
+		policy:    policy,
+		logger:    logger,
+		inFlight:  make(map[string]bool),
+		spillPath: config.SpillPath,
+	}
+	queue.cond = sync.NewCond(&queue.mutex)
+
+	if policy == OverflowSpill {
+		queue.restoreSpilled()
+	}
+
+	return queue
+}
+
+// restoreSpilled picks up the events spilled by a previous run of the collector
+func (q *eventQueue) restoreSpilled() {
+	file, err := os.Open(q.spillPath)
+	if err != nil {
+		if !os.IsNotExist(err) {
+			q.logger.Error().Err(err).Msg("Failed to open spilled events")
+		}
+		return
+	}
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(make([]byte, 0, 64*1024), MaxFrameSize+1)
+	for scanner.Scan() {
+		q.spilled++
+	}
+
+	if q.spilled > 0 {
+		q.logger.Info().Msgf("Restored %d spilled events", q.spilled)
+	}
+}
+
+// push adds the event to the queue, it returns false when the event was dropped or spilled to disk,
+// in which case nobody should wait for it
+func (q *eventQueue) push(item *queuedEvent) bool {
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+
+	if q.closed {
+		q.dropLocked(item)
+		return false
+	}
+
+	q.stats.Received++
+
+	// Once spilling, new events are spilled too, so they are processed after the spilled ones
+	if len(q.events) < q.size && q.spilled == 0 {
+		q.events = append(q.events, item)
+		q.cond.Broadcast()
+		return true
+	}
+
+	switch q.policy {
+	case OverflowDropNewest:
+		q.dropLocked(item)
+		return false
+	case OverflowSpill:
+		if err := q.spillLocked(item.event); err != nil {
+			q.logger.Error().Err(err).Msg("Failed to spill event")
+			q.dropLocked(item)
+		}
+		item.finish()
+		q.cond.Broadcast()
+		return false
+	default:
+		q.dropLocked(q.events[0])
+		q.events = append(q.events[1:], item)
+		q.cond.Broadcast()
+		return true
+	}
+}
+
+// dropLocked accounts for a dropped event and releases whoever waits for it
+func (q *eventQueue) dropLocked(item *queuedEvent) {
+	q.stats.Dropped++
+	if q.stats.Dropped%dropLogInterval == 1 {
+		q.logger.Warn().Msgf("Event queue is full, dropped %d events so far", q.stats.Dropped)
+	}
+	item.finish()
+}
+
+// spillLocked appends the event to the spill file
+func (q *eventQueue) spillLocked(event Event) error {
+	if q.spilled >= maxSpilledEvents {
+		return fmt.Errorf("spill file is full with %d events", q.spilled)
+	}
+
+	line, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+
+	file, err := os.OpenFile(q.spillPath, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0600)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	if _, err := file.Write(append(line, '\n')); err != nil {
+		return err
+	}
+
+	q.spilled++
+
+	return nil
+}
+
+// refillLocked moves up to a queue worth of spilled events back into memory, the spill file is
+// removed once it has been read completely
+func (q *eventQueue) refillLocked() {
+	file, err := os.Open(q.spillPath)
+	if err != nil {
+		q.logger.Error().Err(err).Msg("Failed to read spilled events")
+		q.stats.Dropped += int64(q.spilled)
+		q.spilled, q.spillOffset = 0, 0
+		return
+	}
+	defer file.Close()
+
+	if _, err := file.Seek(q.spillOffset, io.SeekStart); err != nil {
+		q.logger.Error().Err(err).Msg("Failed to read spilled events")
+		return
+	}
+
+	reader := bufio.NewReader(file)
+	for len(q.events) < q.size && q.spilled > 0 {
+		line, err := reader.ReadBytes('\n')
+		if err != nil {
+			// The spill file ended before its count, e.g. it was truncated by a crash
+			q.stats.Dropped += int64(q.spilled)
+			q.spilled = 0
+			break
+		}
+		q.spillOffset += int64(len(line))
+		q.spilled--
+
+		var event Event
+		if err := json.Unmarshal(line, &event); err != nil {
+			q.logger.Error().Err(err).Msg("Failed to decode spilled event")
+			q.stats.Dropped++
+			continue
+		}
+		q.events = append(q.events, &queuedEvent{event: event})
+	}
+
+	if q.spilled == 0 {
+		q.spillOffset = 0
+		if err := os.Remove(q.spillPath); err != nil && !os.IsNotExist(err) {
+			q.logger.Error().Err(err).Msg("Failed to remove spill file")
+		}
+	}
+}
+
+// pop waits for an event whose command has no other event being processed, it returns false once
+// the queue is closed and drained, spilled events stay on disk for the next run
+func (q *eventQueue) pop() (*queuedEvent, bool) {
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+
+	for {
+		if len(q.events) == 0 && q.spilled > 0 && !q.closed {
+			q.refillLocked()
+		}
+
+		for i, item := range q.events {
+			if !q.inFlight[item.event.UUID] {
+				q.events = append(q.events[:i], q.events[i+1:]...)
+				q.inFlight[item.event.UUID] = true
+				return item, true
+			}
+		}
+
+		if q.closed && len(q.events) == 0 {
+			return nil, false
+		}
+
+		q.cond.Wait()
+	}
+}
+
+// done marks the event as processed, releasing the next event of the same command
+func (q *eventQueue) done(item *queuedEvent) {
+	q.mutex.Lock()
+	delete(q.inFlight, item.event.UUID)
+	q.stats.Processed++
+	q.cond.Broadcast()
+	q.mutex.Unlock()
+
+	item.finish()
+}
+
+// waitIdle waits until every queued and spilled event has been processed
+func (q *eventQueue) waitIdle() {
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+
+	for len(q.events) > 0 || len(q.inFlight) > 0 || (q.spilled > 0 && !q.closed) {
+		q.cond.Wait()
+	}
+}
+
+// close stops accepting events, the queued events are still handed out to the workers
+func (q *eventQueue) close() {
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+
+	q.closed = true
+	q.cond.Broadcast()
+}
+
+// snapshot returns the current counters
+func (q *eventQueue) snapshot() QueueStats {
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+
+	stats := q.stats
+	stats.Queued = len(q.events)
+	stats.Spilled = q.spilled
+
+	return stats
+}
+
+// String formats the counters for the logs and the status command
+func (s QueueStats) String() string {
+	return fmt.Sprintf("%d queued, %d spilled, %d received, %d processed, %d dropped",
+		s.Queued, s.Spilled, s.Received, s.Processed, s.Dropped)
+}
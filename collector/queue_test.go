@@ -0,0 +1,304 @@
+package collector
+
+import (
+	"io"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/devzero-inc/local-developer-analytics/database"
+	"github.com/devzero-inc/local-developer-analytics/notify"
+
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/assert"
+)
+
+func queued(uuid string, eventType string) *queuedEvent {
+	return &queuedEvent{event: Event{Type: eventType, UUID: uuid}, done: make(chan struct{})}
+}
+
+func isDone(item *queuedEvent) bool {
+	select {
+	case <-item.done:
+		return true
+	default:
+		return false
+	}
+}
+
+func TestParseOverflowPolicy(t *testing.T) {
+	policy, err := ParseOverflowPolicy("")
+	assert.NoError(t, err)
+	assert.Equal(t, DefaultOverflowPolicy, policy)
+
+	policy, err = ParseOverflowPolicy("drop_newest")
+	assert.NoError(t, err)
+	assert.Equal(t, OverflowDropNewest, policy)
+
+	_, err = ParseOverflowPolicy("block")
+	assert.Error(t, err)
+
+	// The queue defaults to the same policy, spilling falls back to dropping the oldest event without a file
+	spillPath := filepath.Join(t.TempDir(), "spill")
+	assert.Equal(t, DefaultOverflowPolicy, newEventQueue(QueueConfig{SpillPath: spillPath}, zerolog.Nop()).policy)
+	assert.Equal(t, OverflowDropOldest, newEventQueue(QueueConfig{}, zerolog.Nop()).policy)
+}
+
+func TestQueueDropNewest(t *testing.T) {
+	queue := newEventQueue(QueueConfig{Size: 2, Overflow: OverflowDropNewest}, zerolog.Nop())
+
+	first, second, third := queued("1", StartEvent), queued("2", StartEvent), queued("3", StartEvent)
+	assert.True(t, queue.push(first))
+	assert.True(t, queue.push(second))
+	assert.False(t, queue.push(third))
+	assert.True(t, isDone(third), "nobody waits for a dropped event")
+
+	item, _ := queue.pop()
+	assert.Equal(t, "1", item.event.UUID)
+	assert.Equal(t, QueueStats{Queued: 1, Received: 3, Dropped: 1}, queue.snapshot())
+}
+
+func TestQueueDropOldest(t *testing.T) {
+	queue := newEventQueue(QueueConfig{Size: 2, Overflow: OverflowDropOldest}, zerolog.Nop())
+
+	first, second, third := queued("1", StartEvent), queued("2", StartEvent), queued("3", StartEvent)
+	queue.push(first)
+	queue.push(second)
+	assert.True(t, queue.push(third))
+	assert.True(t, isDone(first))
+
+	item, _ := queue.pop()
+	assert.Equal(t, "2", item.event.UUID)
+	queue.done(item)
+	item, _ = queue.pop()
+	assert.Equal(t, "3", item.event.UUID)
+	queue.done(item)
+
+	assert.Equal(t, QueueStats{Received: 3, Processed: 2, Dropped: 1}, queue.snapshot())
+}
+
+func TestQueueSpillsToDisk(t *testing.T) {
+	spillPath := filepath.Join(t.TempDir(), "events.spill")
+	queue := newEventQueue(QueueConfig{Size: 1, Overflow: OverflowSpill, SpillPath: spillPath}, zerolog.Nop())
+
+	assert.True(t, queue.push(queued("1", StartEvent)))
+	assert.False(t, queue.push(queued("2", StartEvent)))
+	assert.False(t, queue.push(queued("3", StartEvent)))
+	assert.Equal(t, QueueStats{Queued: 1, Spilled: 2, Received: 3}, queue.snapshot())
+
+	content, err := os.ReadFile(spillPath)
+	assert.NoError(t, err)
+	assert.Equal(t, 2, strings.Count(string(content), "\n"))
+
+	// A restarted collector picks up the spilled events
+	assert.Equal(t, 2, newEventQueue(QueueConfig{Overflow: OverflowSpill, SpillPath: spillPath}, zerolog.Nop()).snapshot().Spilled)
+
+	var uuids []string
+	for i := 0; i < 3; i++ {
+		item, ok := queue.pop()
+		assert.True(t, ok)
+		uuids = append(uuids, item.event.UUID)
+		queue.done(item)
+	}
+	assert.Equal(t, []string{"1", "2", "3"}, uuids)
+	assert.Equal(t, QueueStats{Received: 3, Processed: 3}, queue.snapshot())
+
+	_, err = os.Stat(spillPath)
+	assert.True(t, os.IsNotExist(err), "the drained spill file is removed")
+}
+
+func TestQueueKeepsEventsOfACommandInOrder(t *testing.T) {
+	queue := newEventQueue(QueueConfig{Size: 10}, zerolog.Nop())
+
+	queue.push(queued("1", StartEvent))
+	queue.push(queued("1", EndEvent))
+	queue.push(queued("2", StartEvent))
+
+	start, _ := queue.pop()
+	// The end of the first command waits for its start to be processed
+	other, _ := queue.pop()
+	assert.Equal(t, "2", other.event.UUID)
+
+	queue.done(start)
+	end, _ := queue.pop()
+	assert.Equal(t, "1", end.event.UUID)
+	assert.Equal(t, EndEvent, end.event.Type)
+
+	queue.done(other)
+	queue.done(end)
+	queue.close()
+
+	_, ok := queue.pop()
+	assert.False(t, ok)
+	assert.False(t, queue.push(queued("3", StartEvent)), "a closed queue doesn't accept events")
+}
+
+func TestEventsOverSocketAreProcessedByTheWorkers(t *testing.T) {
+	setupTestDatabase(t)
+
+	socketPath := filepath.Join(t.TempDir(), "lda.sock")
+	listener, err := net.Listen("unix", socketPath)
+	if !assert.NoError(t, err) {
+		return
+	}
+	defer listener.Close()
+
+	c := newTestCollector()
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			_ = c.handleSocketCollection(conn)
+		}
+	}()
+
+	workerDone := make(chan struct{})
+	go func() {
+		defer close(workerDone)
+		c.processEvents()
+	}()
+
+	for _, event := range []Event{
+		{Type: StartEvent, Command: "make build", UUID: "1"},
+		{Type: EndEvent, UUID: "1", Result: "success", Status: "0"},
+	} {
+		conn, err := net.Dial("unix", socketPath)
+		if !assert.NoError(t, err) {
+			return
+		}
+		frame, _ := EncodeFrame(event)
+		_, err = conn.Write(frame)
+		assert.NoError(t, err)
+		// The connection is closed once the event is queued, or for end events processed
+		_, _ = io.ReadAll(conn)
+		conn.Close()
+	}
+
+	c.queue.close()
+	<-workerDone
+
+	var count int
+	assert.NoError(t, database.DB.Get(&count, "SELECT COUNT(*) FROM commands WHERE uuid = ?", "1"))
+	assert.Equal(t, 1, count)
+
+	status, err := SendControlRequest(socketPath, Event{Type: StatusRequest})
+	assert.NoError(t, err)
+	assert.Equal(t, QueueStats{Received: 2, Processed: 2}, status.Queue)
+}
+
+func TestEndEventOnlyHoldsTheShellForTerminalNotifications(t *testing.T) {
+	setupTestDatabase(t)
+
+	// No worker processes the events, so the shell is only held when it waits for the processing
+	sendEnd := func(c *Collector) time.Duration {
+		socketPath := filepath.Join(t.TempDir(), "lda.sock")
+		listener, err := net.Listen("unix", socketPath)
+		if !assert.NoError(t, err) {
+			return 0
+		}
+		defer listener.Close()
+
+		go func() {
+			if conn, err := listener.Accept(); err == nil {
+				_ = c.handleSocketCollection(conn)
+			}
+		}()
+
+		conn, err := net.Dial("unix", socketPath)
+		if !assert.NoError(t, err) {
+			return 0
+		}
+		defer conn.Close()
+
+		started := time.Now()
+		frame, _ := EncodeFrame(Event{Type: EndEvent, UUID: "1", Result: "success", Status: "0", TTY: "/dev/pts/1"})
+		_, err = conn.Write(frame)
+		assert.NoError(t, err)
+		_, _ = io.ReadAll(conn)
+
+		return time.Since(started)
+	}
+
+	c := newTestCollector()
+	assert.Less(t, sendEnd(c), replyTimeout/2, "the shell is released once the event is queued")
+	assert.Equal(t, 1, c.queue.snapshot().Queued)
+
+	c = newTestCollector()
+	c.notifier = notify.NewManager(notify.Thresholds{Global: time.Minute}, []notify.Notifier{notify.TerminalNotifier{}}, nil, zerolog.Nop())
+	assert.GreaterOrEqual(t, sendEnd(c), replyTimeout, "the shell waits for the terminal notification")
+}
+
+func TestSpilledEventsKeepTheTimeTheyWereReceived(t *testing.T) {
+	setupTestDatabase(t)
+
+	spillPath := filepath.Join(t.TempDir(), "events.spill")
+	queueConfig := QueueConfig{Size: 1, Overflow: OverflowSpill, SpillPath: spillPath}
+	newCollector := func(clock Clock) *Collector {
+		return NewCollector("", nil, zerolog.Nop(), IntervalConfig{
+			ProcessInterval:         time.Hour,
+			CommandInterval:         time.Hour,
+			MaxDuration:             time.Hour,
+			AbandonedCommandTimeout: time.Hour,
+		}, queueConfig, AuthConfig{}, nil, noopProcess{}, nil, nil, nil, nil, clock, nil)
+	}
+
+	socketPath := filepath.Join(t.TempDir(), "lda.sock")
+	listener, err := net.Listen("unix", socketPath)
+	if !assert.NoError(t, err) {
+		return
+	}
+	defer listener.Close()
+
+	start := time.UnixMilli(1700000000000)
+	clock := NewSimulatedClock(start)
+	c := newCollector(clock)
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			_ = c.handleSocketCollection(conn)
+		}
+	}()
+
+	send := func(event Event) {
+		conn, err := net.Dial("unix", socketPath)
+		if !assert.NoError(t, err) {
+			return
+		}
+		defer conn.Close()
+		frame, _ := EncodeFrame(event)
+		_, err = conn.Write(frame)
+		assert.NoError(t, err)
+		_, _ = io.ReadAll(conn)
+	}
+
+	// Nothing processes the events, the first one fills the queue and the command is spilled
+	send(Event{Type: StartEvent, Command: "ls", UUID: "0"})
+	send(Event{Type: StartEvent, Command: "make build", UUID: "1"})
+	clock.Advance(90 * time.Second)
+	send(Event{Type: EndEvent, UUID: "1", Result: "success", Status: "0"})
+	assert.Equal(t, 2, c.queue.snapshot().Spilled)
+
+	// The collector restarts much later and processes the spilled events back to back
+	restarted := newCollector(NewSimulatedClock(start.Add(time.Hour)))
+	workerDone := make(chan struct{})
+	go func() {
+		defer close(workerDone)
+		restarted.processEvents()
+	}()
+	restarted.queue.waitIdle()
+	restarted.queue.close()
+	<-workerDone
+
+	var command Command
+	assert.NoError(t, database.DB.Get(&command, "SELECT start_time, end_time, execution_time FROM commands WHERE uuid = ?", "1"))
+	assert.Equal(t, start.UnixMilli(), command.StartTime)
+	assert.Equal(t, (90 * time.Second).Milliseconds(), command.ExecutionTime)
+}
@@ -0,0 +1,156 @@
+package collector
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	gen "github.com/devzero-inc/local-developer-analytics/gen/api/v1"
+)
+
+// maxBacklog is the maximum number of commands and processes kept for resending, the oldest are dropped first
+const maxBacklog = 10000
+
+// remoteClient sends the collected data to the remote server
+type remoteClient interface {
+	SendCommands(commands []*gen.Command, auth *gen.Auth) error
+	SendProcesses(processes []*gen.Process, auth *gen.Auth) error
+}
+
+// remoteBacklog keeps the commands and processes that are being sent or failed to be sent
+type remoteBacklog struct {
+	mutex sync.Mutex
+	// commands and processes failed to be sent and are resent on flush
+	commands  []*gen.Command
+	processes []*gen.Process
+	// inFlight is the number of commands and processes being sent
+	inFlight int
+	// sending tracks the background sends, so they can be waited for on shutdown
+	sending sync.WaitGroup
+}
+
+// sendCommands sends the commands in the background, failed commands are kept in the backlog
+func (c *Collector) sendCommands(commands []*gen.Command) {
+	if c.client == nil || len(commands) == 0 {
+		return
+	}
+
+	c.backlog.mutex.Lock()
+	c.backlog.inFlight += len(commands)
+	c.backlog.sending.Add(1)
+	c.backlog.mutex.Unlock()
+
+	go func() {
+		defer c.backlog.sending.Done()
+		err := c.client.SendCommands(commands, c.protoAuthConfig)
+		if err != nil {
+			c.logger.Error().Err(err).Msg("Failed to send commands")
+		}
+
+		c.backlog.mutex.Lock()
+		defer c.backlog.mutex.Unlock()
+		c.backlog.inFlight -= len(commands)
+		if err != nil {
+			c.backlog.commands = trimBacklog(append(c.backlog.commands, commands...))
+		}
+	}()
+}
+
+// sendProcesses sends the processes in the background, failed processes are kept in the backlog
+func (c *Collector) sendProcesses(processes []*gen.Process) {
+	if c.client == nil || len(processes) == 0 {
+		return
+	}
+
+	c.backlog.mutex.Lock()
+	c.backlog.inFlight += len(processes)
+	c.backlog.sending.Add(1)
+	c.backlog.mutex.Unlock()
+
+	go func() {
+		defer c.backlog.sending.Done()
+		err := c.client.SendProcesses(processes, c.protoAuthConfig)
+		if err != nil {
+			c.logger.Error().Err(err).Msg("Failed to send processes")
+		}
+
+		c.backlog.mutex.Lock()
+		defer c.backlog.mutex.Unlock()
+		c.backlog.inFlight -= len(processes)
+		if err != nil {
+			c.backlog.processes = trimBacklog(append(c.backlog.processes, processes...))
+		}
+	}()
+}
+
+// flush resends the backlog, whatever fails again is kept for the next flush
+func (c *Collector) flush() error {
+	if c.client == nil {
+		return nil
+	}
+
+	c.backlog.mutex.Lock()
+	commands, processes := c.backlog.commands, c.backlog.processes
+	c.backlog.commands, c.backlog.processes = nil, nil
+	c.backlog.inFlight += len(commands) + len(processes)
+	c.backlog.mutex.Unlock()
+
+	var commandsErr, processesErr error
+	if len(commands) > 0 {
+		commandsErr = c.client.SendCommands(commands, c.protoAuthConfig)
+	}
+	if len(processes) > 0 {
+		processesErr = c.client.SendProcesses(processes, c.protoAuthConfig)
+	}
+
+	c.backlog.mutex.Lock()
+	defer c.backlog.mutex.Unlock()
+	c.backlog.inFlight -= len(commands) + len(processes)
+	if commandsErr != nil {
+		c.backlog.commands = trimBacklog(append(commands, c.backlog.commands...))
+	}
+	if processesErr != nil {
+		c.backlog.processes = trimBacklog(append(processes, c.backlog.processes...))
+	}
+
+	if commandsErr != nil {
+		return commandsErr
+	}
+	return processesErr
+}
+
+// flushRemote waits for the background sends and resends the backlog, giving up at the deadline
+func (c *Collector) flushRemote(deadline time.Time) error {
+	if c.client == nil {
+		return nil
+	}
+
+	done := make(chan error, 1)
+	go func() {
+		c.backlog.sending.Wait()
+		done <- c.flush()
+	}()
+
+	select {
+	case err := <-done:
+		return err
+	case <-time.After(time.Until(deadline)):
+		return fmt.Errorf("%d commands and processes weren't sent before the shutdown deadline", c.backlogSize())
+	}
+}
+
+// backlogSize is the number of commands and processes that haven't been sent yet
+func (c *Collector) backlogSize() int {
+	c.backlog.mutex.Lock()
+	defer c.backlog.mutex.Unlock()
+
+	return len(c.backlog.commands) + len(c.backlog.processes) + c.backlog.inFlight
+}
+
+// trimBacklog drops the oldest entries above the backlog limit
+func trimBacklog[T any](entries []T) []T {
+	if len(entries) > maxBacklog {
+		return entries[len(entries)-maxBacklog:]
+	}
+	return entries
+}
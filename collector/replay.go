@@ -0,0 +1,179 @@
+package collector
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"sync"
+	"time"
+
+	"github.com/devzero-inc/local-developer-analytics/process"
+)
+
+// Record is a socket message recorded by the collector, one JSON object per line
+type Record struct {
+	// Time is the time in milliseconds the message was received
+	Time int64 `json:"time"`
+	// Raw are the bytes read from the socket, a frame or a legacy line, including messages that failed to decode
+	Raw []byte `json:"raw,omitempty"`
+	// Event is the decoded message for reading the recording, it is replayed when there are no raw bytes
+	Event *Event `json:"event,omitempty"`
+	// Context is the development context read from the host for a start event
+	Context *DevContext `json:"context,omitempty"`
+}
+
+// Recorder appends every message received over the socket to a recording
+type Recorder struct {
+	mutex   sync.Mutex
+	encoder *json.Encoder
+}
+
+// NewRecorder creates a recorder writing the records as JSON lines
+func NewRecorder(writer io.Writer) *Recorder {
+	return &Recorder{encoder: json.NewEncoder(writer)}
+}
+
+// record appends the message, the event is left out when it failed to decode
+func (r *Recorder) record(now time.Time, raw []byte, event *Event, devContext *DevContext) error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	return r.encoder.Encode(Record{Time: now.UnixMilli(), Raw: raw, Event: event, Context: devContext})
+}
+
+// Record tees every message received over the socket to the recorder, it has to be set before collecting
+func (c *Collector) Record(recorder *Recorder) {
+	c.recorder = recorder
+}
+
+// ReplayStats are the counters of a replay
+type ReplayStats struct {
+	// Messages is the number of replayed messages and Failed the number of messages that failed to be read or handled
+	Messages int
+	Failed   int
+}
+
+// NoProcesses is a system process collector that doesn't collect anything, replays use it as the
+// processes of the host have nothing to do with the recorded commands
+type NoProcesses struct{}
+
+// Collect returns no processes
+func (NoProcesses) Collect() ([]process.Process, error) {
+	return nil, nil
+}
+
+// Replay feeds the recorded messages through the socket handling in order. The simulated clock of the
+// collector is set to the receive time of every message, every message is processed before the next
+// one is handled, and the development context comes from the recording instead of the host, so replaying
+// the same recording always collects the same commands.
+func (c *Collector) Replay(ctx context.Context, reader io.Reader) (ReplayStats, error) {
+	var stats ReplayStats
+
+	clock, ok := c.clock.(*SimulatedClock)
+	if !ok {
+		return stats, fmt.Errorf("replaying needs a simulated clock")
+	}
+
+	c.replaying = true
+
+	var workers sync.WaitGroup
+	workers.Add(1)
+	go func() {
+		defer workers.Done()
+		c.processEvents()
+	}()
+
+	scanner := bufio.NewScanner(reader)
+	// Raw frames are base64 encoded in the recording
+	scanner.Buffer(make([]byte, 0, 64*1024), 2*MaxFrameSize)
+
+	var readErr error
+	for line := 1; scanner.Scan(); line++ {
+		if ctx.Err() != nil {
+			readErr = ctx.Err()
+			break
+		}
+		if len(scanner.Bytes()) == 0 {
+			continue
+		}
+
+		stats.Messages++
+
+		var record Record
+		if err := json.Unmarshal(scanner.Bytes(), &record); err != nil {
+			c.logger.Error().Err(err).Msgf("Failed to decode record on line %d", line)
+			stats.Failed++
+			continue
+		}
+
+		message, err := record.message()
+		if err != nil {
+			c.logger.Error().Err(err).Msgf("Failed to replay record on line %d", line)
+			stats.Failed++
+			continue
+		}
+
+		clock.Set(time.UnixMilli(record.Time))
+
+		if record.Event != nil && record.Context != nil {
+			c.do(func(state *collectorState) {
+				state.recordedContexts[record.Event.UUID] = *record.Context
+			})
+		}
+
+		if err := c.replayMessage(message); err != nil {
+			stats.Failed++
+		}
+		c.queue.waitIdle()
+	}
+	if err := scanner.Err(); err != nil && readErr == nil {
+		readErr = err
+	}
+
+	c.queue.close()
+	workers.Wait()
+
+	// Commands that never ended in the recording are closed as abandoned, even the ones the live collector would
+	// still wait for, the replay writes to the same database and nothing is left in flight for the next collector
+	c.sweepOnce(clock.Now().Add(c.intervalConfig.AbandonedCommandTimeout))
+	c.shutdown(time.Now().Add(c.shutdownTimeout()))
+
+	return stats, readErr
+}
+
+// message returns the bytes the shell sent for the record
+func (r Record) message() ([]byte, error) {
+	if len(r.Raw) > 0 {
+		message := r.Raw
+		// Legacy lines are read until the newline, the connection can't be half-closed to end them
+		if isLegacyFormat(message[0]) && message[len(message)-1] != '\n' {
+			message = append(message, '\n')
+		}
+		return message, nil
+	}
+
+	if r.Event != nil {
+		return EncodeFrame(*r.Event)
+	}
+
+	return nil, fmt.Errorf("record has neither raw bytes nor an event")
+}
+
+// replayMessage sends the message over an in-memory connection to the socket handling
+func (c *Collector) replayMessage(message []byte) error {
+	server, client := net.Pipe()
+
+	// The replies, like terminal notifications or the status, are read and thrown away
+	go func() {
+		defer client.Close()
+		if _, err := client.Write(message); err != nil {
+			return
+		}
+		_, _ = io.Copy(io.Discard, client)
+	}()
+
+	return c.handleSocketCollection(server)
+}
@@ -0,0 +1,173 @@
+package collector
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/devzero-inc/local-developer-analytics/database"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRecordedMessagesReplayToTheSameCommands(t *testing.T) {
+	start := time.Unix(1700000000, 0)
+	clock := NewSimulatedClock(start)
+	store := newMemoryStore()
+
+	var recording bytes.Buffer
+	live := newSimulatedCollector(clock, store)
+	live.Record(NewRecorder(&recording))
+	go live.processEvents()
+
+	frame, err := EncodeFrame(Event{Type: StartEvent, Command: "go test ./...", Directory: "/src", User: "dev", UUID: "uuid-1"})
+	assert.NoError(t, err)
+	assert.NoError(t, live.replayMessage(frame))
+	live.queue.waitIdle()
+
+	clock.Advance(42 * time.Second)
+	assert.NoError(t, live.replayMessage([]byte("end|go test ./...|/src|dev|uuid-1|success|0\n")))
+	live.queue.waitIdle()
+
+	assert.Error(t, live.replayMessage([]byte{9, 0, 0, 0, 2, '{', '}'}), "unsupported versions are rejected")
+
+	lines := strings.Split(strings.TrimSpace(recording.String()), "\n")
+	if assert.Len(t, lines, 3) {
+		var record Record
+		assert.NoError(t, json.Unmarshal([]byte(lines[1]), &record))
+		assert.Equal(t, start.Add(42*time.Second).UnixMilli(), record.Time)
+		assert.Equal(t, "end|go test ./...|/src|dev|uuid-1|success|0\n", string(record.Raw))
+		if assert.NotNil(t, record.Event) {
+			assert.Equal(t, EndEvent, record.Event.Type)
+		}
+
+		var failed Record
+		assert.NoError(t, json.Unmarshal([]byte(lines[2]), &failed))
+		assert.NotEmpty(t, failed.Raw)
+		assert.Nil(t, failed.Event, "messages that failed to decode are recorded without an event")
+	}
+
+	replayed := newMemoryStore()
+	replayer := newSimulatedCollector(NewSimulatedClock(time.Time{}), replayed)
+	stats, err := replayer.Replay(context.Background(), &recording)
+	assert.NoError(t, err)
+	assert.Equal(t, ReplayStats{Messages: 3, Failed: 1}, stats)
+
+	if assert.Len(t, replayed.commands, 1) {
+		assert.Equal(t, store.commands, replayed.commands)
+		assert.Equal(t, int64(42000), replayed.commands[0].ExecutionTime)
+		assert.Equal(t, "test", replayed.commands[0].Category)
+	}
+}
+
+func TestReplayOfHandWrittenEvents(t *testing.T) {
+	recording := strings.Join([]string{
+		`{"time": 1700000000000, "event": {"type": "start", "command": "make build", "uuid": "built"}}`,
+		`{"time": 1700000005000, "event": {"type": "start", "command": "sleep 100000", "uuid": "abandoned"}}`,
+		``,
+		`not json`,
+		`{"time": 1700000010000}`,
+		`{"time": 1700000030000, "event": {"type": "end", "uuid": "built", "status": "2"}}`,
+		`{"time": 1700090000000, "event": {"type": "status"}}`,
+	}, "\n")
+
+	store := newMemoryStore()
+	c := newSimulatedCollector(NewSimulatedClock(time.Time{}), store)
+
+	stats, err := c.Replay(context.Background(), strings.NewReader(recording))
+	assert.NoError(t, err)
+	assert.Equal(t, ReplayStats{Messages: 6, Failed: 2}, stats)
+
+	results := make(map[string]Command)
+	for _, command := range store.commands {
+		results[command.UUID] = command
+	}
+	if assert.Len(t, results, 2) {
+		assert.Equal(t, int64(30000), results["built"].ExecutionTime)
+		assert.Equal(t, ResultFailure, results["built"].Result)
+		// The abandoned command timeout passed by the end of the recording
+		assert.Equal(t, ResultAbandoned, results["abandoned"].Result)
+	}
+	assert.Empty(t, store.ongoing)
+}
+
+func TestReplayLeavesNoCommandInFlight(t *testing.T) {
+	setupTestDatabase(t)
+
+	recording := strings.Join([]string{
+		`{"time": 1700000000000, "event": {"type": "start", "command": "make build", "uuid": "built"}}`,
+		`{"time": 1700000030000, "event": {"type": "end", "uuid": "built", "status": "0"}}`,
+		`{"time": 1700000040000, "event": {"type": "start", "command": "npm run dev", "uuid": "unmatched"}}`,
+	}, "\n")
+
+	c := newSimulatedCollector(NewSimulatedClock(time.Time{}), DatabaseStore{})
+
+	_, err := c.Replay(context.Background(), strings.NewReader(recording))
+	assert.NoError(t, err)
+
+	// The recording ended long before the abandoned command timeout, the command is closed anyway
+	var result string
+	assert.NoError(t, database.DB.Get(&result, "SELECT result FROM commands WHERE uuid = ?", "unmatched"))
+	assert.Equal(t, ResultAbandoned, result)
+
+	ongoing, err := GetOngoingCommands()
+	assert.NoError(t, err)
+	assert.Empty(t, ongoing)
+}
+
+func TestReplayNeedsASimulatedClock(t *testing.T) {
+	_, err := newTestCollector().Replay(context.Background(), strings.NewReader(""))
+	assert.Error(t, err)
+}
+
+func TestReplayDoesNotReadTheHost(t *testing.T) {
+	repo := t.TempDir()
+	kubeconfig := filepath.Join(t.TempDir(), "config")
+	setHost := func(branch string, kubeContext string) {
+		assert.NoError(t, os.MkdirAll(filepath.Join(repo, ".git"), 0755))
+		assert.NoError(t, os.WriteFile(filepath.Join(repo, ".git", "HEAD"), []byte("ref: refs/heads/"+branch+"\n"), 0644))
+		assert.NoError(t, os.WriteFile(kubeconfig, []byte("current-context: "+kubeContext+"\n"), 0644))
+	}
+
+	setHost("main", "production")
+
+	clock := NewSimulatedClock(time.Unix(1700000000, 0))
+	store := newMemoryStore()
+
+	var recording bytes.Buffer
+	live := newSimulatedCollector(clock, store)
+	live.Record(NewRecorder(&recording))
+	go live.processEvents()
+
+	for _, event := range []Event{
+		{Type: StartEvent, Command: "kubectl apply -f deploy.yaml", Directory: repo, UUID: "uuid-1", Kubeconfig: kubeconfig},
+		{Type: EndEvent, UUID: "uuid-1", Result: "success", Status: "0"},
+	} {
+		frame, err := EncodeFrame(event)
+		assert.NoError(t, err)
+		assert.NoError(t, live.replayMessage(frame))
+		live.queue.waitIdle()
+	}
+
+	if !assert.Len(t, store.commands, 1) {
+		return
+	}
+	assert.Equal(t, "main", store.commands[0].GitBranch)
+	assert.Equal(t, "production", store.commands[0].KubeContext)
+
+	// The host changes between the replays, the replayed commands don't
+	for _, host := range [][2]string{{"feature", "staging"}, {"release", "development"}} {
+		setHost(host[0], host[1])
+
+		replayed := newMemoryStore()
+		replayer := newSimulatedCollector(NewSimulatedClock(time.Time{}), replayed)
+		_, err := replayer.Replay(context.Background(), bytes.NewReader(recording.Bytes()))
+		assert.NoError(t, err)
+		assert.Equal(t, store.commands, replayed.commands)
+	}
+}
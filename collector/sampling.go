@@ -0,0 +1,241 @@
+package collector
+
+import (
+	"fmt"
+	"runtime"
+	"time"
+
+	"github.com/devzero-inc/local-developer-analytics/config"
+
+	"github.com/shirou/gopsutil/cpu"
+	"github.com/shirou/gopsutil/load"
+)
+
+// SamplingPolicy decides the interval until the next process sample of a sampling loop
+type SamplingPolicy interface {
+	// Next returns the interval until the next sample, previous is the last interval or zero before the first sample
+	Next(now time.Time, previous time.Duration) time.Duration
+}
+
+// Sampling policy names used in the configuration
+const (
+	SamplingFixed       = "fixed"
+	SamplingExponential = "exponential"
+	SamplingLoadAware   = "load_aware"
+	SamplingQuietHours  = "quiet_hours"
+)
+
+// FixedPolicy samples at a fixed interval
+type FixedPolicy struct {
+	Interval time.Duration
+}
+
+// Next returns the fixed interval
+func (p FixedPolicy) Next(_ time.Time, _ time.Duration) time.Duration {
+	return p.Interval
+}
+
+// ExponentialPolicy starts at the initial interval and multiplies it by the factor after each sample, up to the maximum
+type ExponentialPolicy struct {
+	Initial time.Duration
+	Factor  float64
+	Max     time.Duration
+}
+
+// Next returns the previous interval multiplied by the factor
+func (p ExponentialPolicy) Next(_ time.Time, previous time.Duration) time.Duration {
+	if previous <= 0 {
+		return p.Initial
+	}
+
+	next := time.Duration(float64(previous) * p.Factor)
+	if next < p.Initial {
+		next = p.Initial
+	}
+	if p.Max > 0 && next > p.Max {
+		next = p.Max
+	}
+
+	return next
+}
+
+// LoadSource reports the system CPU usage in percent and the 1 minute load average per CPU
+type LoadSource func() (cpuPercent float64, loadPerCPU float64, err error)
+
+// SystemLoad is the load source of the host the collector runs on
+func SystemLoad() (float64, float64, error) {
+	percents, err := cpu.Percent(0, false)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	avg, err := load.Avg()
+	if err != nil {
+		return 0, 0, err
+	}
+
+	var cpuPercent float64
+	if len(percents) > 0 {
+		cpuPercent = percents[0]
+	}
+
+	return cpuPercent, avg.Load1 / float64(runtime.NumCPU()), nil
+}
+
+// LoadAwarePolicy follows the base policy, but samples at the fast interval while the CPU usage or the
+// load average is above its threshold, a zero threshold is ignored
+type LoadAwarePolicy struct {
+	Base          SamplingPolicy
+	Fast          time.Duration
+	CPUThreshold  float64
+	LoadThreshold float64
+	Load          LoadSource
+}
+
+// Next returns the fast interval under load and the interval of the base policy otherwise
+func (p LoadAwarePolicy) Next(now time.Time, previous time.Duration) time.Duration {
+	interval := p.Base.Next(now, previous)
+	if interval > p.Fast && p.underLoad() {
+		return p.Fast
+	}
+
+	return interval
+}
+
+// underLoad reports whether the system is busy, a failing load source never is
+func (p LoadAwarePolicy) underLoad() bool {
+	cpuPercent, loadPerCPU, err := p.Load()
+	if err != nil {
+		return false
+	}
+
+	return (p.CPUThreshold > 0 && cpuPercent >= p.CPUThreshold) ||
+		(p.LoadThreshold > 0 && loadPerCPU >= p.LoadThreshold)
+}
+
+// QuietHoursPolicy follows the base policy within the active hours and doesn't sample outside of them,
+// the next sample is postponed to the start of the active hours instead
+type QuietHoursPolicy struct {
+	Base SamplingPolicy
+	// Start and End are the minutes after midnight in local time, the active hours wrap around midnight
+	// when End is before Start
+	Start int
+	End   int
+}
+
+// Next returns the interval of the base policy, or the time until the active hours start
+func (p QuietHoursPolicy) Next(now time.Time, previous time.Duration) time.Duration {
+	interval := p.Base.Next(now, previous)
+
+	next := now.Add(interval)
+	if p.active(next) {
+		return interval
+	}
+
+	start := time.Date(next.Year(), next.Month(), next.Day(), p.Start/60, p.Start%60, 0, 0, next.Location())
+	if !start.After(next) {
+		start = time.Date(next.Year(), next.Month(), next.Day()+1, p.Start/60, p.Start%60, 0, 0, next.Location())
+	}
+
+	return start.Sub(now)
+}
+
+// active reports whether the time is within the active hours
+func (p QuietHoursPolicy) active(t time.Time) bool {
+	minute := t.Hour()*60 + t.Minute()
+	if p.Start <= p.End {
+		return minute >= p.Start && minute < p.End
+	}
+	return minute >= p.Start || minute < p.End
+}
+
+// ParseActiveHours parses a local time window like 09:00-18:00 into minutes after midnight
+func ParseActiveHours(hours string) (int, int, error) {
+	var startHour, startMinute, endHour, endMinute int
+	if _, err := fmt.Sscanf(hours, "%d:%d-%d:%d", &startHour, &startMinute, &endHour, &endMinute); err != nil {
+		return 0, 0, fmt.Errorf("invalid active hours %q, expected e.g. 09:00-18:00", hours)
+	}
+
+	for _, value := range []int{startHour, endHour} {
+		if value < 0 || value > 24 {
+			return 0, 0, fmt.Errorf("invalid active hours %q, hours must be between 0 and 24", hours)
+		}
+	}
+	for _, value := range []int{startMinute, endMinute} {
+		if value < 0 || value > 59 {
+			return 0, 0, fmt.Errorf("invalid active hours %q, minutes must be between 0 and 59", hours)
+		}
+	}
+
+	start, end := startHour*60+startMinute, endHour*60+endMinute
+	if start == end {
+		return 0, 0, fmt.Errorf("invalid active hours %q, the window is empty", hours)
+	}
+
+	return start % (24 * 60), end % (24 * 60), nil
+}
+
+// NewSamplingPolicy creates the sampling policy of a loop from its configuration, the interval, the multiplier and the
+// maximum interval that aren't configured are taken from the defaults
+func NewSamplingPolicy(conf config.SamplingPolicy, defaults ExponentialPolicy, loadSource LoadSource) (SamplingPolicy, error) {
+	exponential := defaults
+	if conf.Interval > 0 {
+		exponential.Initial = time.Duration(conf.Interval) * time.Second
+	}
+	if conf.Multiplier > 0 {
+		exponential.Factor = conf.Multiplier
+	}
+	if conf.MaxInterval > 0 {
+		exponential.Max = time.Duration(conf.MaxInterval) * time.Second
+	}
+	if exponential.Initial <= 0 {
+		return nil, fmt.Errorf("sampling interval has to be positive")
+	}
+
+	base := func(name string) (SamplingPolicy, error) {
+		switch name {
+		case "", SamplingExponential:
+			return exponential, nil
+		case SamplingFixed:
+			return FixedPolicy{Interval: exponential.Initial}, nil
+		default:
+			return nil, fmt.Errorf("invalid base sampling policy %q, expected %s or %s", name, SamplingFixed, SamplingExponential)
+		}
+	}
+
+	switch conf.Policy {
+	case "", SamplingExponential, SamplingFixed:
+		return base(conf.Policy)
+	case SamplingLoadAware:
+		basePolicy, err := base(conf.Base)
+		if err != nil {
+			return nil, err
+		}
+		if conf.FastInterval <= 0 {
+			return nil, fmt.Errorf("fast sampling interval has to be positive")
+		}
+		if loadSource == nil {
+			loadSource = SystemLoad
+		}
+		return LoadAwarePolicy{
+			Base:          basePolicy,
+			Fast:          time.Duration(conf.FastInterval) * time.Second,
+			CPUThreshold:  conf.CPUThreshold,
+			LoadThreshold: conf.LoadThreshold,
+			Load:          loadSource,
+		}, nil
+	case SamplingQuietHours:
+		basePolicy, err := base(conf.Base)
+		if err != nil {
+			return nil, err
+		}
+		start, end, err := ParseActiveHours(conf.ActiveHours)
+		if err != nil {
+			return nil, err
+		}
+		return QuietHoursPolicy{Base: basePolicy, Start: start, End: end}, nil
+	default:
+		return nil, fmt.Errorf("invalid sampling policy %q, expected %s, %s, %s or %s",
+			conf.Policy, SamplingFixed, SamplingExponential, SamplingLoadAware, SamplingQuietHours)
+	}
+}
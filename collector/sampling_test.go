@@ -0,0 +1,122 @@
+package collector
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/devzero-inc/local-developer-analytics/config"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestExponentialPolicyBacksOffUpToTheMaximum(t *testing.T) {
+	policy := ExponentialPolicy{Initial: time.Second, Factor: 3, Max: 20 * time.Second}
+	now := time.Now()
+
+	var intervals []time.Duration
+	interval := time.Duration(0)
+	for i := 0; i < 5; i++ {
+		interval = policy.Next(now, interval)
+		intervals = append(intervals, interval)
+	}
+
+	assert.Equal(t, []time.Duration{time.Second, 3 * time.Second, 9 * time.Second, 20 * time.Second, 20 * time.Second}, intervals)
+	assert.Equal(t, 5*time.Second, FixedPolicy{Interval: 5 * time.Second}.Next(now, time.Hour))
+}
+
+func TestLoadAwarePolicySamplesFasterUnderLoad(t *testing.T) {
+	var cpuPercent, loadPerCPU float64
+	var loadErr error
+	policy := LoadAwarePolicy{
+		Base:          FixedPolicy{Interval: time.Minute},
+		Fast:          time.Second,
+		CPUThreshold:  80,
+		LoadThreshold: 1.5,
+		Load: func() (float64, float64, error) {
+			return cpuPercent, loadPerCPU, loadErr
+		},
+	}
+	now := time.Now()
+
+	assert.Equal(t, time.Minute, policy.Next(now, 0))
+
+	cpuPercent = 95
+	assert.Equal(t, time.Second, policy.Next(now, time.Minute))
+
+	cpuPercent, loadPerCPU = 10, 2
+	assert.Equal(t, time.Second, policy.Next(now, time.Second))
+
+	loadErr = errors.New("no load average")
+	assert.Equal(t, time.Minute, policy.Next(now, time.Second), "a failing load source follows the base policy")
+}
+
+func TestQuietHoursPolicyPostponesToTheActiveHours(t *testing.T) {
+	day := func(hour, minute int) time.Time {
+		return time.Date(2024, 3, 4, hour, minute, 0, 0, time.Local)
+	}
+	base := FixedPolicy{Interval: 30 * time.Minute}
+
+	office := QuietHoursPolicy{Base: base, Start: 9 * 60, End: 18 * 60}
+	assert.Equal(t, 30*time.Minute, office.Next(day(10, 0), 0))
+	assert.Equal(t, 2*time.Hour, office.Next(day(7, 0), 0), "waits for the start of the day")
+	assert.Equal(t, 15*time.Hour+15*time.Minute, office.Next(day(17, 45), 0), "waits for the next day")
+
+	night := QuietHoursPolicy{Base: base, Start: 22 * 60, End: 6 * 60}
+	assert.Equal(t, 30*time.Minute, night.Next(day(23, 0), 0))
+	assert.Equal(t, 30*time.Minute, night.Next(day(2, 0), 0))
+	assert.Equal(t, 10*time.Hour, night.Next(day(12, 0), 0))
+}
+
+func TestParseActiveHours(t *testing.T) {
+	start, end, err := ParseActiveHours("09:30-18:00")
+	assert.NoError(t, err)
+	assert.Equal(t, 9*60+30, start)
+	assert.Equal(t, 18*60, end)
+
+	start, end, err = ParseActiveHours("22:00-24:00")
+	assert.NoError(t, err)
+	assert.Equal(t, 22*60, start)
+	assert.Equal(t, 0, end)
+
+	for _, invalid := range []string{"", "9-18", "09:00-09:00", "25:00-06:00", "09:60-10:00"} {
+		_, _, err := ParseActiveHours(invalid)
+		assert.Error(t, err, invalid)
+	}
+}
+
+func TestNewSamplingPolicy(t *testing.T) {
+	defaults := ExponentialPolicy{Initial: time.Second, Factor: 3, Max: time.Hour}
+
+	policy, err := NewSamplingPolicy(config.SamplingPolicy{}, defaults, nil)
+	assert.NoError(t, err)
+	assert.Equal(t, defaults, policy)
+
+	policy, err = NewSamplingPolicy(config.SamplingPolicy{Policy: SamplingFixed, Interval: 60}, defaults, nil)
+	assert.NoError(t, err)
+	assert.Equal(t, FixedPolicy{Interval: time.Minute}, policy)
+
+	policy, err = NewSamplingPolicy(config.SamplingPolicy{
+		Policy:       SamplingLoadAware,
+		Base:         SamplingFixed,
+		Interval:     120,
+		FastInterval: 5,
+		CPUThreshold: 80,
+	}, defaults, func() (float64, float64, error) { return 90, 0, nil })
+	assert.NoError(t, err)
+	assert.Equal(t, 5*time.Second, policy.Next(time.Now(), 0))
+
+	policy, err = NewSamplingPolicy(config.SamplingPolicy{Policy: SamplingQuietHours, ActiveHours: "22:00-06:00", Multiplier: 2}, defaults, nil)
+	assert.NoError(t, err)
+	assert.Equal(t, QuietHoursPolicy{Base: ExponentialPolicy{Initial: time.Second, Factor: 2, Max: time.Hour}, Start: 22 * 60, End: 6 * 60}, policy)
+
+	for _, invalid := range []config.SamplingPolicy{
+		{Policy: "random"},
+		{Policy: SamplingLoadAware, Base: "random", FastInterval: 1},
+		{Policy: SamplingLoadAware},
+		{Policy: SamplingQuietHours},
+	} {
+		_, err := NewSamplingPolicy(invalid, defaults, nil)
+		assert.Error(t, err, invalid.Policy)
+	}
+}
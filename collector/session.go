@@ -0,0 +1,173 @@
+package collector
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/devzero-inc/local-developer-analytics/database"
+	gen "github.com/devzero-inc/local-developer-analytics/gen/api/v1"
+	"github.com/devzero-inc/local-developer-analytics/logging"
+)
+
+// Session is a terminal session, the shell identified by its PID and start time
+type Session struct {
+	Id        int64  `json:"id" db:"id"`
+	SessionID string `json:"session_id" db:"session_id"`
+	User      string `json:"user" db:"user"`
+	ShellPID  int64  `json:"shell_pid" db:"shell_pid"`
+	// ShellStartTime is the time in milliseconds the shell was started, zero when the hook didn't report it
+	ShellStartTime int64  `json:"shell_start_time" db:"shell_start_time"`
+	TTY            string `json:"tty" db:"tty"`
+	// TmuxSession is the tmux socket name with the session index, e.g. default:0, and TmuxPane the pane, e.g. %3
+	TmuxSession string `json:"tmux_session" db:"tmux_session"`
+	TmuxPane    string `json:"tmux_pane" db:"tmux_pane"`
+	// ScreenSession is the GNU screen session, e.g. 1234.pts-0.host
+	ScreenSession string `json:"screen_session" db:"screen_session"`
+	// SSHClient is the address of the SSH client when the shell runs in an SSH connection
+	SSHClient   string `json:"ssh_client" db:"ssh_client"`
+	TermProgram string `json:"term_program" db:"term_program"`
+	// FirstSeenTime and LastSeenTime are the times in milliseconds of the first and the last command of the session
+	FirstSeenTime int64 `json:"first_seen_time" db:"first_seen_time"`
+	LastSeenTime  int64 `json:"last_seen_time" db:"last_seen_time"`
+	CommandCount  int64 `json:"command_count" db:"command_count"`
+}
+
+// SessionCommand is a command on the session timeline
+type SessionCommand struct {
+	Session
+	CommandID int64  `json:"command_id" db:"command_id"`
+	Command   string `json:"command" db:"command"`
+	Category  string `json:"category" db:"category"`
+	StartTime int64  `json:"start_time" db:"start_time"`
+	EndTime   int64  `json:"end_time" db:"end_time"`
+}
+
+// sessionFromEvent builds the session of the shell that sent the event,
+// shells that don't report their PID can't be told apart and have no session
+func sessionFromEvent(event Event, now time.Time) (Session, bool) {
+	if event.ShellPID <= 0 {
+		return Session{}, false
+	}
+
+	session := Session{
+		SessionID:      fmt.Sprintf("%d-%d", event.ShellPID, event.ShellStartTime),
+		User:           event.User,
+		ShellPID:       event.ShellPID,
+		ShellStartTime: event.ShellStartTime * 1000,
+		TTY:            event.TTY,
+		TmuxPane:       event.TmuxPane,
+		ScreenSession:  event.Screen,
+		TermProgram:    event.TermProgram,
+		FirstSeenTime:  now.UnixMilli(),
+		LastSeenTime:   now.UnixMilli(),
+	}
+
+	// $TMUX is the server socket, the server PID and the session index, e.g. /tmp/tmux-1000/default,1234,0
+	if parts := strings.Split(event.Tmux, ","); len(parts) == 3 {
+		session.TmuxSession = filepath.Base(parts[0]) + ":" + parts[2]
+	}
+
+	// $SSH_CONNECTION is the client address and port followed by the server address and port
+	if fields := strings.Fields(event.SSHConnection); len(fields) > 0 {
+		session.SSHClient = fields[0]
+	}
+
+	return session, true
+}
+
+// Label names the session on the dashboard by the most specific terminal it runs in
+func (s Session) Label() string {
+	var parts []string
+	switch {
+	case s.TmuxSession != "":
+		parts = append(parts, "tmux "+s.TmuxSession+" "+s.TmuxPane)
+	case s.ScreenSession != "":
+		parts = append(parts, "screen "+s.ScreenSession)
+	case s.TTY != "":
+		parts = append(parts, s.TTY)
+	}
+	if s.SSHClient != "" {
+		parts = append(parts, "ssh "+s.SSHClient)
+	}
+	if s.TermProgram != "" {
+		parts = append(parts, s.TermProgram)
+	}
+	parts = append(parts, fmt.Sprintf("pid %d", s.ShellPID))
+
+	return strings.Join(parts, " · ")
+}
+
+// UpsertSession records a command of the session, creating the session on its first command
+func UpsertSession(session Session) error {
+	query := `INSERT INTO sessions (session_id, user, shell_pid, shell_start_time, tty, tmux_session, tmux_pane, screen_session,
+		ssh_client, term_program, first_seen_time, last_seen_time, command_count)
+	VALUES (:session_id, :user, :shell_pid, :shell_start_time, :tty, :tmux_session, :tmux_pane, :screen_session,
+		:ssh_client, :term_program, :first_seen_time, :last_seen_time, 1)
+	ON CONFLICT(session_id) DO UPDATE SET
+		last_seen_time = MAX(last_seen_time, excluded.last_seen_time),
+		command_count = command_count + 1`
+
+	_, err := database.DB.NamedExec(query, session)
+
+	return err
+}
+
+// TouchSession extends the session until the end of its latest command
+func TouchSession(sessionID string, lastSeenTime int64) error {
+	_, err := database.DB.Exec(`UPDATE sessions SET last_seen_time = MAX(last_seen_time, ?) WHERE session_id = ?`,
+		lastSeenTime, sessionID)
+
+	return err
+}
+
+// GetSession fetches a session by its session ID
+func GetSession(sessionID string) (*Session, error) {
+	var session Session
+
+	if err := database.DB.Get(&session, `SELECT * FROM sessions WHERE session_id = ?`, sessionID); err != nil {
+		logging.Log.Err(err).Msg("Failed to get session")
+		return nil, err
+	}
+
+	return &session, nil
+}
+
+// GetSessionCommandsForPeriod fetches the commands of every session for a given period ordered by session and time,
+// limited to the given modes if there are any
+func GetSessionCommandsForPeriod(start int64, end int64, modes ...string) ([]SessionCommand, error) {
+	var commands []SessionCommand
+
+	query, args, err := withModes(`SELECT sessions.*, commands.id AS command_id, commands.command AS command,
+              commands.category AS category, commands.start_time AS start_time, commands.end_time AS end_time
+              FROM commands
+              JOIN sessions ON sessions.session_id = commands.session_id
+              WHERE commands.start_time BETWEEN ? AND ? %s
+              ORDER BY sessions.first_seen_time ASC, sessions.id ASC, commands.start_time ASC;`, []interface{}{start, end}, modes)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := database.DB.Select(&commands, query, args...); err != nil {
+		logging.Log.Err(err).Msg("Failed to get session commands with start and end times")
+		return nil, err
+	}
+
+	return commands, nil
+}
+
+// MapSessionToProto maps the session to its proto message
+func MapSessionToProto(session Session) *gen.Session {
+	return &gen.Session{
+		SessionId:      session.SessionID,
+		ShellPid:       session.ShellPID,
+		ShellStartTime: session.ShellStartTime,
+		Tty:            session.TTY,
+		TmuxSession:    session.TmuxSession,
+		TmuxPane:       session.TmuxPane,
+		ScreenSession:  session.ScreenSession,
+		SshClient:      session.SSHClient,
+		TermProgram:    session.TermProgram,
+	}
+}
@@ -0,0 +1,71 @@
+package collector
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSessionFromEvent(t *testing.T) {
+	now := time.UnixMilli(1700000000000)
+
+	session, ok := sessionFromEvent(Event{
+		User:           "dev",
+		ShellPID:       4242,
+		ShellStartTime: 1699999000,
+		TTY:            "/dev/pts/3",
+		Tmux:           "/tmp/tmux-1000/default,1234,2",
+		TmuxPane:       "%7",
+		SSHConnection:  "10.0.0.5 52144 10.0.0.1 22",
+		TermProgram:    "iTerm.app",
+	}, now)
+
+	assert.True(t, ok)
+	assert.Equal(t, "4242-1699999000", session.SessionID)
+	assert.Equal(t, int64(1699999000000), session.ShellStartTime)
+	assert.Equal(t, "default:2", session.TmuxSession)
+	assert.Equal(t, "%7", session.TmuxPane)
+	assert.Equal(t, "10.0.0.5", session.SSHClient)
+	assert.Equal(t, now.UnixMilli(), session.FirstSeenTime)
+	assert.Equal(t, "tmux default:2 %7 · ssh 10.0.0.5 · iTerm.app · pid 4242", session.Label())
+
+	assert.Equal(t, "/dev/pts/3 · pid 1", Session{ShellPID: 1, TTY: "/dev/pts/3"}.Label())
+
+	_, ok = sessionFromEvent(Event{TTY: "/dev/pts/3"}, now)
+	assert.False(t, ok, "shells without a PID have no session")
+}
+
+func TestCommandsAreGroupedIntoSessions(t *testing.T) {
+	setupTestDatabase(t)
+
+	c := newTestCollector()
+	events := []Event{
+		{Command: "make build", UUID: "1", ShellPID: 100, ShellStartTime: 1, TTY: "/dev/pts/1"},
+		{Command: "npm run dev", UUID: "2", ShellPID: 200, ShellStartTime: 2, TTY: "/dev/pts/2"},
+		{Command: "go test ./...", UUID: "3", ShellPID: 100, ShellStartTime: 1, TTY: "/dev/pts/1"},
+		{Command: "ls", UUID: "4"},
+	}
+	for _, event := range events {
+		event.Type = StartEvent
+		assert.NoError(t, c.handleStartCommand(event))
+		assert.NoError(t, c.handleEndCommand(Event{Type: EndEvent, UUID: event.UUID, Result: "success", Status: "0"}, nil))
+	}
+
+	session, err := GetSession("100-1")
+	assert.NoError(t, err)
+	assert.Equal(t, int64(2), session.CommandCount)
+	assert.Equal(t, "/dev/pts/1", session.TTY)
+	assert.GreaterOrEqual(t, session.LastSeenTime, session.FirstSeenTime)
+
+	commands, err := GetSessionCommandsForPeriod(0, time.Now().UnixMilli())
+	assert.NoError(t, err)
+
+	var sessions, texts []string
+	for _, command := range commands {
+		sessions = append(sessions, command.SessionID)
+		texts = append(texts, command.Command)
+	}
+	assert.Equal(t, []string{"100-1", "100-1", "200-2"}, sessions)
+	assert.Equal(t, []string{"make build", "go test ./...", "npm run dev"}, texts)
+}
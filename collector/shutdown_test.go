@@ -0,0 +1,104 @@
+package collector
+
+import (
+	"context"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/devzero-inc/local-developer-analytics/database"
+	gen "github.com/devzero-inc/local-developer-analytics/gen/api/v1"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// slowClient is a remote client that takes a while to send
+type slowClient struct {
+	fakeClient
+	delay time.Duration
+}
+
+func (s *slowClient) SendCommands(commands []*gen.Command, auth *gen.Auth) error {
+	time.Sleep(s.delay)
+	return s.fakeClient.SendCommands(commands, auth)
+}
+
+func sendEvent(t *testing.T, socketPath string, event Event) {
+	t.Helper()
+
+	conn, err := net.Dial("unix", socketPath)
+	if !assert.NoError(t, err) {
+		return
+	}
+	defer conn.Close()
+
+	frame, _ := EncodeFrame(event)
+	_, err = conn.Write(frame)
+	assert.NoError(t, err)
+	// Wait for the collector to close the connection
+	_, _ = conn.Read(make([]byte, 1))
+}
+
+func TestGracefulShutdown(t *testing.T) {
+	setupTestDatabase(t)
+
+	socketPath := filepath.Join(t.TempDir(), "lda.sock")
+	remote := &slowClient{delay: 200 * time.Millisecond}
+	c := newTestCollector()
+	c.socketPath = socketPath
+	c.client = remote
+	c.intervalConfig.ShutdownTimeout = 5 * time.Second
+
+	ctx, cancel := context.WithCancel(context.Background())
+	stopped := make(chan struct{})
+	go func() {
+		defer close(stopped)
+		c.Collect(ctx)
+	}()
+
+	assert.Eventually(t, func() bool {
+		_, err := os.Stat(socketPath)
+		return err == nil
+	}, 5*time.Second, 10*time.Millisecond)
+
+	sendEvent(t, socketPath, Event{Type: StartEvent, Command: "sleep 1000", UUID: "running"})
+	sendEvent(t, socketPath, Event{Type: StartEvent, Command: "make build", UUID: "finished"})
+	sendEvent(t, socketPath, Event{Type: EndEvent, UUID: "finished", Result: "success", Status: "0"})
+
+	cancel()
+	select {
+	case <-stopped:
+	case <-time.After(10 * time.Second):
+		t.Fatal("collector didn't stop")
+	}
+
+	_, err := os.Stat(socketPath)
+	assert.True(t, os.IsNotExist(err), "the socket is removed")
+
+	var count int
+	assert.NoError(t, database.DB.Get(&count, "SELECT COUNT(*) FROM commands WHERE uuid = ?", "finished"))
+	assert.Equal(t, 1, count)
+
+	ongoing, err := GetOngoingCommands()
+	assert.NoError(t, err)
+	assert.Contains(t, ongoing, "running", "in-flight commands are kept for the next run")
+
+	remote.mutex.Lock()
+	assert.Len(t, remote.commands, 1, "pending sends are finished before exiting")
+	remote.mutex.Unlock()
+	assert.Equal(t, QueueStats{Received: 3, Processed: 3}, c.queue.snapshot())
+}
+
+func TestShutdownGivesUpOnRemoteSendsAtTheDeadline(t *testing.T) {
+	c := newTestCollector()
+	c.client = &slowClient{delay: time.Second}
+
+	c.sendCommands([]*gen.Command{{Command: "make"}})
+
+	start := time.Now()
+	err := c.flushRemote(time.Now().Add(50 * time.Millisecond))
+	assert.Error(t, err)
+	assert.Less(t, time.Since(start), time.Second)
+}
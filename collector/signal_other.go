@@ -0,0 +1,10 @@
+//go:build !unix
+
+package collector
+
+import "syscall"
+
+// signalName returns no name, the shells sending exit statuses of signals only run on unix
+func signalName(_ syscall.Signal) string {
+	return ""
+}
@@ -0,0 +1,14 @@
+//go:build unix
+
+package collector
+
+import (
+	"syscall"
+
+	"golang.org/x/sys/unix"
+)
+
+// signalName returns the name of the signal, like SIGTERM, empty when it is unknown
+func signalName(signal syscall.Signal) string {
+	return unix.SignalName(signal)
+}
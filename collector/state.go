@@ -0,0 +1,125 @@
+package collector
+
+import (
+	"context"
+	"time"
+)
+
+// collectorState is the state of the collection. It is owned by the state loop and only read and written
+// by the operations run on it, so it needs no locking and its changes happen in a single order.
+type collectorState struct {
+	// ongoingCommands are the commands in flight by their UUID
+	ongoingCommands map[string]Command
+	// excludedCommands are the commands rejected by the filter or started while paused, so their end events can be ignored
+	excludedCommands map[string]time.Time
+	// activeCommands is the number of commands keeping the command sampling loop running
+	activeCommands int
+	// stopSampling stops the command sampling loop, nil when it isn't running
+	stopSampling context.CancelFunc
+	// paused stops the collection of new commands and processes until it is resumed
+	paused bool
+	// pausedUntil is the time the collection resumes by itself, zero when paused until resumed
+	pausedUntil time.Time
+	// lastEventTime is the time of the last start or end event received from the shells
+	lastEventTime time.Time
+	// recordedContexts are the development contexts of the start events that were recorded or are replayed
+	recordedContexts map[string]DevContext
+	// backgroundInterval and commandInterval are the current intervals of the process sampling loops
+	backgroundInterval time.Duration
+	commandInterval    time.Duration
+}
+
+// runStateLoop runs the operations on the collector state one at a time for the lifetime of the collector,
+// until the shutdown stops it. Operations must never block, the I/O like storing commands or sampling
+// processes happens on the goroutines requesting the operations, before or after them.
+func (c *Collector) runStateLoop() {
+	for {
+		select {
+		case operation := <-c.operations:
+			operation(&c.state)
+		case <-c.stopped:
+			return
+		}
+	}
+}
+
+// do runs the operation on the state loop and waits for it to finish, once the state loop is stopped the
+// operation is not run anymore
+func (c *Collector) do(operation func(state *collectorState)) {
+	done := make(chan struct{})
+	select {
+	case c.operations <- func(state *collectorState) {
+		defer close(done)
+		operation(state)
+	}:
+		<-done
+	case <-c.stopped:
+	}
+}
+
+// stopStateLoop stops the state loop, it is the last step of the shutdown
+func (c *Collector) stopStateLoop() {
+	c.stopOnce.Do(func() {
+		close(c.stopped)
+	})
+}
+
+// isPaused reports whether the collection is paused, expired pauses are resumed
+func (s *collectorState) isPaused(now time.Time) bool {
+	if s.paused && !s.pausedUntil.IsZero() && !now.Before(s.pausedUntil) {
+		s.paused = false
+		s.pausedUntil = time.Time{}
+	}
+
+	return s.paused
+}
+
+// exclude remembers a command whose end event has to be ignored
+func (s *collectorState) exclude(uuid string, now time.Time) {
+	s.excludedCommands[uuid] = now
+}
+
+// startTracking adds the command to the in-flight commands and starts the command sampling loop when it
+// isn't running yet, it runs on the state loop
+func (c *Collector) startTracking(state *collectorState, command Command) {
+	state.ongoingCommands[command.UUID] = command
+	state.activeCommands++
+
+	if state.stopSampling == nil {
+		c.logger.Debug().Msg("Starting collection")
+		ctx, cancel := context.WithCancel(context.Background())
+		state.stopSampling = cancel
+		go c.sampleCommands(ctx)
+	}
+}
+
+// stopTracking removes the command from the in-flight commands and stops the command sampling loop once no
+// command is left, it runs on the state loop and reports whether the command was in flight
+func (c *Collector) stopTracking(state *collectorState, uuid string) bool {
+	if _, exists := state.ongoingCommands[uuid]; !exists {
+		return false
+	}
+	delete(state.ongoingCommands, uuid)
+	state.activeCommands--
+
+	if state.activeCommands == 0 && state.stopSampling != nil {
+		c.logger.Debug().Msg("Stopping collection")
+		state.stopSampling()
+		state.stopSampling = nil
+	}
+
+	return true
+}
+
+// ongoingCommands returns a copy of the in-flight commands
+func (c *Collector) ongoingCommands() map[string]Command {
+	var commands map[string]Command
+	c.do(func(state *collectorState) {
+		commands = make(map[string]Command, len(state.ongoingCommands))
+		for uuid, command := range state.ongoingCommands {
+			commands[uuid] = command
+		}
+	})
+
+	return commands
+}
@@ -0,0 +1,297 @@
+package collector
+
+import (
+	"database/sql"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/devzero-inc/local-developer-analytics/process"
+	"github.com/devzero-inc/local-developer-analytics/util"
+
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/assert"
+)
+
+// memoryStore is a store keeping everything in memory
+type memoryStore struct {
+	mutex    sync.Mutex
+	ongoing  map[string]Command
+	commands []Command
+	sessions map[string]Session
+	samples  int
+}
+
+func newMemoryStore() *memoryStore {
+	return &memoryStore{ongoing: make(map[string]Command), sessions: make(map[string]Session)}
+}
+
+func (m *memoryStore) InsertOngoingCommand(command Command) error {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	m.ongoing[command.UUID] = command
+	return nil
+}
+
+func (m *memoryStore) DeleteOngoingCommand(uuid string) error {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	delete(m.ongoing, uuid)
+	return nil
+}
+
+func (m *memoryStore) GetOngoingCommands() (map[string]Command, error) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	commands := make(map[string]Command, len(m.ongoing))
+	for uuid, command := range m.ongoing {
+		commands[uuid] = command
+	}
+	return commands, nil
+}
+
+func (m *memoryStore) InsertCommand(command Command) error {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	m.commands = append(m.commands, command)
+	return nil
+}
+
+func (m *memoryStore) InsertCommands(commands []Command) error {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	m.commands = append(m.commands, commands...)
+	return nil
+}
+
+func (m *memoryStore) GetCommandsStartedBetween(start int64, end int64) ([]Command, error) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	var commands []Command
+	for _, command := range m.commands {
+		if command.StartTime >= start && command.StartTime <= end {
+			commands = append(commands, command)
+		}
+	}
+	return commands, nil
+}
+
+func (m *memoryStore) InsertCommandComponents([]CommandComponent) error {
+	return nil
+}
+
+func (m *memoryStore) GetExecutionTimes(string, int) ([]int64, error) {
+	return nil, nil
+}
+
+func (m *memoryStore) UpsertSession(session Session) error {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	m.sessions[session.SessionID] = session
+	return nil
+}
+
+func (m *memoryStore) TouchSession(string, int64) error {
+	return nil
+}
+
+func (m *memoryStore) GetSession(sessionID string) (*Session, error) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	session, ok := m.sessions[sessionID]
+	if !ok {
+		return nil, sql.ErrNoRows
+	}
+	return &session, nil
+}
+
+func (m *memoryStore) InsertProcesses([]process.Process) error {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	m.samples++
+	return nil
+}
+
+func (m *memoryStore) InsertCommandProcesses([]process.CommandProcess) error {
+	return nil
+}
+
+func (m *memoryStore) GetResourceUsageForCommand(string) (*process.ResourceUsage, error) {
+	return &process.ResourceUsage{}, nil
+}
+
+func (m *memoryStore) GetLastSampleTimeForCommand(string) (int64, error) {
+	return 0, nil
+}
+
+func (m *memoryStore) sampleCount() int {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	return m.samples
+}
+
+// newSimulatedCollector creates a collector on a simulated clock and an in-memory store
+func newSimulatedCollector(clock Clock, store Store) *Collector {
+	util.SetupFS()
+
+	return NewCollector("", nil, zerolog.Nop(), IntervalConfig{
+		ProcessInterval:         time.Hour,
+		CommandInterval:         time.Second,
+		MaxDuration:             time.Hour,
+		AbandonedCommandTimeout: time.Hour,
+		CommandPolicy:           FixedPolicy{Interval: time.Second},
+	}, QueueConfig{}, AuthConfig{}, nil, noopProcess{}, nil, nil, nil, nil, clock, store)
+}
+
+// backdateCommand moves the start of the in-flight command back by the age
+func backdateCommand(c *Collector, uuid string, age time.Duration) {
+	c.do(func(state *collectorState) {
+		command := state.ongoingCommands[uuid]
+		command.StartTime = time.UnixMilli(command.StartTime).Add(-age).UnixMilli()
+		state.ongoingCommands[uuid] = command
+	})
+}
+
+func activeCommands(c *Collector) int {
+	var active int
+	c.do(func(state *collectorState) {
+		active = state.activeCommands
+	})
+	return active
+}
+
+func excludedCommands(c *Collector) map[string]time.Time {
+	excluded := make(map[string]time.Time)
+	c.do(func(state *collectorState) {
+		for uuid, excludedAt := range state.excludedCommands {
+			excluded[uuid] = excludedAt
+		}
+	})
+	return excluded
+}
+
+func isSampling(c *Collector) bool {
+	var sampling bool
+	c.do(func(state *collectorState) {
+		sampling = state.stopSampling != nil
+	})
+	return sampling
+}
+
+func TestSimulatedClockFiresTimersInOrder(t *testing.T) {
+	start := time.Unix(1700000000, 0)
+	clock := NewSimulatedClock(start)
+
+	late := clock.After(2 * time.Minute)
+	early := clock.After(time.Minute)
+
+	clock.Advance(30 * time.Second)
+	assert.Len(t, early, 0)
+	assert.Equal(t, 2, clock.Pending())
+
+	clock.Advance(time.Minute)
+	assert.Equal(t, start.Add(time.Minute), <-early)
+	assert.Len(t, late, 0)
+
+	// The clock never moves backwards
+	clock.Set(start)
+	assert.Equal(t, start.Add(90*time.Second), clock.Now())
+
+	clock.Advance(time.Hour)
+	assert.Equal(t, start.Add(2*time.Minute), <-late)
+	assert.Zero(t, clock.Pending())
+}
+
+func TestCommandTimesFollowTheClock(t *testing.T) {
+	start := time.Unix(1700000000, 0)
+	clock := NewSimulatedClock(start)
+	store := newMemoryStore()
+	c := newSimulatedCollector(clock, store)
+
+	assert.NoError(t, c.handleStartCommand(Event{Type: StartEvent, Command: "make build", UUID: "uuid-1"}))
+	clock.Advance(90 * time.Second)
+	assert.NoError(t, c.handleEndCommand(Event{Type: EndEvent, UUID: "uuid-1", Result: "success", Status: "0"}, nil))
+
+	if assert.Len(t, store.commands, 1) {
+		assert.Equal(t, start.UnixMilli(), store.commands[0].StartTime)
+		assert.Equal(t, int64(90000), store.commands[0].ExecutionTime)
+	}
+	assert.Empty(t, store.ongoing)
+	assert.Zero(t, activeCommands(c))
+}
+
+func TestCommandSamplingFollowsTheClock(t *testing.T) {
+	clock := NewSimulatedClock(time.Unix(1700000000, 0))
+	store := newMemoryStore()
+	c := newSimulatedCollector(clock, store)
+
+	assert.NoError(t, c.handleStartCommand(Event{Type: StartEvent, Command: "make build", UUID: "uuid-1"}))
+	assert.Equal(t, 1, store.sampleCount(), "every command is sampled when it starts")
+	assert.True(t, isSampling(c))
+
+	// The sampling loop waits for the next sample and for the maximum duration
+	for i := 2; i <= 4; i++ {
+		assert.Eventually(t, func() bool { return clock.Pending() == 2 }, time.Second, time.Millisecond)
+		clock.Advance(time.Second)
+		assert.Eventually(t, func() bool { return store.sampleCount() == i }, time.Second, time.Millisecond)
+	}
+
+	assert.NoError(t, c.handleEndCommand(Event{Type: EndEvent, UUID: "uuid-1", Result: "success", Status: "0"}, nil))
+	assert.False(t, isSampling(c))
+}
+
+func TestConcurrentEventsKeepTheStateConsistent(t *testing.T) {
+	store := newMemoryStore()
+	c := newSimulatedCollector(NewSimulatedClock(time.Unix(1700000000, 0)), store)
+
+	const shells = 20
+	const commandsPerShell = 10
+
+	var wg sync.WaitGroup
+	for shell := 0; shell < shells; shell++ {
+		wg.Add(1)
+		go func(shell int) {
+			defer wg.Done()
+			for i := 0; i < commandsPerShell; i++ {
+				uuid := fmt.Sprintf("%d-%d", shell, i)
+				assert.NoError(t, c.handleStartCommand(Event{Type: StartEvent, Command: "make", UUID: uuid, ShellPID: int64(shell + 1)}))
+				assert.NoError(t, c.handleEndCommand(Event{Type: EndEvent, UUID: uuid, Result: "success", Status: "0"}, nil))
+			}
+		}(shell)
+	}
+
+	// Control requests and the status are served while the shells send events
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		for i := 0; i < 50; i++ {
+			c.status(time.Now())
+			c.linkCommandProcesses(nil)
+			c.recordEvent()
+		}
+	}()
+
+	wg.Wait()
+
+	assert.Len(t, store.commands, shells*commandsPerShell)
+	assert.Empty(t, store.ongoing)
+	assert.Empty(t, c.ongoingCommands())
+	assert.Zero(t, activeCommands(c))
+	assert.False(t, isSampling(c))
+}
+
+func TestShutdownStopsTheStateLoop(t *testing.T) {
+	c := newSimulatedCollector(NewSimulatedClock(time.Unix(1700000000, 0)), newMemoryStore())
+	assert.NoError(t, c.handleStartCommand(Event{Type: StartEvent, Command: "make build", UUID: "uuid-1"}))
+
+	c.shutdown(time.Now().Add(time.Second))
+
+	// Operations requested after the shutdown don't block and aren't run
+	ran := false
+	c.do(func(state *collectorState) {
+		ran = true
+	})
+	assert.False(t, ran)
+	assert.Empty(t, c.ongoingCommands())
+}
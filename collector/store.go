@@ -0,0 +1,87 @@
+package collector
+
+import (
+	"github.com/devzero-inc/local-developer-analytics/process"
+)
+
+// Store persists the commands, sessions and processes collected by the collector
+type Store interface {
+	InsertOngoingCommand(command Command) error
+	DeleteOngoingCommand(uuid string) error
+	GetOngoingCommands() (map[string]Command, error)
+	InsertCommand(command Command) error
+	InsertCommands(commands []Command) error
+	GetCommandsStartedBetween(start int64, end int64) ([]Command, error)
+	InsertCommandComponents(components []CommandComponent) error
+	GetExecutionTimes(subcommand string, limit int) ([]int64, error)
+	UpsertSession(session Session) error
+	TouchSession(sessionID string, lastSeenTime int64) error
+	GetSession(sessionID string) (*Session, error)
+	InsertProcesses(processes []process.Process) error
+	InsertCommandProcesses(links []process.CommandProcess) error
+	GetResourceUsageForCommand(commandUUID string) (*process.ResourceUsage, error)
+	GetLastSampleTimeForCommand(commandUUID string) (int64, error)
+}
+
+// DatabaseStore is the store backed by the local database
+type DatabaseStore struct{}
+
+func (DatabaseStore) InsertOngoingCommand(command Command) error {
+	return InsertOngoingCommand(command)
+}
+
+func (DatabaseStore) DeleteOngoingCommand(uuid string) error {
+	return DeleteOngoingCommand(uuid)
+}
+
+func (DatabaseStore) GetOngoingCommands() (map[string]Command, error) {
+	return GetOngoingCommands()
+}
+
+func (DatabaseStore) InsertCommand(command Command) error {
+	return InsertCommand(command)
+}
+
+func (DatabaseStore) InsertCommands(commands []Command) error {
+	return InsertCommands(commands)
+}
+
+func (DatabaseStore) GetCommandsStartedBetween(start int64, end int64) ([]Command, error) {
+	return GetCommandsStartedBetween(start, end)
+}
+
+func (DatabaseStore) InsertCommandComponents(components []CommandComponent) error {
+	return InsertCommandComponents(components)
+}
+
+func (DatabaseStore) GetExecutionTimes(subcommand string, limit int) ([]int64, error) {
+	return GetExecutionTimes(subcommand, limit)
+}
+
+func (DatabaseStore) UpsertSession(session Session) error {
+	return UpsertSession(session)
+}
+
+func (DatabaseStore) TouchSession(sessionID string, lastSeenTime int64) error {
+	return TouchSession(sessionID, lastSeenTime)
+}
+
+func (DatabaseStore) GetSession(sessionID string) (*Session, error) {
+	return GetSession(sessionID)
+}
+
+func (DatabaseStore) InsertProcesses(processes []process.Process) error {
+	return process.InsertProcesses(processes)
+}
+
+func (DatabaseStore) InsertCommandProcesses(links []process.CommandProcess) error {
+	return process.InsertCommandProcesses(links)
+}
+
+func (DatabaseStore) GetResourceUsageForCommand(commandUUID string) (*process.ResourceUsage, error) {
+	return process.GetResourceUsageForCommand(commandUUID)
+}
+
+func (DatabaseStore) GetLastSampleTimeForCommand(commandUUID string) (int64, error) {
+	return process.GetLastSampleTimeForCommand(commandUUID)
+}
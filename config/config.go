@@ -7,6 +7,9 @@ import (
 	"os"
 	"os/user"
 	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
 
 	"github.com/devzero-inc/local-developer-analytics/util"
 
@@ -27,8 +30,18 @@ type Config struct {
 	CommandIntervalMultiplier float64 `mapstructure:"command_interval_multiplier"`
 	// MaxDuration max duration that collection can run for
 	MaxDuration int `mapstructure:"max_duration"`
-	// MaxConcurrentCommands maximum number of concurrent commands to collect - defaults to 20
+	// AbandonedCommandTimeout time in seconds after which a command without an end event is closed as abandoned - defaults to 24 hours
+	AbandonedCommandTimeout int `mapstructure:"abandoned_command_timeout"`
+	// LongRunningThreshold time in seconds after which an idle command is classified as interactive or a service - defaults to 60 seconds
+	LongRunningThreshold int `mapstructure:"long_running_threshold"`
+	// ShutdownTimeout time in seconds the shutdown waits for running hooks and remote sends - defaults to 10 seconds
+	ShutdownTimeout int `mapstructure:"shutdown_timeout"`
+	// MaxConcurrentCommands number of workers processing the events of the shells - defaults to 20
 	MaxConcurrentCommands int `mapstructure:"max_concurrent_commands"`
+	// EventQueueSize number of events waiting for a worker kept in memory - defaults to 1000
+	EventQueueSize int `mapstructure:"event_queue_size"`
+	// EventQueueOverflow what happens to events received while the queue is full, drop_oldest, drop_newest or spill - defaults to spill
+	EventQueueOverflow string `mapstructure:"event_queue_overflow"`
 	// RemoteCollection flag to enable remote collection - defaults to false
 	RemoteCollection bool `mapstructure:"remote_collection"`
 	// ServerAddress host to connect to for remote collection
@@ -37,8 +50,25 @@ type Config struct {
 	SecureConnection bool `mapstructure:"secure_connection"`
 	// CertFile path to the certificate file
 	CertFile string `mapstructure:"cert_file"`
-	// ExcludeRegex regular expression to exclude processes from collection
+	// SocketPath is the location of the collector socket, persisted by the installation - defaults to ~/.lda/lda.socket
+	SocketPath string `mapstructure:"socket_path"`
+	// Filters include and exclude rules for collected commands, the first matching rule decides
+	Filters []FilterRule `mapstructure:"filters"`
+	// ExcludeRegex regular expression to exclude commands from collection, deprecated in favour of Filters
 	ExcludeRegex string `mapstructure:"exclude_regex"`
+	// RedactPatterns additional regular expressions for secrets that are masked before commands are stored or sent,
+	// a named group `secret` limits the mask to that part of the match
+	RedactPatterns []string `mapstructure:"redact_patterns"`
+	// RedactEntropyThreshold entropy in bits per character above which random looking strings are masked, 0 disables it - defaults to 4.0
+	RedactEntropyThreshold float64 `mapstructure:"redact_entropy_threshold"`
+	// Hooks executables run on command start and end events, they receive the command as JSON on stdin
+	Hooks []Hook `mapstructure:"hooks"`
+	// HookConcurrency maximum number of hooks running at the same time - defaults to 4
+	HookConcurrency int `mapstructure:"hook_concurrency"`
+	// Notifications notify about long-running commands when they finish
+	Notifications Notifications `mapstructure:"notifications"`
+	// Sampling policies of the process sampling loops
+	Sampling Sampling `mapstructure:"sampling"`
 	// ProcessCollectionType type of process collection to use, ps or psutil
 	ProcessCollectionType string `mapstructure:"process_collection_type"`
 	// TeamID is the team identifier for the workspace
@@ -51,6 +81,84 @@ type Config struct {
 	WorkspaceID string `mapstructure:"workspace_id"`
 }
 
+// FilterRule is an include or exclude rule for collected commands, all non-empty matchers have to match
+type FilterRule struct {
+	// Action is either include or exclude
+	Action string `mapstructure:"action"`
+	// Command is a regular expression matched against the command
+	Command string `mapstructure:"command"`
+	// Directory is a glob matched against the working directory, `**` matches any number of directories
+	Directory string `mapstructure:"directory"`
+	// Repository is a glob matched against the repository name
+	Repository string `mapstructure:"repository"`
+	// User is the name of the user that executed the command
+	User string `mapstructure:"user"`
+}
+
+// Hook is an executable run on command events
+type Hook struct {
+	// Command is the path of the executable, `~` is expanded to the home directory
+	Command string `mapstructure:"command"`
+	// Args are passed to the executable
+	Args []string `mapstructure:"args"`
+	// Events are the events the hook runs on, start and/or end - defaults to end
+	Events []string `mapstructure:"events"`
+	// Timeout in seconds after which the hook is killed - defaults to 10 seconds
+	Timeout int `mapstructure:"timeout"`
+}
+
+// Notifications configures the thresholds and sinks of the long-running command notifications
+type Notifications struct {
+	// Threshold time in seconds after which a finished command is notified, 0 disables it - defaults to 300 seconds
+	Threshold int `mapstructure:"threshold"`
+	// CategoryThresholds override the threshold for categories, in seconds
+	CategoryThresholds map[string]int `mapstructure:"category_thresholds"`
+	// LearnedPercentile notifies commands slower than the percentile of their previous runs, 0 disables it
+	LearnedPercentile float64 `mapstructure:"learned_percentile"`
+	// LearnedMinSamples number of previous runs needed before the learned percentile is used - defaults to 20
+	LearnedMinSamples int `mapstructure:"learned_min_samples"`
+	// LearnedMinDuration time in seconds below which commands are never notified by the learned percentile - defaults to 30 seconds
+	LearnedMinDuration int `mapstructure:"learned_min_duration"`
+	// Terminal rings the bell and sends an OSC 9 notification through the terminal of the shell
+	Terminal bool `mapstructure:"terminal"`
+	// LogFile appends the notifications as JSON lines to the file
+	LogFile string `mapstructure:"log_file"`
+	// WebhookURL posts the notifications as JSON to the URL
+	WebhookURL string `mapstructure:"webhook_url"`
+	// WebhookTimeout timeout in seconds of the webhook request - defaults to 5 seconds
+	WebhookTimeout int `mapstructure:"webhook_timeout"`
+}
+
+// Sampling contains the policies of the process sampling loops
+type Sampling struct {
+	// Background is the policy of the loop sampling the processes while the collector runs
+	Background SamplingPolicy `mapstructure:"background"`
+	// Command is the policy of the loop sampling the processes while commands are running
+	Command SamplingPolicy `mapstructure:"command"`
+}
+
+// SamplingPolicy decides the intervals between the process samples of a loop
+type SamplingPolicy struct {
+	// Policy fixed, exponential, load_aware or quiet_hours - defaults to exponential
+	Policy string `mapstructure:"policy"`
+	// Base policy adjusted by the load_aware and quiet_hours policies, fixed or exponential - defaults to exponential
+	Base string `mapstructure:"base"`
+	// Interval time in seconds of the first sample - defaults to process_interval or command_interval
+	Interval int `mapstructure:"interval"`
+	// Multiplier of the exponential backoff - defaults to 3 or command_interval_multiplier
+	Multiplier float64 `mapstructure:"multiplier"`
+	// MaxInterval time in seconds the exponential backoff is capped at - defaults to max_duration
+	MaxInterval int `mapstructure:"max_interval"`
+	// FastInterval time in seconds between samples while the system is under load - defaults to 60 and 1 seconds
+	FastInterval int `mapstructure:"fast_interval"`
+	// CPUThreshold system CPU usage in percent above which the system is under load - defaults to 80
+	CPUThreshold float64 `mapstructure:"cpu_threshold"`
+	// LoadThreshold 1 minute load average per CPU above which the system is under load - defaults to 1.0
+	LoadThreshold float64 `mapstructure:"load_threshold"`
+	// ActiveHours local time window the quiet_hours policy samples in, e.g. 09:00-18:00
+	ActiveHours string `mapstructure:"active_hours"`
+}
+
 // SystemConfig Configuration that is not available via the configuration file
 type SystemConfig struct {
 	// Out is the output writer for printing information
@@ -99,8 +207,37 @@ func SetupConfig(ldaDir string, user *user.User) {
 		CommandInterval:           1,
 		CommandIntervalMultiplier: 3,
 		MaxConcurrentCommands:     20,
+		EventQueueSize:            1000,
 		ProcessCollectionType:     "ps",
 		MaxDuration:               3600,
+		AbandonedCommandTimeout:   86400,
+		LongRunningThreshold:      60,
+		ShutdownTimeout:           10,
+		HookConcurrency:           4,
+		RedactEntropyThreshold:    4.0,
+		Notifications: Notifications{
+			Threshold:          300,
+			LearnedMinSamples:  20,
+			LearnedMinDuration: 30,
+			WebhookTimeout:     5,
+		},
+		Sampling: Sampling{
+			Background: SamplingPolicy{
+				Policy:        "exponential",
+				Base:          "exponential",
+				Multiplier:    3,
+				FastInterval:  60,
+				CPUThreshold:  80,
+				LoadThreshold: 1.0,
+			},
+			Command: SamplingPolicy{
+				Policy:        "exponential",
+				Base:          "exponential",
+				FastInterval:  1,
+				CPUThreshold:  80,
+				LoadThreshold: 1.0,
+			},
+		},
 	}
 
 	if err := viper.ReadInConfig(); err != nil {
@@ -113,3 +250,36 @@ func SetupConfig(ldaDir string, user *user.User) {
 
 	AppConfig = config
 }
+
+// socketPathLine matches the socket path setting of the configuration file, commented out or not
+var socketPathLine = regexp.MustCompile(`(?m)^#?[ \t]*socket_path[ \t]*=.*$`)
+
+// SaveSocketPath persists the socket path in the configuration file, so the daemon and the shell hooks use the
+// path resolved by the installation instead of resolving it again in a different environment
+func SaveSocketPath(ldaDir string, socketPath string, user *user.User) error {
+	configPath := filepath.Join(ldaDir, "config.toml")
+
+	content, err := os.ReadFile(configPath)
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+
+	setting := "socket_path = " + strconv.Quote(socketPath)
+	text := string(content)
+	if location := socketPathLine.FindStringIndex(text); location != nil {
+		text = text[:location[0]] + setting + text[location[1]:]
+	} else {
+		// Keys after a table header would belong to the table, so the setting goes first
+		text = setting + "\n" + strings.TrimPrefix(text, "\n")
+	}
+
+	if err := util.WriteFileAndChown(configPath, []byte(text), 0644, user); err != nil {
+		return err
+	}
+
+	if AppConfig != nil {
+		AppConfig.SocketPath = socketPath
+	}
+
+	return nil
+}
@@ -0,0 +1,48 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/spf13/viper"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSaveSocketPath(t *testing.T) {
+	previous := AppConfig
+	t.Cleanup(func() { AppConfig = previous })
+	AppConfig = &Config{}
+
+	ldaDir := t.TempDir()
+	configPath := filepath.Join(ldaDir, "config.toml")
+	require.NoError(t, os.WriteFile(configPath, []byte(configExample), 0644))
+
+	require.NoError(t, SaveSocketPath(ldaDir, "/run/user/1000/lda.socket", nil))
+	assert.Equal(t, "/run/user/1000/lda.socket", AppConfig.SocketPath)
+
+	// The commented out setting is replaced, the rest of the file is kept
+	content, err := os.ReadFile(configPath)
+	require.NoError(t, err)
+	assert.Contains(t, string(content), "\nsocket_path = \"/run/user/1000/lda.socket\"\n")
+	assert.NotContains(t, string(content), "# socket_path")
+	assert.Contains(t, string(content), "# Location of the UNIX socket")
+
+	require.NoError(t, SaveSocketPath(ldaDir, "/home/dev/.lda/lda.socket", nil))
+
+	config := viper.New()
+	config.SetConfigFile(configPath)
+	require.NoError(t, config.ReadInConfig())
+	assert.Equal(t, "/home/dev/.lda/lda.socket", config.GetString("socket_path"))
+
+	// Without the setting it is added before the tables
+	require.NoError(t, os.WriteFile(configPath, []byte("[notifications]\nterminal = true\n"), 0644))
+	require.NoError(t, SaveSocketPath(ldaDir, "/tmp/lda.socket", nil))
+
+	config = viper.New()
+	config.SetConfigFile(configPath)
+	require.NoError(t, config.ReadInConfig())
+	assert.Equal(t, "/tmp/lda.socket", config.GetString("socket_path"))
+	assert.True(t, config.GetBool("notifications.terminal"))
+}
@@ -3,10 +3,12 @@ package config
 import (
 	"fmt"
 	"os"
+	"os/exec"
 	"os/user"
 	"path"
 	"path/filepath"
 	"runtime"
+	"strconv"
 
 	"github.com/devzero-inc/local-developer-analytics/util"
 
@@ -14,7 +16,14 @@ import (
 )
 
 var (
-	SupportedShells = []string{"/bin/bash", "/bin/zsh", "/bin/fish"}
+	SupportedShells = []string{"/bin/bash", "/bin/zsh", "/bin/fish", "/usr/bin/nu", "/usr/bin/pwsh"}
+)
+
+const (
+	// SocketName is the name of the collector socket file
+	SocketName = "lda.socket"
+	// runtimeDirBase is the base directory of per-user runtime directories created by systemd-logind
+	runtimeDirBase = "/run/user"
 )
 
 func GetShellType(shellLocation string) ShellType {
@@ -26,19 +35,37 @@ func GetShellType(shellLocation string) ShellType {
 		return Zsh
 	case "fish":
 		return Fish
+	case "nu":
+		return Nushell
+	case "pwsh":
+		return PowerShell
 	default:
 		return -1
 	}
 }
 
+// InstalledShells returns the locations of the supported shells found in the PATH
+func InstalledShells() []string {
+	var shells []string
+	for _, shell := range SupportedShells {
+		if location, err := exec.LookPath(path.Base(shell)); err == nil {
+			shells = append(shells, location)
+		}
+	}
+
+	return shells
+}
+
 // ShellType is the type of the shell that is supported
 type ShellType int
 
 const (
-	Bash ShellType = 0
-	Zsh  ShellType = 1
-	Fish ShellType = 2
-	Sh   ShellType = 3
+	Bash       ShellType = 0
+	Zsh        ShellType = 1
+	Fish       ShellType = 2
+	Sh         ShellType = 3
+	Nushell    ShellType = 4
+	PowerShell ShellType = 5
 )
 
 // OSType is the type of the operating system
@@ -108,6 +135,53 @@ func GetLdaDir(homeDir string, user *user.User) (string, error) {
 	return dir, nil
 }
 
+// GetSocketPath returns the location of the collector socket. The daemon, the shell hooks and the control
+// commands all use the path persisted in the configuration by the installation, so they never disagree,
+// without one the socket is in the LDA directory.
+func GetSocketPath(ldaDir string) string {
+	if AppConfig != nil && AppConfig.SocketPath != "" {
+		return AppConfig.SocketPath
+	}
+
+	return filepath.Join(ldaDir, SocketName)
+}
+
+// ResolveSocketPath picks the location of the socket at installation, in the user runtime directory when
+// the daemon runs in the session of the user and in the LDA directory otherwise. A system service starts
+// without XDG_RUNTIME_DIR and possibly before logind created the runtime directory, so it never uses it.
+func ResolveSocketPath(ldaDir string, user *user.User, systemService bool) string {
+	if !systemService {
+		if runtimeDir := getRuntimeDir(user); runtimeDir != "" {
+			return filepath.Join(runtimeDir, SocketName)
+		}
+	}
+
+	return filepath.Join(ldaDir, SocketName)
+}
+
+// getRuntimeDir returns the runtime directory of the user, XDG_RUNTIME_DIR or the directory created by logind
+func getRuntimeDir(user *user.User) string {
+	uid := strconv.Itoa(os.Getuid())
+	runtimeDir := os.Getenv("XDG_RUNTIME_DIR")
+
+	// when running with sudo the environment belongs to root, not the original user
+	if user != nil {
+		uid = user.Uid
+		runtimeDir = ""
+	}
+
+	if runtimeDir == "" {
+		runtimeDir = filepath.Join(runtimeDirBase, uid)
+	}
+
+	info, err := os.Stat(runtimeDir)
+	if err != nil || !info.IsDir() {
+		return ""
+	}
+
+	return runtimeDir
+}
+
 // GetLdaBinaryPath returns the path to the lda binary
 func GetLdaBinaryPath() (string, error) {
 	exePath, err := os.Executable()
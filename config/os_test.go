@@ -0,0 +1,58 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGetShellType(t *testing.T) {
+	assert.Equal(t, Bash, GetShellType("/bin/bash"))
+	assert.Equal(t, Zsh, GetShellType("/usr/bin/zsh"))
+	assert.Equal(t, Fish, GetShellType("/opt/homebrew/bin/fish"))
+	assert.Equal(t, Nushell, GetShellType("/home/dev/.cargo/bin/nu"))
+	assert.Equal(t, PowerShell, GetShellType("/usr/bin/pwsh"))
+	assert.Equal(t, ShellType(-1), GetShellType("/bin/tcsh"))
+
+	for _, shell := range SupportedShells {
+		assert.NotEqual(t, ShellType(-1), GetShellType(shell), shell)
+	}
+}
+
+func TestResolveSocketPath(t *testing.T) {
+	ldaDir := t.TempDir()
+	runtimeDir := t.TempDir()
+	t.Setenv("XDG_RUNTIME_DIR", runtimeDir)
+
+	assert.Equal(t, filepath.Join(runtimeDir, SocketName), ResolveSocketPath(ldaDir, nil, false))
+	// A system service doesn't run in the session owning the runtime directory
+	assert.Equal(t, filepath.Join(ldaDir, SocketName), ResolveSocketPath(ldaDir, nil, true))
+
+	t.Setenv("XDG_RUNTIME_DIR", filepath.Join(runtimeDir, "missing"))
+	assert.Equal(t, filepath.Join(ldaDir, SocketName), ResolveSocketPath(ldaDir, nil, false))
+}
+
+func TestGetSocketPathDoesNotDependOnTheSession(t *testing.T) {
+	ldaDir := t.TempDir()
+	previous := AppConfig
+	t.Cleanup(func() { AppConfig = previous })
+
+	AppConfig = &Config{}
+	t.Setenv("XDG_RUNTIME_DIR", t.TempDir())
+	assert.Equal(t, filepath.Join(ldaDir, SocketName), GetSocketPath(ldaDir))
+
+	AppConfig = &Config{SocketPath: "/run/user/1000/lda.socket"}
+	assert.Equal(t, "/run/user/1000/lda.socket", GetSocketPath(ldaDir))
+}
+
+func TestInstalledShells(t *testing.T) {
+	dir := t.TempDir()
+	for _, name := range []string{"bash", "nu"} {
+		assert.NoError(t, os.WriteFile(filepath.Join(dir, name), []byte("#!/bin/sh\n"), 0755))
+	}
+	t.Setenv("PATH", dir)
+
+	assert.Equal(t, []string{filepath.Join(dir, "bash"), filepath.Join(dir, "nu")}, InstalledShells())
+}
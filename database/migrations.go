@@ -15,6 +15,17 @@ func RunMigrations() {
 	createConfigTable()
 	addIndexOnProcesses()
 	shellTypeToLocation()
+	createOngoingCommandsTable()
+	addContextToCommands()
+	createCommandProcessesTable()
+	addResourceUsage()
+	addSubcommandToCommands()
+	addModeToCommands()
+	addIndexOnSubcommand()
+	addExitCodeToCommands()
+	createCommandComponentsTable()
+	createSessionsTable()
+	addSourceToCommands()
 }
 
 func ensureMigrationTableExists() {
@@ -148,6 +159,278 @@ func shellTypeToLocation() {
 	}
 }
 
+func createOngoingCommandsTable() {
+	migrationName := "create_ongoing_commands_table"
+	if !migrationApplied(migrationName) {
+		createOngoingCommandsTableSQL := `
+		CREATE TABLE IF NOT EXISTS ongoing_commands (
+			uuid TEXT PRIMARY KEY,
+			category TEXT NOT NULL,
+			command TEXT NOT NULL,
+			user TEXT,
+			directory TEXT,
+			start_time INTEGER,
+			repository TEXT
+		);`
+
+		_, err := DB.Exec(createOngoingCommandsTableSQL)
+		if err != nil {
+			fmt.Fprintf(config.SysConfig.ErrOut, "Failed to create ongoing_commands table: %s\n", err)
+			os.Exit(1)
+		}
+		recordMigration(migrationName)
+	}
+}
+
+func addContextToCommands() {
+	migrationName := "add_context_to_commands"
+	if !migrationApplied(migrationName) {
+		var columnsSQL []string
+		for _, table := range []string{"commands", "ongoing_commands"} {
+			columnsSQL = append(columnsSQL,
+				fmt.Sprintf(`ALTER TABLE %s ADD COLUMN git_branch TEXT NOT NULL DEFAULT '';`, table),
+				fmt.Sprintf(`ALTER TABLE %s ADD COLUMN git_commit TEXT NOT NULL DEFAULT '';`, table),
+				fmt.Sprintf(`ALTER TABLE %s ADD COLUMN git_dirty BOOLEAN NOT NULL DEFAULT 0;`, table),
+				fmt.Sprintf(`ALTER TABLE %s ADD COLUMN virtual_env TEXT NOT NULL DEFAULT '';`, table),
+				fmt.Sprintf(`ALTER TABLE %s ADD COLUMN node_version TEXT NOT NULL DEFAULT '';`, table),
+				fmt.Sprintf(`ALTER TABLE %s ADD COLUMN kube_context TEXT NOT NULL DEFAULT '';`, table),
+				fmt.Sprintf(`ALTER TABLE %s ADD COLUMN direnv_dir TEXT NOT NULL DEFAULT '';`, table),
+			)
+		}
+
+		for _, sql := range columnsSQL {
+			_, err := DB.Exec(sql)
+			if err != nil {
+				fmt.Fprintf(config.SysConfig.ErrOut, "Failed to add context columns: %s\n", err)
+				os.Exit(1)
+			}
+		}
+		recordMigration(migrationName)
+	}
+}
+
+func createCommandProcessesTable() {
+	migrationName := "create_command_processes_table"
+	if !migrationApplied(migrationName) {
+		migrationSQL := []string{
+			`ALTER TABLE commands ADD COLUMN uuid TEXT NOT NULL DEFAULT '';`,
+			`ALTER TABLE commands ADD COLUMN shell_pid INTEGER NOT NULL DEFAULT 0;`,
+			`ALTER TABLE ongoing_commands ADD COLUMN shell_pid INTEGER NOT NULL DEFAULT 0;`,
+			`CREATE TABLE IF NOT EXISTS command_processes (
+				id INTEGER PRIMARY KEY AUTOINCREMENT,
+				command_uuid TEXT NOT NULL,
+				pid INTEGER NOT NULL,
+				stored_time INTEGER NOT NULL
+			);`,
+			`CREATE INDEX IF NOT EXISTS idx_command_processes_uuid ON command_processes(command_uuid, pid, stored_time);`,
+			`CREATE INDEX IF NOT EXISTS idx_commands_uuid ON commands(uuid);`,
+		}
+
+		for _, sql := range migrationSQL {
+			_, err := DB.Exec(sql)
+			if err != nil {
+				fmt.Fprintf(config.SysConfig.ErrOut, "Failed to create command_processes table: %s\n", err)
+				os.Exit(1)
+			}
+		}
+		recordMigration(migrationName)
+	}
+}
+
+func addResourceUsage() {
+	migrationName := "add_resource_usage"
+	if !migrationApplied(migrationName) {
+		columnsSQL := []string{
+			`ALTER TABLE processes ADD COLUMN cpu_time REAL NOT NULL DEFAULT 0;`,
+			`ALTER TABLE processes ADD COLUMN rss INTEGER NOT NULL DEFAULT 0;`,
+			`ALTER TABLE processes ADD COLUMN read_bytes INTEGER NOT NULL DEFAULT 0;`,
+			`ALTER TABLE processes ADD COLUMN write_bytes INTEGER NOT NULL DEFAULT 0;`,
+			`ALTER TABLE commands ADD COLUMN cpu_time REAL NOT NULL DEFAULT 0;`,
+			`ALTER TABLE commands ADD COLUMN peak_rss INTEGER NOT NULL DEFAULT 0;`,
+			`ALTER TABLE commands ADD COLUMN read_bytes INTEGER NOT NULL DEFAULT 0;`,
+			`ALTER TABLE commands ADD COLUMN write_bytes INTEGER NOT NULL DEFAULT 0;`,
+		}
+
+		for _, sql := range columnsSQL {
+			_, err := DB.Exec(sql)
+			if err != nil {
+				fmt.Fprintf(config.SysConfig.ErrOut, "Failed to add resource usage columns: %s\n", err)
+				os.Exit(1)
+			}
+		}
+		recordMigration(migrationName)
+	}
+}
+
+func addSubcommandToCommands() {
+	migrationName := "add_subcommand_to_commands"
+	if !migrationApplied(migrationName) {
+		columnsSQL := []string{
+			`ALTER TABLE commands ADD COLUMN subcommand TEXT NOT NULL DEFAULT '';`,
+			`ALTER TABLE ongoing_commands ADD COLUMN subcommand TEXT NOT NULL DEFAULT '';`,
+		}
+
+		for _, sql := range columnsSQL {
+			_, err := DB.Exec(sql)
+			if err != nil {
+				fmt.Fprintf(config.SysConfig.ErrOut, "Failed to add subcommand column: %s\n", err)
+				os.Exit(1)
+			}
+		}
+		recordMigration(migrationName)
+	}
+}
+
+func addModeToCommands() {
+	migrationName := "add_mode_to_commands"
+	if !migrationApplied(migrationName) {
+		columnsSQL := []string{
+			// Commands stored before the classification are counted as batch commands
+			`ALTER TABLE commands ADD COLUMN mode TEXT NOT NULL DEFAULT 'batch';`,
+			`ALTER TABLE commands ADD COLUMN tty TEXT NOT NULL DEFAULT '';`,
+			`ALTER TABLE ongoing_commands ADD COLUMN mode TEXT NOT NULL DEFAULT '';`,
+			`ALTER TABLE ongoing_commands ADD COLUMN tty TEXT NOT NULL DEFAULT '';`,
+			`CREATE INDEX IF NOT EXISTS idx_commands_mode_start_time ON commands(mode, start_time);`,
+		}
+
+		for _, sql := range columnsSQL {
+			_, err := DB.Exec(sql)
+			if err != nil {
+				fmt.Fprintf(config.SysConfig.ErrOut, "Failed to add mode column: %s\n", err)
+				os.Exit(1)
+			}
+		}
+		recordMigration(migrationName)
+	}
+}
+
+func addIndexOnSubcommand() {
+	migrationName := "add_index_on_subcommand"
+	if !migrationApplied(migrationName) {
+		indexSQL := `CREATE INDEX IF NOT EXISTS idx_commands_subcommand_start_time ON commands(subcommand, start_time);`
+		if _, err := DB.Exec(indexSQL); err != nil {
+			fmt.Fprintf(config.SysConfig.ErrOut, "Failed to create index on subcommand: %s\n", err)
+			os.Exit(1)
+		}
+		recordMigration(migrationName)
+	}
+}
+
+func addExitCodeToCommands() {
+	migrationName := "add_exit_code_to_commands"
+	if !migrationApplied(migrationName) {
+		columnsSQL := []string{
+			`ALTER TABLE commands ADD COLUMN exit_code INTEGER NOT NULL DEFAULT 0;`,
+			`ALTER TABLE commands ADD COLUMN signal TEXT NOT NULL DEFAULT '';`,
+			// Reclassify the stored commands, the names of the signals other than SIGINT aren't backfilled
+			`UPDATE commands SET exit_code = CAST(status AS INTEGER) WHERE status GLOB '[0-9]*';`,
+			`UPDATE commands SET result = 'permission_denied' WHERE exit_code = 126;`,
+			`UPDATE commands SET result = 'not_found' WHERE exit_code = 127;`,
+			`UPDATE commands SET result = 'interrupted', signal = 'SIGINT' WHERE exit_code = 130;`,
+			`UPDATE commands SET result = 'killed' WHERE exit_code > 128 AND exit_code <= 192 AND exit_code != 130;`,
+			`CREATE INDEX IF NOT EXISTS idx_commands_result_start_time ON commands(result, start_time);`,
+		}
+
+		for _, sql := range columnsSQL {
+			_, err := DB.Exec(sql)
+			if err != nil {
+				fmt.Fprintf(config.SysConfig.ErrOut, "Failed to add exit code column: %s\n", err)
+				os.Exit(1)
+			}
+		}
+		recordMigration(migrationName)
+	}
+}
+
+func createCommandComponentsTable() {
+	migrationName := "create_command_components_table"
+	if !migrationApplied(migrationName) {
+		migrationSQL := []string{
+			`CREATE TABLE IF NOT EXISTS command_components (
+				id INTEGER PRIMARY KEY AUTOINCREMENT,
+				command_uuid TEXT NOT NULL,
+				position INTEGER NOT NULL,
+				operator TEXT NOT NULL DEFAULT '',
+				start_offset INTEGER NOT NULL,
+				end_offset INTEGER NOT NULL,
+				command TEXT NOT NULL,
+				category TEXT NOT NULL,
+				subcommand TEXT NOT NULL DEFAULT '',
+				execution_time INTEGER NOT NULL DEFAULT 0,
+				start_time INTEGER NOT NULL
+			);`,
+			`CREATE INDEX IF NOT EXISTS idx_command_components_uuid ON command_components(command_uuid, position);`,
+			`CREATE INDEX IF NOT EXISTS idx_command_components_category_start_time ON command_components(category, start_time);`,
+		}
+
+		for _, sql := range migrationSQL {
+			_, err := DB.Exec(sql)
+			if err != nil {
+				fmt.Fprintf(config.SysConfig.ErrOut, "Failed to create command_components table: %s\n", err)
+				os.Exit(1)
+			}
+		}
+		recordMigration(migrationName)
+	}
+}
+
+func createSessionsTable() {
+	migrationName := "create_sessions_table"
+	if !migrationApplied(migrationName) {
+		migrationSQL := []string{
+			`CREATE TABLE IF NOT EXISTS sessions (
+				id INTEGER PRIMARY KEY AUTOINCREMENT,
+				session_id TEXT NOT NULL UNIQUE,
+				user TEXT NOT NULL DEFAULT '',
+				shell_pid INTEGER NOT NULL,
+				shell_start_time INTEGER NOT NULL DEFAULT 0,
+				tty TEXT NOT NULL DEFAULT '',
+				tmux_session TEXT NOT NULL DEFAULT '',
+				tmux_pane TEXT NOT NULL DEFAULT '',
+				screen_session TEXT NOT NULL DEFAULT '',
+				ssh_client TEXT NOT NULL DEFAULT '',
+				term_program TEXT NOT NULL DEFAULT '',
+				first_seen_time INTEGER NOT NULL,
+				last_seen_time INTEGER NOT NULL,
+				command_count INTEGER NOT NULL DEFAULT 0
+			);`,
+			`CREATE INDEX IF NOT EXISTS idx_sessions_last_seen_time ON sessions(last_seen_time);`,
+			`ALTER TABLE commands ADD COLUMN session_id TEXT NOT NULL DEFAULT '';`,
+			`ALTER TABLE ongoing_commands ADD COLUMN session_id TEXT NOT NULL DEFAULT '';`,
+			`CREATE INDEX IF NOT EXISTS idx_commands_session_id_start_time ON commands(session_id, start_time);`,
+		}
+
+		for _, sql := range migrationSQL {
+			_, err := DB.Exec(sql)
+			if err != nil {
+				fmt.Fprintf(config.SysConfig.ErrOut, "Failed to create sessions table: %s\n", err)
+				os.Exit(1)
+			}
+		}
+		recordMigration(migrationName)
+	}
+}
+
+func addSourceToCommands() {
+	migrationName := "add_source_to_commands"
+	if !migrationApplied(migrationName) {
+		columnsSQL := []string{
+			`ALTER TABLE commands ADD COLUMN source TEXT NOT NULL DEFAULT 'shell';`,
+			// Imported commands are deduplicated by their start time
+			`CREATE INDEX IF NOT EXISTS idx_commands_start_time ON commands(start_time);`,
+		}
+
+		for _, sql := range columnsSQL {
+			_, err := DB.Exec(sql)
+			if err != nil {
+				fmt.Fprintf(config.SysConfig.ErrOut, "Failed to add source column: %s\n", err)
+				os.Exit(1)
+			}
+		}
+		recordMigration(migrationName)
+	}
+}
+
 func migrationApplied(migrationName string) bool {
 	var count int
 	err := DB.Get(&count, "SELECT COUNT(*) FROM schema_migrations WHERE migration_name = ?", migrationName)
@@ -0,0 +1,129 @@
+package decompose
+
+import (
+	"strings"
+
+	"mvdan.cc/sh/v3/syntax"
+)
+
+const (
+	// OperatorSequence runs the command after the previous one, `;` or a new line
+	OperatorSequence = ";"
+	// OperatorBackground runs the previous command in the background, `&`
+	OperatorBackground = "&"
+	// OperatorAnd runs the command when the previous one succeeded, `&&`
+	OperatorAnd = "&&"
+	// OperatorOr runs the command when the previous one failed, `||`
+	OperatorOr = "||"
+	// OperatorPipe pipes the output of the previous command into the command, `|`
+	OperatorPipe = "|"
+	// OperatorPipeAll pipes the output and the errors of the previous command into the command, `|&`
+	OperatorPipeAll = "|&"
+)
+
+// Component is a simple command of a command line
+type Component struct {
+	// Position is the index of the component in the command line
+	Position int
+	// Operator connects the component to the previous one, empty for the first component
+	Operator string
+	// Start and End are the byte offsets of the component in the command line
+	Start int
+	End   int
+	// Command is the text of the component, without the redirections
+	Command string
+}
+
+// Split parses the command line with the bash grammar and returns its simple commands in execution order.
+// Commands inside subshells, blocks, conditionals and loops are components too, commands inside command
+// substitutions are part of the command using them. An error is returned when the line isn't valid bash,
+// e.g. fish syntax, callers should treat the whole line as a single component then.
+func Split(line string) ([]Component, error) {
+	file, err := syntax.NewParser(syntax.Variant(syntax.LangBash)).Parse(strings.NewReader(line), "")
+	if err != nil {
+		return nil, err
+	}
+
+	splitter := splitter{line: line}
+	splitter.stmts(file.Stmts, "")
+
+	return splitter.components, nil
+}
+
+// Whole is the command line as a single component, used for lines that can't be split
+func Whole(line string) []Component {
+	return []Component{{Command: line, End: len(line)}}
+}
+
+type splitter struct {
+	line       string
+	components []Component
+}
+
+// stmts walks a statement list, the operator connects the first statement to the previous component
+func (s *splitter) stmts(stmts []*syntax.Stmt, operator string) {
+	for i, stmt := range stmts {
+		if i > 0 {
+			operator = OperatorSequence
+			if stmts[i-1].Background {
+				operator = OperatorBackground
+			}
+		}
+		s.command(stmt.Cmd, operator)
+	}
+}
+
+func (s *splitter) command(command syntax.Command, operator string) {
+	switch command := command.(type) {
+	case *syntax.CallExpr:
+		// Assignments on their own, like `FOO=bar`, don't run anything
+		if len(command.Args) == 0 {
+			return
+		}
+		s.add(command, operator)
+	case *syntax.BinaryCmd:
+		s.command(command.X.Cmd, operator)
+		s.command(command.Y.Cmd, command.Op.String())
+	case *syntax.Subshell:
+		s.stmts(command.Stmts, operator)
+	case *syntax.Block:
+		s.stmts(command.Stmts, operator)
+	case *syntax.IfClause:
+		for clause := command; clause != nil; clause = clause.Else {
+			s.stmts(clause.Cond, operator)
+			s.stmts(clause.Then, OperatorSequence)
+			operator = OperatorSequence
+		}
+	case *syntax.WhileClause:
+		s.stmts(command.Cond, operator)
+		s.stmts(command.Do, OperatorSequence)
+	case *syntax.ForClause:
+		s.stmts(command.Do, operator)
+	case *syntax.CaseClause:
+		for _, item := range command.Items {
+			s.stmts(item.Stmts, operator)
+			operator = OperatorSequence
+		}
+	case *syntax.TimeClause:
+		if command.Stmt != nil {
+			s.command(command.Stmt.Cmd, operator)
+		}
+	case *syntax.DeclClause, *syntax.TestClause, *syntax.ArithmCmd, *syntax.FuncDecl, *syntax.LetClause, *syntax.CoprocClause:
+		// Builtins and declarations don't run other commands
+	}
+}
+
+func (s *splitter) add(node syntax.Node, operator string) {
+	if len(s.components) == 0 {
+		operator = ""
+	}
+
+	start, end := int(node.Pos().Offset()), int(node.End().Offset())
+	s.components = append(s.components, Component{
+		Position: len(s.components),
+		Operator: operator,
+		Start:    start,
+		End:      end,
+		Command:  s.line[start:end],
+	})
+}
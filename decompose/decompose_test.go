@@ -0,0 +1,64 @@
+package decompose
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSplit(t *testing.T) {
+	line := "go generate ./... && go test ./... | tee out.log"
+	components, err := Split(line)
+	assert.NoError(t, err)
+	assert.Equal(t, []Component{
+		{Position: 0, Operator: "", Start: 0, End: 17, Command: "go generate ./..."},
+		{Position: 1, Operator: OperatorAnd, Start: 21, End: 34, Command: "go test ./..."},
+		{Position: 2, Operator: OperatorPipe, Start: 37, End: 48, Command: "tee out.log"},
+	}, components)
+
+	for _, component := range components {
+		assert.Equal(t, component.Command, line[component.Start:component.End])
+	}
+}
+
+func TestSplitOperators(t *testing.T) {
+	tests := []struct {
+		line      string
+		commands  []string
+		operators []string
+	}{
+		{"ls", []string{"ls"}, []string{""}},
+		{"make || echo failed; make clean", []string{"make", "echo failed", "make clean"}, []string{"", "||", ";"}},
+		{"npm run dev & go test |& grep FAIL", []string{"npm run dev", "go test", "grep FAIL"}, []string{"", "&", "|&"}},
+		{"(cd web && npm ci) && make", []string{"cd web", "npm ci", "make"}, []string{"", "&&", "&&"}},
+		{"if make; then ./bin/app; else echo no; fi", []string{"make", "./bin/app", "echo no"}, []string{"", ";", ";"}},
+		{"for f in *.go; do gofmt -l $f; done", []string{"gofmt -l $f"}, []string{""}},
+		{"FOO=bar go test > out.log 2>&1", []string{"FOO=bar go test"}, []string{""}},
+		{"echo $(git rev-parse HEAD) | pbcopy", []string{"echo $(git rev-parse HEAD)", "pbcopy"}, []string{"", "|"}},
+		{"export FOO=bar; FOO=baz", nil, nil},
+	}
+
+	for _, test := range tests {
+		components, err := Split(test.line)
+		assert.NoError(t, err, test.line)
+
+		var commands, operators []string
+		for _, component := range components {
+			commands = append(commands, component.Command)
+			operators = append(operators, component.Operator)
+		}
+		assert.Equal(t, test.commands, commands, test.line)
+		assert.Equal(t, test.operators, operators, test.line)
+	}
+}
+
+func TestSplitInvalid(t *testing.T) {
+	// fish syntax isn't valid bash
+	_, err := Split("for f in *.go; gofmt -l $f; end")
+	assert.Error(t, err)
+
+	_, err = Split("echo 'unterminated")
+	assert.Error(t, err)
+
+	assert.Equal(t, []Component{{Command: "echo 'unterminated", End: 18}}, Whole("echo 'unterminated"))
+}
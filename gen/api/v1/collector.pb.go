@@ -100,17 +100,34 @@ type Command struct {
 	sizeCache     protoimpl.SizeCache
 	unknownFields protoimpl.UnknownFields
 
-	Id            int64  `protobuf:"varint,1,opt,name=id,proto3" json:"id,omitempty"`                                            // Unique identifier for the command.
-	Category      string `protobuf:"bytes,2,opt,name=category,proto3" json:"category,omitempty"`                                 // Category of the command (e.g., system, user).
-	Command       string `protobuf:"bytes,3,opt,name=command,proto3" json:"command,omitempty"`                                   // The actual command string.
-	User          string `protobuf:"bytes,4,opt,name=user,proto3" json:"user,omitempty"`                                         // The user who executed the command.
-	Directory     string `protobuf:"bytes,5,opt,name=directory,proto3" json:"directory,omitempty"`                               // The directory from which the command was executed.
-	ExecutionTime int64  `protobuf:"varint,6,opt,name=execution_time,json=executionTime,proto3" json:"execution_time,omitempty"` // Execution time of the command in milliseconds.
-	StartTime     int64  `protobuf:"varint,7,opt,name=start_time,json=startTime,proto3" json:"start_time,omitempty"`             // Start time of the command execution (Unix timestamp).
-	EndTime       int64  `protobuf:"varint,8,opt,name=end_time,json=endTime,proto3" json:"end_time,omitempty"`                   // End time of the command execution (Unix timestamp).
-	Result        string `protobuf:"bytes,9,opt,name=result,proto3" json:"result,omitempty"`                                     // Result of executed command => success/failure
-	Status        string `protobuf:"bytes,10,opt,name=status,proto3" json:"status,omitempty"`                                    // Status of executed command
-	Repository    string `protobuf:"bytes,11,opt,name=repository,proto3" json:"repository,omitempty"`                            // Repository is repository where commands are executed
+	Id            int64               `protobuf:"varint,1,opt,name=id,proto3" json:"id,omitempty"`                                            // Unique identifier for the command.
+	Category      string              `protobuf:"bytes,2,opt,name=category,proto3" json:"category,omitempty"`                                 // Category of the command (e.g., system, user).
+	Command       string              `protobuf:"bytes,3,opt,name=command,proto3" json:"command,omitempty"`                                   // The actual command string.
+	User          string              `protobuf:"bytes,4,opt,name=user,proto3" json:"user,omitempty"`                                         // The user who executed the command.
+	Directory     string              `protobuf:"bytes,5,opt,name=directory,proto3" json:"directory,omitempty"`                               // The directory from which the command was executed.
+	ExecutionTime int64               `protobuf:"varint,6,opt,name=execution_time,json=executionTime,proto3" json:"execution_time,omitempty"` // Execution time of the command in milliseconds.
+	StartTime     int64               `protobuf:"varint,7,opt,name=start_time,json=startTime,proto3" json:"start_time,omitempty"`             // Start time of the command execution (Unix timestamp).
+	EndTime       int64               `protobuf:"varint,8,opt,name=end_time,json=endTime,proto3" json:"end_time,omitempty"`                   // End time of the command execution (Unix timestamp).
+	Result        string              `protobuf:"bytes,9,opt,name=result,proto3" json:"result,omitempty"`                                     // Result of executed command => success/failure/interrupted/killed/not_found/permission_denied/abandoned
+	Status        string              `protobuf:"bytes,10,opt,name=status,proto3" json:"status,omitempty"`                                    // Status of executed command
+	Repository    string              `protobuf:"bytes,11,opt,name=repository,proto3" json:"repository,omitempty"`                            // Repository is repository where commands are executed
+	GitBranch     string              `protobuf:"bytes,12,opt,name=git_branch,json=gitBranch,proto3" json:"git_branch,omitempty"`             // Git branch checked out when the command was executed
+	GitCommit     string              `protobuf:"bytes,13,opt,name=git_commit,json=gitCommit,proto3" json:"git_commit,omitempty"`             // Git commit checked out when the command was executed
+	GitDirty      bool                `protobuf:"varint,14,opt,name=git_dirty,json=gitDirty,proto3" json:"git_dirty,omitempty"`               // Whether the working tree had uncommitted changes
+	VirtualEnv    string              `protobuf:"bytes,15,opt,name=virtual_env,json=virtualEnv,proto3" json:"virtual_env,omitempty"`          // Active Python virtual environment
+	NodeVersion   string              `protobuf:"bytes,16,opt,name=node_version,json=nodeVersion,proto3" json:"node_version,omitempty"`       // Active nvm node version
+	KubeContext   string              `protobuf:"bytes,17,opt,name=kube_context,json=kubeContext,proto3" json:"kube_context,omitempty"`       // Active Kubernetes context
+	DirenvDir     string              `protobuf:"bytes,18,opt,name=direnv_dir,json=direnvDir,proto3" json:"direnv_dir,omitempty"`             // Directory of the loaded direnv environment
+	CpuTime       float64             `protobuf:"fixed64,19,opt,name=cpu_time,json=cpuTime,proto3" json:"cpu_time,omitempty"`                 // Total CPU time in seconds used by the processes spawned by the command
+	PeakRss       int64               `protobuf:"varint,20,opt,name=peak_rss,json=peakRss,proto3" json:"peak_rss,omitempty"`                  // Peak resident memory in bytes of the processes spawned by the command
+	ReadBytes     int64               `protobuf:"varint,21,opt,name=read_bytes,json=readBytes,proto3" json:"read_bytes,omitempty"`            // Total bytes read by the processes spawned by the command
+	WriteBytes    int64               `protobuf:"varint,22,opt,name=write_bytes,json=writeBytes,proto3" json:"write_bytes,omitempty"`         // Total bytes written by the processes spawned by the command
+	Subcommand    string              `protobuf:"bytes,23,opt,name=subcommand,proto3" json:"subcommand,omitempty"`                            // Tool with its subcommands, e.g. git push
+	Mode          string              `protobuf:"bytes,24,opt,name=mode,proto3" json:"mode,omitempty"`                                        // Mode of the command: batch, interactive or service
+	ExitCode      int32               `protobuf:"varint,25,opt,name=exit_code,json=exitCode,proto3" json:"exit_code,omitempty"`               // Exit code of the command
+	Signal        string              `protobuf:"bytes,26,opt,name=signal,proto3" json:"signal,omitempty"`                                    // Name of the signal that killed the command, e.g. SIGINT
+	Components    []*CommandComponent `protobuf:"bytes,27,rep,name=components,proto3" json:"components,omitempty"`                            // Simple commands of a compound command line, in execution order
+	Session       *Session            `protobuf:"bytes,28,opt,name=session,proto3" json:"session,omitempty"`                                  // Terminal session that executed the command, unset when unknown
 }
 
 func (x *Command) Reset() {
@@ -222,6 +239,341 @@ func (x *Command) GetRepository() string {
 	return ""
 }
 
+func (x *Command) GetGitBranch() string {
+	if x != nil {
+		return x.GitBranch
+	}
+	return ""
+}
+
+func (x *Command) GetGitCommit() string {
+	if x != nil {
+		return x.GitCommit
+	}
+	return ""
+}
+
+func (x *Command) GetGitDirty() bool {
+	if x != nil {
+		return x.GitDirty
+	}
+	return false
+}
+
+func (x *Command) GetVirtualEnv() string {
+	if x != nil {
+		return x.VirtualEnv
+	}
+	return ""
+}
+
+func (x *Command) GetNodeVersion() string {
+	if x != nil {
+		return x.NodeVersion
+	}
+	return ""
+}
+
+func (x *Command) GetKubeContext() string {
+	if x != nil {
+		return x.KubeContext
+	}
+	return ""
+}
+
+func (x *Command) GetDirenvDir() string {
+	if x != nil {
+		return x.DirenvDir
+	}
+	return ""
+}
+
+func (x *Command) GetCpuTime() float64 {
+	if x != nil {
+		return x.CpuTime
+	}
+	return 0
+}
+
+func (x *Command) GetPeakRss() int64 {
+	if x != nil {
+		return x.PeakRss
+	}
+	return 0
+}
+
+func (x *Command) GetReadBytes() int64 {
+	if x != nil {
+		return x.ReadBytes
+	}
+	return 0
+}
+
+func (x *Command) GetWriteBytes() int64 {
+	if x != nil {
+		return x.WriteBytes
+	}
+	return 0
+}
+
+func (x *Command) GetSubcommand() string {
+	if x != nil {
+		return x.Subcommand
+	}
+	return ""
+}
+
+func (x *Command) GetMode() string {
+	if x != nil {
+		return x.Mode
+	}
+	return ""
+}
+
+func (x *Command) GetExitCode() int32 {
+	if x != nil {
+		return x.ExitCode
+	}
+	return 0
+}
+
+func (x *Command) GetSignal() string {
+	if x != nil {
+		return x.Signal
+	}
+	return ""
+}
+
+func (x *Command) GetComponents() []*CommandComponent {
+	if x != nil {
+		return x.Components
+	}
+	return nil
+}
+
+func (x *Command) GetSession() *Session {
+	if x != nil {
+		return x.Session
+	}
+	return nil
+}
+
+// Define a message representing a terminal session, the shell that executed commands.
+type Session struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	SessionId      string `protobuf:"bytes,1,opt,name=session_id,json=sessionId,proto3" json:"session_id,omitempty"`                   // Identifier of the session, the shell PID and start time
+	ShellPid       int64  `protobuf:"varint,2,opt,name=shell_pid,json=shellPid,proto3" json:"shell_pid,omitempty"`                     // PID of the shell
+	ShellStartTime int64  `protobuf:"varint,3,opt,name=shell_start_time,json=shellStartTime,proto3" json:"shell_start_time,omitempty"` // Start time of the shell (Unix timestamp in milliseconds)
+	Tty            string `protobuf:"bytes,4,opt,name=tty,proto3" json:"tty,omitempty"`                                                // Terminal of the shell
+	TmuxSession    string `protobuf:"bytes,5,opt,name=tmux_session,json=tmuxSession,proto3" json:"tmux_session,omitempty"`             // tmux socket name and session index, e.g. default:0
+	TmuxPane       string `protobuf:"bytes,6,opt,name=tmux_pane,json=tmuxPane,proto3" json:"tmux_pane,omitempty"`                      // tmux pane, e.g. %3
+	ScreenSession  string `protobuf:"bytes,7,opt,name=screen_session,json=screenSession,proto3" json:"screen_session,omitempty"`       // GNU screen session
+	SshClient      string `protobuf:"bytes,8,opt,name=ssh_client,json=sshClient,proto3" json:"ssh_client,omitempty"`                   // Address of the SSH client
+	TermProgram    string `protobuf:"bytes,9,opt,name=term_program,json=termProgram,proto3" json:"term_program,omitempty"`             // Terminal emulator running the shell
+}
+
+func (x *Session) Reset() {
+	*x = Session{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_api_v1_collector_proto_msgTypes[2]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *Session) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Session) ProtoMessage() {}
+
+func (x *Session) ProtoReflect() protoreflect.Message {
+	mi := &file_api_v1_collector_proto_msgTypes[2]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Session.ProtoReflect.Descriptor instead.
+func (*Session) Descriptor() ([]byte, []int) {
+	return file_api_v1_collector_proto_rawDescGZIP(), []int{2}
+}
+
+func (x *Session) GetSessionId() string {
+	if x != nil {
+		return x.SessionId
+	}
+	return ""
+}
+
+func (x *Session) GetShellPid() int64 {
+	if x != nil {
+		return x.ShellPid
+	}
+	return 0
+}
+
+func (x *Session) GetShellStartTime() int64 {
+	if x != nil {
+		return x.ShellStartTime
+	}
+	return 0
+}
+
+func (x *Session) GetTty() string {
+	if x != nil {
+		return x.Tty
+	}
+	return ""
+}
+
+func (x *Session) GetTmuxSession() string {
+	if x != nil {
+		return x.TmuxSession
+	}
+	return ""
+}
+
+func (x *Session) GetTmuxPane() string {
+	if x != nil {
+		return x.TmuxPane
+	}
+	return ""
+}
+
+func (x *Session) GetScreenSession() string {
+	if x != nil {
+		return x.ScreenSession
+	}
+	return ""
+}
+
+func (x *Session) GetSshClient() string {
+	if x != nil {
+		return x.SshClient
+	}
+	return ""
+}
+
+func (x *Session) GetTermProgram() string {
+	if x != nil {
+		return x.TermProgram
+	}
+	return ""
+}
+
+// Define a message representing a simple command of a compound command line, e.g. a pipeline member.
+type CommandComponent struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Position      int32  `protobuf:"varint,1,opt,name=position,proto3" json:"position,omitempty"`                                // Index of the component in the command line
+	Operator      string `protobuf:"bytes,2,opt,name=operator,proto3" json:"operator,omitempty"`                                 // Operator connecting the component to the previous one (e.g. &&, ||, |), empty for the first
+	StartOffset   int32  `protobuf:"varint,3,opt,name=start_offset,json=startOffset,proto3" json:"start_offset,omitempty"`       // Byte offset of the component in the command line
+	EndOffset     int32  `protobuf:"varint,4,opt,name=end_offset,json=endOffset,proto3" json:"end_offset,omitempty"`             // Byte offset of the end of the component in the command line
+	Command       string `protobuf:"bytes,5,opt,name=command,proto3" json:"command,omitempty"`                                   // The component command string
+	Category      string `protobuf:"bytes,6,opt,name=category,proto3" json:"category,omitempty"`                                 // Category of the component
+	Subcommand    string `protobuf:"bytes,7,opt,name=subcommand,proto3" json:"subcommand,omitempty"`                             // Tool with its subcommands, e.g. git push
+	ExecutionTime int64  `protobuf:"varint,8,opt,name=execution_time,json=executionTime,proto3" json:"execution_time,omitempty"` // Share of the command execution time in milliseconds attributed to the component
+}
+
+func (x *CommandComponent) Reset() {
+	*x = CommandComponent{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_api_v1_collector_proto_msgTypes[3]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *CommandComponent) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CommandComponent) ProtoMessage() {}
+
+func (x *CommandComponent) ProtoReflect() protoreflect.Message {
+	mi := &file_api_v1_collector_proto_msgTypes[3]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CommandComponent.ProtoReflect.Descriptor instead.
+func (*CommandComponent) Descriptor() ([]byte, []int) {
+	return file_api_v1_collector_proto_rawDescGZIP(), []int{3}
+}
+
+func (x *CommandComponent) GetPosition() int32 {
+	if x != nil {
+		return x.Position
+	}
+	return 0
+}
+
+func (x *CommandComponent) GetOperator() string {
+	if x != nil {
+		return x.Operator
+	}
+	return ""
+}
+
+func (x *CommandComponent) GetStartOffset() int32 {
+	if x != nil {
+		return x.StartOffset
+	}
+	return 0
+}
+
+func (x *CommandComponent) GetEndOffset() int32 {
+	if x != nil {
+		return x.EndOffset
+	}
+	return 0
+}
+
+func (x *CommandComponent) GetCommand() string {
+	if x != nil {
+		return x.Command
+	}
+	return ""
+}
+
+func (x *CommandComponent) GetCategory() string {
+	if x != nil {
+		return x.Category
+	}
+	return ""
+}
+
+func (x *CommandComponent) GetSubcommand() string {
+	if x != nil {
+		return x.Subcommand
+	}
+	return ""
+}
+
+func (x *CommandComponent) GetExecutionTime() int64 {
+	if x != nil {
+		return x.ExecutionTime
+	}
+	return 0
+}
+
 // Define a message representing a process, including its metadata and resource usage.
 type Process struct {
 	state         protoimpl.MessageState
@@ -240,12 +592,16 @@ type Process struct {
 	CpuUsage       float64 `protobuf:"fixed64,10,opt,name=cpu_usage,json=cpuUsage,proto3" json:"cpu_usage,omitempty"`                // CPU usage percentage by the process.
 	MemoryUsage    float64 `protobuf:"fixed64,11,opt,name=memory_usage,json=memoryUsage,proto3" json:"memory_usage,omitempty"`       // Memory usage by the process in megabytes.
 	Ppid           int64   `protobuf:"varint,12,opt,name=ppid,proto3" json:"ppid,omitempty"`                                         // Parent process ID.
+	CpuTime        float64 `protobuf:"fixed64,13,opt,name=cpu_time,json=cpuTime,proto3" json:"cpu_time,omitempty"`                   // Cumulative CPU time of the process in seconds.
+	Rss            int64   `protobuf:"varint,14,opt,name=rss,proto3" json:"rss,omitempty"`                                           // Resident memory of the process in bytes.
+	ReadBytes      int64   `protobuf:"varint,15,opt,name=read_bytes,json=readBytes,proto3" json:"read_bytes,omitempty"`              // Cumulative bytes read by the process.
+	WriteBytes     int64   `protobuf:"varint,16,opt,name=write_bytes,json=writeBytes,proto3" json:"write_bytes,omitempty"`           // Cumulative bytes written by the process.
 }
 
 func (x *Process) Reset() {
 	*x = Process{}
 	if protoimpl.UnsafeEnabled {
-		mi := &file_api_v1_collector_proto_msgTypes[2]
+		mi := &file_api_v1_collector_proto_msgTypes[4]
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		ms.StoreMessageInfo(mi)
 	}
@@ -258,7 +614,7 @@ func (x *Process) String() string {
 func (*Process) ProtoMessage() {}
 
 func (x *Process) ProtoReflect() protoreflect.Message {
-	mi := &file_api_v1_collector_proto_msgTypes[2]
+	mi := &file_api_v1_collector_proto_msgTypes[4]
 	if protoimpl.UnsafeEnabled && x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -271,7 +627,7 @@ func (x *Process) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use Process.ProtoReflect.Descriptor instead.
 func (*Process) Descriptor() ([]byte, []int) {
-	return file_api_v1_collector_proto_rawDescGZIP(), []int{2}
+	return file_api_v1_collector_proto_rawDescGZIP(), []int{4}
 }
 
 func (x *Process) GetId() int64 {
@@ -358,6 +714,34 @@ func (x *Process) GetPpid() int64 {
 	return 0
 }
 
+func (x *Process) GetCpuTime() float64 {
+	if x != nil {
+		return x.CpuTime
+	}
+	return 0
+}
+
+func (x *Process) GetRss() int64 {
+	if x != nil {
+		return x.Rss
+	}
+	return 0
+}
+
+func (x *Process) GetReadBytes() int64 {
+	if x != nil {
+		return x.ReadBytes
+	}
+	return 0
+}
+
+func (x *Process) GetWriteBytes() int64 {
+	if x != nil {
+		return x.WriteBytes
+	}
+	return 0
+}
+
 // Defines a request for sending a collection of commands.
 type SendCommandsRequest struct {
 	state         protoimpl.MessageState
@@ -371,7 +755,7 @@ type SendCommandsRequest struct {
 func (x *SendCommandsRequest) Reset() {
 	*x = SendCommandsRequest{}
 	if protoimpl.UnsafeEnabled {
-		mi := &file_api_v1_collector_proto_msgTypes[3]
+		mi := &file_api_v1_collector_proto_msgTypes[5]
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		ms.StoreMessageInfo(mi)
 	}
@@ -384,7 +768,7 @@ func (x *SendCommandsRequest) String() string {
 func (*SendCommandsRequest) ProtoMessage() {}
 
 func (x *SendCommandsRequest) ProtoReflect() protoreflect.Message {
-	mi := &file_api_v1_collector_proto_msgTypes[3]
+	mi := &file_api_v1_collector_proto_msgTypes[5]
 	if protoimpl.UnsafeEnabled && x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -397,7 +781,7 @@ func (x *SendCommandsRequest) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use SendCommandsRequest.ProtoReflect.Descriptor instead.
 func (*SendCommandsRequest) Descriptor() ([]byte, []int) {
-	return file_api_v1_collector_proto_rawDescGZIP(), []int{3}
+	return file_api_v1_collector_proto_rawDescGZIP(), []int{5}
 }
 
 func (x *SendCommandsRequest) GetCommands() []*Command {
@@ -427,7 +811,7 @@ type SendProcessesRequest struct {
 func (x *SendProcessesRequest) Reset() {
 	*x = SendProcessesRequest{}
 	if protoimpl.UnsafeEnabled {
-		mi := &file_api_v1_collector_proto_msgTypes[4]
+		mi := &file_api_v1_collector_proto_msgTypes[6]
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		ms.StoreMessageInfo(mi)
 	}
@@ -440,7 +824,7 @@ func (x *SendProcessesRequest) String() string {
 func (*SendProcessesRequest) ProtoMessage() {}
 
 func (x *SendProcessesRequest) ProtoReflect() protoreflect.Message {
-	mi := &file_api_v1_collector_proto_msgTypes[4]
+	mi := &file_api_v1_collector_proto_msgTypes[6]
 	if protoimpl.UnsafeEnabled && x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -453,7 +837,7 @@ func (x *SendProcessesRequest) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use SendProcessesRequest.ProtoReflect.Descriptor instead.
 func (*SendProcessesRequest) Descriptor() ([]byte, []int) {
-	return file_api_v1_collector_proto_rawDescGZIP(), []int{4}
+	return file_api_v1_collector_proto_rawDescGZIP(), []int{6}
 }
 
 func (x *SendProcessesRequest) GetProcesses() []*Process {
@@ -486,7 +870,7 @@ var file_api_v1_collector_proto_rawDesc = []byte{
 	0x12, 0x1d, 0x0a, 0x0a, 0x75, 0x73, 0x65, 0x72, 0x5f, 0x65, 0x6d, 0x61, 0x69, 0x6c, 0x18, 0x04,
 	0x20, 0x01, 0x28, 0x09, 0x52, 0x09, 0x75, 0x73, 0x65, 0x72, 0x45, 0x6d, 0x61, 0x69, 0x6c, 0x42,
 	0x0f, 0x0a, 0x0d, 0x5f, 0x77, 0x6f, 0x72, 0x6b, 0x73, 0x70, 0x61, 0x63, 0x65, 0x5f, 0x69, 0x64,
-	0x22, 0xb2, 0x02, 0x0a, 0x07, 0x43, 0x6f, 0x6d, 0x6d, 0x61, 0x6e, 0x64, 0x12, 0x0e, 0x0a, 0x02,
+	0x22, 0xd7, 0x06, 0x0a, 0x07, 0x43, 0x6f, 0x6d, 0x6d, 0x61, 0x6e, 0x64, 0x12, 0x0e, 0x0a, 0x02,
 	0x69, 0x64, 0x18, 0x01, 0x20, 0x01, 0x28, 0x03, 0x52, 0x02, 0x69, 0x64, 0x12, 0x1a, 0x0a, 0x08,
 	0x63, 0x61, 0x74, 0x65, 0x67, 0x6f, 0x72, 0x79, 0x18, 0x02, 0x20, 0x01, 0x28, 0x09, 0x52, 0x08,
 	0x63, 0x61, 0x74, 0x65, 0x67, 0x6f, 0x72, 0x79, 0x12, 0x18, 0x0a, 0x07, 0x63, 0x6f, 0x6d, 0x6d,
@@ -505,58 +889,134 @@ var file_api_v1_collector_proto_rawDesc = []byte{
 	0x06, 0x73, 0x74, 0x61, 0x74, 0x75, 0x73, 0x18, 0x0a, 0x20, 0x01, 0x28, 0x09, 0x52, 0x06, 0x73,
 	0x74, 0x61, 0x74, 0x75, 0x73, 0x12, 0x1e, 0x0a, 0x0a, 0x72, 0x65, 0x70, 0x6f, 0x73, 0x69, 0x74,
 	0x6f, 0x72, 0x79, 0x18, 0x0b, 0x20, 0x01, 0x28, 0x09, 0x52, 0x0a, 0x72, 0x65, 0x70, 0x6f, 0x73,
-	0x69, 0x74, 0x6f, 0x72, 0x79, 0x22, 0xc4, 0x02, 0x0a, 0x07, 0x50, 0x72, 0x6f, 0x63, 0x65, 0x73,
-	0x73, 0x12, 0x0e, 0x0a, 0x02, 0x69, 0x64, 0x18, 0x01, 0x20, 0x01, 0x28, 0x03, 0x52, 0x02, 0x69,
-	0x64, 0x12, 0x10, 0x0a, 0x03, 0x70, 0x69, 0x64, 0x18, 0x02, 0x20, 0x01, 0x28, 0x03, 0x52, 0x03,
-	0x70, 0x69, 0x64, 0x12, 0x12, 0x0a, 0x04, 0x6e, 0x61, 0x6d, 0x65, 0x18, 0x03, 0x20, 0x01, 0x28,
-	0x09, 0x52, 0x04, 0x6e, 0x61, 0x6d, 0x65, 0x12, 0x16, 0x0a, 0x06, 0x73, 0x74, 0x61, 0x74, 0x75,
-	0x73, 0x18, 0x04, 0x20, 0x01, 0x28, 0x09, 0x52, 0x06, 0x73, 0x74, 0x61, 0x74, 0x75, 0x73, 0x12,
-	0x21, 0x0a, 0x0c, 0x63, 0x72, 0x65, 0x61, 0x74, 0x65, 0x64, 0x5f, 0x74, 0x69, 0x6d, 0x65, 0x18,
-	0x05, 0x20, 0x01, 0x28, 0x03, 0x52, 0x0b, 0x63, 0x72, 0x65, 0x61, 0x74, 0x65, 0x64, 0x54, 0x69,
-	0x6d, 0x65, 0x12, 0x1f, 0x0a, 0x0b, 0x73, 0x74, 0x6f, 0x72, 0x65, 0x64, 0x5f, 0x74, 0x69, 0x6d,
-	0x65, 0x18, 0x06, 0x20, 0x01, 0x28, 0x03, 0x52, 0x0a, 0x73, 0x74, 0x6f, 0x72, 0x65, 0x64, 0x54,
-	0x69, 0x6d, 0x65, 0x12, 0x0e, 0x0a, 0x02, 0x6f, 0x73, 0x18, 0x07, 0x20, 0x01, 0x28, 0x09, 0x52,
-	0x02, 0x6f, 0x73, 0x12, 0x1a, 0x0a, 0x08, 0x70, 0x6c, 0x61, 0x74, 0x66, 0x6f, 0x72, 0x6d, 0x18,
-	0x08, 0x20, 0x01, 0x28, 0x09, 0x52, 0x08, 0x70, 0x6c, 0x61, 0x74, 0x66, 0x6f, 0x72, 0x6d, 0x12,
-	0x27, 0x0a, 0x0f, 0x70, 0x6c, 0x61, 0x74, 0x66, 0x6f, 0x72, 0x6d, 0x5f, 0x66, 0x61, 0x6d, 0x69,
-	0x6c, 0x79, 0x18, 0x09, 0x20, 0x01, 0x28, 0x09, 0x52, 0x0e, 0x70, 0x6c, 0x61, 0x74, 0x66, 0x6f,
-	0x72, 0x6d, 0x46, 0x61, 0x6d, 0x69, 0x6c, 0x79, 0x12, 0x1b, 0x0a, 0x09, 0x63, 0x70, 0x75, 0x5f,
-	0x75, 0x73, 0x61, 0x67, 0x65, 0x18, 0x0a, 0x20, 0x01, 0x28, 0x01, 0x52, 0x08, 0x63, 0x70, 0x75,
-	0x55, 0x73, 0x61, 0x67, 0x65, 0x12, 0x21, 0x0a, 0x0c, 0x6d, 0x65, 0x6d, 0x6f, 0x72, 0x79, 0x5f,
-	0x75, 0x73, 0x61, 0x67, 0x65, 0x18, 0x0b, 0x20, 0x01, 0x28, 0x01, 0x52, 0x0b, 0x6d, 0x65, 0x6d,
-	0x6f, 0x72, 0x79, 0x55, 0x73, 0x61, 0x67, 0x65, 0x12, 0x12, 0x0a, 0x04, 0x70, 0x70, 0x69, 0x64,
-	0x18, 0x0c, 0x20, 0x01, 0x28, 0x03, 0x52, 0x04, 0x70, 0x70, 0x69, 0x64, 0x22, 0x72, 0x0a, 0x13,
-	0x53, 0x65, 0x6e, 0x64, 0x43, 0x6f, 0x6d, 0x6d, 0x61, 0x6e, 0x64, 0x73, 0x52, 0x65, 0x71, 0x75,
-	0x65, 0x73, 0x74, 0x12, 0x2b, 0x0a, 0x08, 0x63, 0x6f, 0x6d, 0x6d, 0x61, 0x6e, 0x64, 0x73, 0x18,
-	0x01, 0x20, 0x03, 0x28, 0x0b, 0x32, 0x0f, 0x2e, 0x61, 0x70, 0x69, 0x2e, 0x76, 0x31, 0x2e, 0x43,
-	0x6f, 0x6d, 0x6d, 0x61, 0x6e, 0x64, 0x52, 0x08, 0x63, 0x6f, 0x6d, 0x6d, 0x61, 0x6e, 0x64, 0x73,
-	0x12, 0x25, 0x0a, 0x04, 0x61, 0x75, 0x74, 0x68, 0x18, 0x02, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x0c,
-	0x2e, 0x61, 0x70, 0x69, 0x2e, 0x76, 0x31, 0x2e, 0x41, 0x75, 0x74, 0x68, 0x48, 0x00, 0x52, 0x04,
-	0x61, 0x75, 0x74, 0x68, 0x88, 0x01, 0x01, 0x42, 0x07, 0x0a, 0x05, 0x5f, 0x61, 0x75, 0x74, 0x68,
-	0x22, 0x75, 0x0a, 0x14, 0x53, 0x65, 0x6e, 0x64, 0x50, 0x72, 0x6f, 0x63, 0x65, 0x73, 0x73, 0x65,
-	0x73, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x12, 0x2d, 0x0a, 0x09, 0x70, 0x72, 0x6f, 0x63,
-	0x65, 0x73, 0x73, 0x65, 0x73, 0x18, 0x01, 0x20, 0x03, 0x28, 0x0b, 0x32, 0x0f, 0x2e, 0x61, 0x70,
-	0x69, 0x2e, 0x76, 0x31, 0x2e, 0x50, 0x72, 0x6f, 0x63, 0x65, 0x73, 0x73, 0x52, 0x09, 0x70, 0x72,
-	0x6f, 0x63, 0x65, 0x73, 0x73, 0x65, 0x73, 0x12, 0x25, 0x0a, 0x04, 0x61, 0x75, 0x74, 0x68, 0x18,
-	0x02, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x0c, 0x2e, 0x61, 0x70, 0x69, 0x2e, 0x76, 0x31, 0x2e, 0x41,
-	0x75, 0x74, 0x68, 0x48, 0x00, 0x52, 0x04, 0x61, 0x75, 0x74, 0x68, 0x88, 0x01, 0x01, 0x42, 0x07,
-	0x0a, 0x05, 0x5f, 0x61, 0x75, 0x74, 0x68, 0x32, 0x9e, 0x01, 0x0a, 0x10, 0x43, 0x6f, 0x6c, 0x6c,
-	0x65, 0x63, 0x74, 0x6f, 0x72, 0x53, 0x65, 0x72, 0x76, 0x69, 0x63, 0x65, 0x12, 0x43, 0x0a, 0x0c,
-	0x53, 0x65, 0x6e, 0x64, 0x43, 0x6f, 0x6d, 0x6d, 0x61, 0x6e, 0x64, 0x73, 0x12, 0x1b, 0x2e, 0x61,
-	0x70, 0x69, 0x2e, 0x76, 0x31, 0x2e, 0x53, 0x65, 0x6e, 0x64, 0x43, 0x6f, 0x6d, 0x6d, 0x61, 0x6e,
-	0x64, 0x73, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x1a, 0x16, 0x2e, 0x67, 0x6f, 0x6f, 0x67,
-	0x6c, 0x65, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x62, 0x75, 0x66, 0x2e, 0x45, 0x6d, 0x70, 0x74,
-	0x79, 0x12, 0x45, 0x0a, 0x0d, 0x53, 0x65, 0x6e, 0x64, 0x50, 0x72, 0x6f, 0x63, 0x65, 0x73, 0x73,
-	0x65, 0x73, 0x12, 0x1c, 0x2e, 0x61, 0x70, 0x69, 0x2e, 0x76, 0x31, 0x2e, 0x53, 0x65, 0x6e, 0x64,
-	0x50, 0x72, 0x6f, 0x63, 0x65, 0x73, 0x73, 0x65, 0x73, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74,
-	0x1a, 0x16, 0x2e, 0x67, 0x6f, 0x6f, 0x67, 0x6c, 0x65, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x62,
-	0x75, 0x66, 0x2e, 0x45, 0x6d, 0x70, 0x74, 0x79, 0x42, 0x4f, 0x0a, 0x0a, 0x67, 0x65, 0x6e, 0x2e,
-	0x61, 0x70, 0x69, 0x2e, 0x76, 0x31, 0x50, 0x01, 0x5a, 0x3f, 0x67, 0x69, 0x74, 0x68, 0x75, 0x62,
-	0x2e, 0x63, 0x6f, 0x6d, 0x2f, 0x64, 0x65, 0x76, 0x7a, 0x65, 0x72, 0x6f, 0x2d, 0x69, 0x6e, 0x63,
-	0x2f, 0x6c, 0x6f, 0x63, 0x61, 0x6c, 0x2d, 0x64, 0x65, 0x76, 0x65, 0x6c, 0x6f, 0x70, 0x65, 0x72,
-	0x2d, 0x61, 0x6e, 0x61, 0x6c, 0x79, 0x74, 0x69, 0x63, 0x73, 0x2f, 0x67, 0x65, 0x6e, 0x2f, 0x61,
-	0x70, 0x69, 0x2f, 0x76, 0x31, 0x3b, 0x67, 0x65, 0x6e, 0x62, 0x06, 0x70, 0x72, 0x6f, 0x74, 0x6f,
-	0x33,
+	0x69, 0x74, 0x6f, 0x72, 0x79, 0x12, 0x1d, 0x0a, 0x0a, 0x67, 0x69, 0x74, 0x5f, 0x62, 0x72, 0x61,
+	0x6e, 0x63, 0x68, 0x18, 0x0c, 0x20, 0x01, 0x28, 0x09, 0x52, 0x09, 0x67, 0x69, 0x74, 0x42, 0x72,
+	0x61, 0x6e, 0x63, 0x68, 0x12, 0x1d, 0x0a, 0x0a, 0x67, 0x69, 0x74, 0x5f, 0x63, 0x6f, 0x6d, 0x6d,
+	0x69, 0x74, 0x18, 0x0d, 0x20, 0x01, 0x28, 0x09, 0x52, 0x09, 0x67, 0x69, 0x74, 0x43, 0x6f, 0x6d,
+	0x6d, 0x69, 0x74, 0x12, 0x1b, 0x0a, 0x09, 0x67, 0x69, 0x74, 0x5f, 0x64, 0x69, 0x72, 0x74, 0x79,
+	0x18, 0x0e, 0x20, 0x01, 0x28, 0x08, 0x52, 0x08, 0x67, 0x69, 0x74, 0x44, 0x69, 0x72, 0x74, 0x79,
+	0x12, 0x1f, 0x0a, 0x0b, 0x76, 0x69, 0x72, 0x74, 0x75, 0x61, 0x6c, 0x5f, 0x65, 0x6e, 0x76, 0x18,
+	0x0f, 0x20, 0x01, 0x28, 0x09, 0x52, 0x0a, 0x76, 0x69, 0x72, 0x74, 0x75, 0x61, 0x6c, 0x45, 0x6e,
+	0x76, 0x12, 0x21, 0x0a, 0x0c, 0x6e, 0x6f, 0x64, 0x65, 0x5f, 0x76, 0x65, 0x72, 0x73, 0x69, 0x6f,
+	0x6e, 0x18, 0x10, 0x20, 0x01, 0x28, 0x09, 0x52, 0x0b, 0x6e, 0x6f, 0x64, 0x65, 0x56, 0x65, 0x72,
+	0x73, 0x69, 0x6f, 0x6e, 0x12, 0x21, 0x0a, 0x0c, 0x6b, 0x75, 0x62, 0x65, 0x5f, 0x63, 0x6f, 0x6e,
+	0x74, 0x65, 0x78, 0x74, 0x18, 0x11, 0x20, 0x01, 0x28, 0x09, 0x52, 0x0b, 0x6b, 0x75, 0x62, 0x65,
+	0x43, 0x6f, 0x6e, 0x74, 0x65, 0x78, 0x74, 0x12, 0x1d, 0x0a, 0x0a, 0x64, 0x69, 0x72, 0x65, 0x6e,
+	0x76, 0x5f, 0x64, 0x69, 0x72, 0x18, 0x12, 0x20, 0x01, 0x28, 0x09, 0x52, 0x09, 0x64, 0x69, 0x72,
+	0x65, 0x6e, 0x76, 0x44, 0x69, 0x72, 0x12, 0x19, 0x0a, 0x08, 0x63, 0x70, 0x75, 0x5f, 0x74, 0x69,
+	0x6d, 0x65, 0x18, 0x13, 0x20, 0x01, 0x28, 0x01, 0x52, 0x07, 0x63, 0x70, 0x75, 0x54, 0x69, 0x6d,
+	0x65, 0x12, 0x19, 0x0a, 0x08, 0x70, 0x65, 0x61, 0x6b, 0x5f, 0x72, 0x73, 0x73, 0x18, 0x14, 0x20,
+	0x01, 0x28, 0x03, 0x52, 0x07, 0x70, 0x65, 0x61, 0x6b, 0x52, 0x73, 0x73, 0x12, 0x1d, 0x0a, 0x0a,
+	0x72, 0x65, 0x61, 0x64, 0x5f, 0x62, 0x79, 0x74, 0x65, 0x73, 0x18, 0x15, 0x20, 0x01, 0x28, 0x03,
+	0x52, 0x09, 0x72, 0x65, 0x61, 0x64, 0x42, 0x79, 0x74, 0x65, 0x73, 0x12, 0x1f, 0x0a, 0x0b, 0x77,
+	0x72, 0x69, 0x74, 0x65, 0x5f, 0x62, 0x79, 0x74, 0x65, 0x73, 0x18, 0x16, 0x20, 0x01, 0x28, 0x03,
+	0x52, 0x0a, 0x77, 0x72, 0x69, 0x74, 0x65, 0x42, 0x79, 0x74, 0x65, 0x73, 0x12, 0x1e, 0x0a, 0x0a,
+	0x73, 0x75, 0x62, 0x63, 0x6f, 0x6d, 0x6d, 0x61, 0x6e, 0x64, 0x18, 0x17, 0x20, 0x01, 0x28, 0x09,
+	0x52, 0x0a, 0x73, 0x75, 0x62, 0x63, 0x6f, 0x6d, 0x6d, 0x61, 0x6e, 0x64, 0x12, 0x12, 0x0a, 0x04,
+	0x6d, 0x6f, 0x64, 0x65, 0x18, 0x18, 0x20, 0x01, 0x28, 0x09, 0x52, 0x04, 0x6d, 0x6f, 0x64, 0x65,
+	0x12, 0x1b, 0x0a, 0x09, 0x65, 0x78, 0x69, 0x74, 0x5f, 0x63, 0x6f, 0x64, 0x65, 0x18, 0x19, 0x20,
+	0x01, 0x28, 0x05, 0x52, 0x08, 0x65, 0x78, 0x69, 0x74, 0x43, 0x6f, 0x64, 0x65, 0x12, 0x16, 0x0a,
+	0x06, 0x73, 0x69, 0x67, 0x6e, 0x61, 0x6c, 0x18, 0x1a, 0x20, 0x01, 0x28, 0x09, 0x52, 0x06, 0x73,
+	0x69, 0x67, 0x6e, 0x61, 0x6c, 0x12, 0x38, 0x0a, 0x0a, 0x63, 0x6f, 0x6d, 0x70, 0x6f, 0x6e, 0x65,
+	0x6e, 0x74, 0x73, 0x18, 0x1b, 0x20, 0x03, 0x28, 0x0b, 0x32, 0x18, 0x2e, 0x61, 0x70, 0x69, 0x2e,
+	0x76, 0x31, 0x2e, 0x43, 0x6f, 0x6d, 0x6d, 0x61, 0x6e, 0x64, 0x43, 0x6f, 0x6d, 0x70, 0x6f, 0x6e,
+	0x65, 0x6e, 0x74, 0x52, 0x0a, 0x63, 0x6f, 0x6d, 0x70, 0x6f, 0x6e, 0x65, 0x6e, 0x74, 0x73, 0x12,
+	0x29, 0x0a, 0x07, 0x73, 0x65, 0x73, 0x73, 0x69, 0x6f, 0x6e, 0x18, 0x1c, 0x20, 0x01, 0x28, 0x0b,
+	0x32, 0x0f, 0x2e, 0x61, 0x70, 0x69, 0x2e, 0x76, 0x31, 0x2e, 0x53, 0x65, 0x73, 0x73, 0x69, 0x6f,
+	0x6e, 0x52, 0x07, 0x73, 0x65, 0x73, 0x73, 0x69, 0x6f, 0x6e, 0x22, 0xaa, 0x02, 0x0a, 0x07, 0x53,
+	0x65, 0x73, 0x73, 0x69, 0x6f, 0x6e, 0x12, 0x1d, 0x0a, 0x0a, 0x73, 0x65, 0x73, 0x73, 0x69, 0x6f,
+	0x6e, 0x5f, 0x69, 0x64, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x09, 0x73, 0x65, 0x73, 0x73,
+	0x69, 0x6f, 0x6e, 0x49, 0x64, 0x12, 0x1b, 0x0a, 0x09, 0x73, 0x68, 0x65, 0x6c, 0x6c, 0x5f, 0x70,
+	0x69, 0x64, 0x18, 0x02, 0x20, 0x01, 0x28, 0x03, 0x52, 0x08, 0x73, 0x68, 0x65, 0x6c, 0x6c, 0x50,
+	0x69, 0x64, 0x12, 0x28, 0x0a, 0x10, 0x73, 0x68, 0x65, 0x6c, 0x6c, 0x5f, 0x73, 0x74, 0x61, 0x72,
+	0x74, 0x5f, 0x74, 0x69, 0x6d, 0x65, 0x18, 0x03, 0x20, 0x01, 0x28, 0x03, 0x52, 0x0e, 0x73, 0x68,
+	0x65, 0x6c, 0x6c, 0x53, 0x74, 0x61, 0x72, 0x74, 0x54, 0x69, 0x6d, 0x65, 0x12, 0x10, 0x0a, 0x03,
+	0x74, 0x74, 0x79, 0x18, 0x04, 0x20, 0x01, 0x28, 0x09, 0x52, 0x03, 0x74, 0x74, 0x79, 0x12, 0x21,
+	0x0a, 0x0c, 0x74, 0x6d, 0x75, 0x78, 0x5f, 0x73, 0x65, 0x73, 0x73, 0x69, 0x6f, 0x6e, 0x18, 0x05,
+	0x20, 0x01, 0x28, 0x09, 0x52, 0x0b, 0x74, 0x6d, 0x75, 0x78, 0x53, 0x65, 0x73, 0x73, 0x69, 0x6f,
+	0x6e, 0x12, 0x1b, 0x0a, 0x09, 0x74, 0x6d, 0x75, 0x78, 0x5f, 0x70, 0x61, 0x6e, 0x65, 0x18, 0x06,
+	0x20, 0x01, 0x28, 0x09, 0x52, 0x08, 0x74, 0x6d, 0x75, 0x78, 0x50, 0x61, 0x6e, 0x65, 0x12, 0x25,
+	0x0a, 0x0e, 0x73, 0x63, 0x72, 0x65, 0x65, 0x6e, 0x5f, 0x73, 0x65, 0x73, 0x73, 0x69, 0x6f, 0x6e,
+	0x18, 0x07, 0x20, 0x01, 0x28, 0x09, 0x52, 0x0d, 0x73, 0x63, 0x72, 0x65, 0x65, 0x6e, 0x53, 0x65,
+	0x73, 0x73, 0x69, 0x6f, 0x6e, 0x12, 0x1d, 0x0a, 0x0a, 0x73, 0x73, 0x68, 0x5f, 0x63, 0x6c, 0x69,
+	0x65, 0x6e, 0x74, 0x18, 0x08, 0x20, 0x01, 0x28, 0x09, 0x52, 0x09, 0x73, 0x73, 0x68, 0x43, 0x6c,
+	0x69, 0x65, 0x6e, 0x74, 0x12, 0x21, 0x0a, 0x0c, 0x74, 0x65, 0x72, 0x6d, 0x5f, 0x70, 0x72, 0x6f,
+	0x67, 0x72, 0x61, 0x6d, 0x18, 0x09, 0x20, 0x01, 0x28, 0x09, 0x52, 0x0b, 0x74, 0x65, 0x72, 0x6d,
+	0x50, 0x72, 0x6f, 0x67, 0x72, 0x61, 0x6d, 0x22, 0x89, 0x02, 0x0a, 0x10, 0x43, 0x6f, 0x6d, 0x6d,
+	0x61, 0x6e, 0x64, 0x43, 0x6f, 0x6d, 0x70, 0x6f, 0x6e, 0x65, 0x6e, 0x74, 0x12, 0x1a, 0x0a, 0x08,
+	0x70, 0x6f, 0x73, 0x69, 0x74, 0x69, 0x6f, 0x6e, 0x18, 0x01, 0x20, 0x01, 0x28, 0x05, 0x52, 0x08,
+	0x70, 0x6f, 0x73, 0x69, 0x74, 0x69, 0x6f, 0x6e, 0x12, 0x1a, 0x0a, 0x08, 0x6f, 0x70, 0x65, 0x72,
+	0x61, 0x74, 0x6f, 0x72, 0x18, 0x02, 0x20, 0x01, 0x28, 0x09, 0x52, 0x08, 0x6f, 0x70, 0x65, 0x72,
+	0x61, 0x74, 0x6f, 0x72, 0x12, 0x21, 0x0a, 0x0c, 0x73, 0x74, 0x61, 0x72, 0x74, 0x5f, 0x6f, 0x66,
+	0x66, 0x73, 0x65, 0x74, 0x18, 0x03, 0x20, 0x01, 0x28, 0x05, 0x52, 0x0b, 0x73, 0x74, 0x61, 0x72,
+	0x74, 0x4f, 0x66, 0x66, 0x73, 0x65, 0x74, 0x12, 0x1d, 0x0a, 0x0a, 0x65, 0x6e, 0x64, 0x5f, 0x6f,
+	0x66, 0x66, 0x73, 0x65, 0x74, 0x18, 0x04, 0x20, 0x01, 0x28, 0x05, 0x52, 0x09, 0x65, 0x6e, 0x64,
+	0x4f, 0x66, 0x66, 0x73, 0x65, 0x74, 0x12, 0x18, 0x0a, 0x07, 0x63, 0x6f, 0x6d, 0x6d, 0x61, 0x6e,
+	0x64, 0x18, 0x05, 0x20, 0x01, 0x28, 0x09, 0x52, 0x07, 0x63, 0x6f, 0x6d, 0x6d, 0x61, 0x6e, 0x64,
+	0x12, 0x1a, 0x0a, 0x08, 0x63, 0x61, 0x74, 0x65, 0x67, 0x6f, 0x72, 0x79, 0x18, 0x06, 0x20, 0x01,
+	0x28, 0x09, 0x52, 0x08, 0x63, 0x61, 0x74, 0x65, 0x67, 0x6f, 0x72, 0x79, 0x12, 0x1e, 0x0a, 0x0a,
+	0x73, 0x75, 0x62, 0x63, 0x6f, 0x6d, 0x6d, 0x61, 0x6e, 0x64, 0x18, 0x07, 0x20, 0x01, 0x28, 0x09,
+	0x52, 0x0a, 0x73, 0x75, 0x62, 0x63, 0x6f, 0x6d, 0x6d, 0x61, 0x6e, 0x64, 0x12, 0x25, 0x0a, 0x0e,
+	0x65, 0x78, 0x65, 0x63, 0x75, 0x74, 0x69, 0x6f, 0x6e, 0x5f, 0x74, 0x69, 0x6d, 0x65, 0x18, 0x08,
+	0x20, 0x01, 0x28, 0x03, 0x52, 0x0d, 0x65, 0x78, 0x65, 0x63, 0x75, 0x74, 0x69, 0x6f, 0x6e, 0x54,
+	0x69, 0x6d, 0x65, 0x22, 0xb1, 0x03, 0x0a, 0x07, 0x50, 0x72, 0x6f, 0x63, 0x65, 0x73, 0x73, 0x12,
+	0x0e, 0x0a, 0x02, 0x69, 0x64, 0x18, 0x01, 0x20, 0x01, 0x28, 0x03, 0x52, 0x02, 0x69, 0x64, 0x12,
+	0x10, 0x0a, 0x03, 0x70, 0x69, 0x64, 0x18, 0x02, 0x20, 0x01, 0x28, 0x03, 0x52, 0x03, 0x70, 0x69,
+	0x64, 0x12, 0x12, 0x0a, 0x04, 0x6e, 0x61, 0x6d, 0x65, 0x18, 0x03, 0x20, 0x01, 0x28, 0x09, 0x52,
+	0x04, 0x6e, 0x61, 0x6d, 0x65, 0x12, 0x16, 0x0a, 0x06, 0x73, 0x74, 0x61, 0x74, 0x75, 0x73, 0x18,
+	0x04, 0x20, 0x01, 0x28, 0x09, 0x52, 0x06, 0x73, 0x74, 0x61, 0x74, 0x75, 0x73, 0x12, 0x21, 0x0a,
+	0x0c, 0x63, 0x72, 0x65, 0x61, 0x74, 0x65, 0x64, 0x5f, 0x74, 0x69, 0x6d, 0x65, 0x18, 0x05, 0x20,
+	0x01, 0x28, 0x03, 0x52, 0x0b, 0x63, 0x72, 0x65, 0x61, 0x74, 0x65, 0x64, 0x54, 0x69, 0x6d, 0x65,
+	0x12, 0x1f, 0x0a, 0x0b, 0x73, 0x74, 0x6f, 0x72, 0x65, 0x64, 0x5f, 0x74, 0x69, 0x6d, 0x65, 0x18,
+	0x06, 0x20, 0x01, 0x28, 0x03, 0x52, 0x0a, 0x73, 0x74, 0x6f, 0x72, 0x65, 0x64, 0x54, 0x69, 0x6d,
+	0x65, 0x12, 0x0e, 0x0a, 0x02, 0x6f, 0x73, 0x18, 0x07, 0x20, 0x01, 0x28, 0x09, 0x52, 0x02, 0x6f,
+	0x73, 0x12, 0x1a, 0x0a, 0x08, 0x70, 0x6c, 0x61, 0x74, 0x66, 0x6f, 0x72, 0x6d, 0x18, 0x08, 0x20,
+	0x01, 0x28, 0x09, 0x52, 0x08, 0x70, 0x6c, 0x61, 0x74, 0x66, 0x6f, 0x72, 0x6d, 0x12, 0x27, 0x0a,
+	0x0f, 0x70, 0x6c, 0x61, 0x74, 0x66, 0x6f, 0x72, 0x6d, 0x5f, 0x66, 0x61, 0x6d, 0x69, 0x6c, 0x79,
+	0x18, 0x09, 0x20, 0x01, 0x28, 0x09, 0x52, 0x0e, 0x70, 0x6c, 0x61, 0x74, 0x66, 0x6f, 0x72, 0x6d,
+	0x46, 0x61, 0x6d, 0x69, 0x6c, 0x79, 0x12, 0x1b, 0x0a, 0x09, 0x63, 0x70, 0x75, 0x5f, 0x75, 0x73,
+	0x61, 0x67, 0x65, 0x18, 0x0a, 0x20, 0x01, 0x28, 0x01, 0x52, 0x08, 0x63, 0x70, 0x75, 0x55, 0x73,
+	0x61, 0x67, 0x65, 0x12, 0x21, 0x0a, 0x0c, 0x6d, 0x65, 0x6d, 0x6f, 0x72, 0x79, 0x5f, 0x75, 0x73,
+	0x61, 0x67, 0x65, 0x18, 0x0b, 0x20, 0x01, 0x28, 0x01, 0x52, 0x0b, 0x6d, 0x65, 0x6d, 0x6f, 0x72,
+	0x79, 0x55, 0x73, 0x61, 0x67, 0x65, 0x12, 0x12, 0x0a, 0x04, 0x70, 0x70, 0x69, 0x64, 0x18, 0x0c,
+	0x20, 0x01, 0x28, 0x03, 0x52, 0x04, 0x70, 0x70, 0x69, 0x64, 0x12, 0x19, 0x0a, 0x08, 0x63, 0x70,
+	0x75, 0x5f, 0x74, 0x69, 0x6d, 0x65, 0x18, 0x0d, 0x20, 0x01, 0x28, 0x01, 0x52, 0x07, 0x63, 0x70,
+	0x75, 0x54, 0x69, 0x6d, 0x65, 0x12, 0x10, 0x0a, 0x03, 0x72, 0x73, 0x73, 0x18, 0x0e, 0x20, 0x01,
+	0x28, 0x03, 0x52, 0x03, 0x72, 0x73, 0x73, 0x12, 0x1d, 0x0a, 0x0a, 0x72, 0x65, 0x61, 0x64, 0x5f,
+	0x62, 0x79, 0x74, 0x65, 0x73, 0x18, 0x0f, 0x20, 0x01, 0x28, 0x03, 0x52, 0x09, 0x72, 0x65, 0x61,
+	0x64, 0x42, 0x79, 0x74, 0x65, 0x73, 0x12, 0x1f, 0x0a, 0x0b, 0x77, 0x72, 0x69, 0x74, 0x65, 0x5f,
+	0x62, 0x79, 0x74, 0x65, 0x73, 0x18, 0x10, 0x20, 0x01, 0x28, 0x03, 0x52, 0x0a, 0x77, 0x72, 0x69,
+	0x74, 0x65, 0x42, 0x79, 0x74, 0x65, 0x73, 0x22, 0x72, 0x0a, 0x13, 0x53, 0x65, 0x6e, 0x64, 0x43,
+	0x6f, 0x6d, 0x6d, 0x61, 0x6e, 0x64, 0x73, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x12, 0x2b,
+	0x0a, 0x08, 0x63, 0x6f, 0x6d, 0x6d, 0x61, 0x6e, 0x64, 0x73, 0x18, 0x01, 0x20, 0x03, 0x28, 0x0b,
+	0x32, 0x0f, 0x2e, 0x61, 0x70, 0x69, 0x2e, 0x76, 0x31, 0x2e, 0x43, 0x6f, 0x6d, 0x6d, 0x61, 0x6e,
+	0x64, 0x52, 0x08, 0x63, 0x6f, 0x6d, 0x6d, 0x61, 0x6e, 0x64, 0x73, 0x12, 0x25, 0x0a, 0x04, 0x61,
+	0x75, 0x74, 0x68, 0x18, 0x02, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x0c, 0x2e, 0x61, 0x70, 0x69, 0x2e,
+	0x76, 0x31, 0x2e, 0x41, 0x75, 0x74, 0x68, 0x48, 0x00, 0x52, 0x04, 0x61, 0x75, 0x74, 0x68, 0x88,
+	0x01, 0x01, 0x42, 0x07, 0x0a, 0x05, 0x5f, 0x61, 0x75, 0x74, 0x68, 0x22, 0x75, 0x0a, 0x14, 0x53,
+	0x65, 0x6e, 0x64, 0x50, 0x72, 0x6f, 0x63, 0x65, 0x73, 0x73, 0x65, 0x73, 0x52, 0x65, 0x71, 0x75,
+	0x65, 0x73, 0x74, 0x12, 0x2d, 0x0a, 0x09, 0x70, 0x72, 0x6f, 0x63, 0x65, 0x73, 0x73, 0x65, 0x73,
+	0x18, 0x01, 0x20, 0x03, 0x28, 0x0b, 0x32, 0x0f, 0x2e, 0x61, 0x70, 0x69, 0x2e, 0x76, 0x31, 0x2e,
+	0x50, 0x72, 0x6f, 0x63, 0x65, 0x73, 0x73, 0x52, 0x09, 0x70, 0x72, 0x6f, 0x63, 0x65, 0x73, 0x73,
+	0x65, 0x73, 0x12, 0x25, 0x0a, 0x04, 0x61, 0x75, 0x74, 0x68, 0x18, 0x02, 0x20, 0x01, 0x28, 0x0b,
+	0x32, 0x0c, 0x2e, 0x61, 0x70, 0x69, 0x2e, 0x76, 0x31, 0x2e, 0x41, 0x75, 0x74, 0x68, 0x48, 0x00,
+	0x52, 0x04, 0x61, 0x75, 0x74, 0x68, 0x88, 0x01, 0x01, 0x42, 0x07, 0x0a, 0x05, 0x5f, 0x61, 0x75,
+	0x74, 0x68, 0x32, 0x9e, 0x01, 0x0a, 0x10, 0x43, 0x6f, 0x6c, 0x6c, 0x65, 0x63, 0x74, 0x6f, 0x72,
+	0x53, 0x65, 0x72, 0x76, 0x69, 0x63, 0x65, 0x12, 0x43, 0x0a, 0x0c, 0x53, 0x65, 0x6e, 0x64, 0x43,
+	0x6f, 0x6d, 0x6d, 0x61, 0x6e, 0x64, 0x73, 0x12, 0x1b, 0x2e, 0x61, 0x70, 0x69, 0x2e, 0x76, 0x31,
+	0x2e, 0x53, 0x65, 0x6e, 0x64, 0x43, 0x6f, 0x6d, 0x6d, 0x61, 0x6e, 0x64, 0x73, 0x52, 0x65, 0x71,
+	0x75, 0x65, 0x73, 0x74, 0x1a, 0x16, 0x2e, 0x67, 0x6f, 0x6f, 0x67, 0x6c, 0x65, 0x2e, 0x70, 0x72,
+	0x6f, 0x74, 0x6f, 0x62, 0x75, 0x66, 0x2e, 0x45, 0x6d, 0x70, 0x74, 0x79, 0x12, 0x45, 0x0a, 0x0d,
+	0x53, 0x65, 0x6e, 0x64, 0x50, 0x72, 0x6f, 0x63, 0x65, 0x73, 0x73, 0x65, 0x73, 0x12, 0x1c, 0x2e,
+	0x61, 0x70, 0x69, 0x2e, 0x76, 0x31, 0x2e, 0x53, 0x65, 0x6e, 0x64, 0x50, 0x72, 0x6f, 0x63, 0x65,
+	0x73, 0x73, 0x65, 0x73, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x1a, 0x16, 0x2e, 0x67, 0x6f,
+	0x6f, 0x67, 0x6c, 0x65, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x62, 0x75, 0x66, 0x2e, 0x45, 0x6d,
+	0x70, 0x74, 0x79, 0x42, 0x4f, 0x0a, 0x0a, 0x67, 0x65, 0x6e, 0x2e, 0x61, 0x70, 0x69, 0x2e, 0x76,
+	0x31, 0x50, 0x01, 0x5a, 0x3f, 0x67, 0x69, 0x74, 0x68, 0x75, 0x62, 0x2e, 0x63, 0x6f, 0x6d, 0x2f,
+	0x64, 0x65, 0x76, 0x7a, 0x65, 0x72, 0x6f, 0x2d, 0x69, 0x6e, 0x63, 0x2f, 0x6c, 0x6f, 0x63, 0x61,
+	0x6c, 0x2d, 0x64, 0x65, 0x76, 0x65, 0x6c, 0x6f, 0x70, 0x65, 0x72, 0x2d, 0x61, 0x6e, 0x61, 0x6c,
+	0x79, 0x74, 0x69, 0x63, 0x73, 0x2f, 0x67, 0x65, 0x6e, 0x2f, 0x61, 0x70, 0x69, 0x2f, 0x76, 0x31,
+	0x3b, 0x67, 0x65, 0x6e, 0x62, 0x06, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x33,
 }
 
 var (
@@ -571,29 +1031,33 @@ func file_api_v1_collector_proto_rawDescGZIP() []byte {
 	return file_api_v1_collector_proto_rawDescData
 }
 
-var file_api_v1_collector_proto_msgTypes = make([]protoimpl.MessageInfo, 5)
+var file_api_v1_collector_proto_msgTypes = make([]protoimpl.MessageInfo, 7)
 var file_api_v1_collector_proto_goTypes = []interface{}{
 	(*Auth)(nil),                 // 0: api.v1.Auth
 	(*Command)(nil),              // 1: api.v1.Command
-	(*Process)(nil),              // 2: api.v1.Process
-	(*SendCommandsRequest)(nil),  // 3: api.v1.SendCommandsRequest
-	(*SendProcessesRequest)(nil), // 4: api.v1.SendProcessesRequest
-	(*emptypb.Empty)(nil),        // 5: google.protobuf.Empty
+	(*Session)(nil),              // 2: api.v1.Session
+	(*CommandComponent)(nil),     // 3: api.v1.CommandComponent
+	(*Process)(nil),              // 4: api.v1.Process
+	(*SendCommandsRequest)(nil),  // 5: api.v1.SendCommandsRequest
+	(*SendProcessesRequest)(nil), // 6: api.v1.SendProcessesRequest
+	(*emptypb.Empty)(nil),        // 7: google.protobuf.Empty
 }
 var file_api_v1_collector_proto_depIdxs = []int32{
-	1, // 0: api.v1.SendCommandsRequest.commands:type_name -> api.v1.Command
-	0, // 1: api.v1.SendCommandsRequest.auth:type_name -> api.v1.Auth
-	2, // 2: api.v1.SendProcessesRequest.processes:type_name -> api.v1.Process
-	0, // 3: api.v1.SendProcessesRequest.auth:type_name -> api.v1.Auth
-	3, // 4: api.v1.CollectorService.SendCommands:input_type -> api.v1.SendCommandsRequest
-	4, // 5: api.v1.CollectorService.SendProcesses:input_type -> api.v1.SendProcessesRequest
-	5, // 6: api.v1.CollectorService.SendCommands:output_type -> google.protobuf.Empty
-	5, // 7: api.v1.CollectorService.SendProcesses:output_type -> google.protobuf.Empty
-	6, // [6:8] is the sub-list for method output_type
-	4, // [4:6] is the sub-list for method input_type
-	4, // [4:4] is the sub-list for extension type_name
-	4, // [4:4] is the sub-list for extension extendee
-	0, // [0:4] is the sub-list for field type_name
+	3, // 0: api.v1.Command.components:type_name -> api.v1.CommandComponent
+	2, // 1: api.v1.Command.session:type_name -> api.v1.Session
+	1, // 2: api.v1.SendCommandsRequest.commands:type_name -> api.v1.Command
+	0, // 3: api.v1.SendCommandsRequest.auth:type_name -> api.v1.Auth
+	4, // 4: api.v1.SendProcessesRequest.processes:type_name -> api.v1.Process
+	0, // 5: api.v1.SendProcessesRequest.auth:type_name -> api.v1.Auth
+	5, // 6: api.v1.CollectorService.SendCommands:input_type -> api.v1.SendCommandsRequest
+	6, // 7: api.v1.CollectorService.SendProcesses:input_type -> api.v1.SendProcessesRequest
+	7, // 8: api.v1.CollectorService.SendCommands:output_type -> google.protobuf.Empty
+	7, // 9: api.v1.CollectorService.SendProcesses:output_type -> google.protobuf.Empty
+	8, // [8:10] is the sub-list for method output_type
+	6, // [6:8] is the sub-list for method input_type
+	6, // [6:6] is the sub-list for extension type_name
+	6, // [6:6] is the sub-list for extension extendee
+	0, // [0:6] is the sub-list for field type_name
 }
 
 func init() { file_api_v1_collector_proto_init() }
@@ -627,7 +1091,7 @@ func file_api_v1_collector_proto_init() {
 			}
 		}
 		file_api_v1_collector_proto_msgTypes[2].Exporter = func(v interface{}, i int) interface{} {
-			switch v := v.(*Process); i {
+			switch v := v.(*Session); i {
 			case 0:
 				return &v.state
 			case 1:
@@ -639,7 +1103,7 @@ func file_api_v1_collector_proto_init() {
 			}
 		}
 		file_api_v1_collector_proto_msgTypes[3].Exporter = func(v interface{}, i int) interface{} {
-			switch v := v.(*SendCommandsRequest); i {
+			switch v := v.(*CommandComponent); i {
 			case 0:
 				return &v.state
 			case 1:
@@ -651,6 +1115,30 @@ func file_api_v1_collector_proto_init() {
 			}
 		}
 		file_api_v1_collector_proto_msgTypes[4].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*Process); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_api_v1_collector_proto_msgTypes[5].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*SendCommandsRequest); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_api_v1_collector_proto_msgTypes[6].Exporter = func(v interface{}, i int) interface{} {
 			switch v := v.(*SendProcessesRequest); i {
 			case 0:
 				return &v.state
@@ -664,15 +1152,15 @@ func file_api_v1_collector_proto_init() {
 		}
 	}
 	file_api_v1_collector_proto_msgTypes[0].OneofWrappers = []interface{}{}
-	file_api_v1_collector_proto_msgTypes[3].OneofWrappers = []interface{}{}
-	file_api_v1_collector_proto_msgTypes[4].OneofWrappers = []interface{}{}
+	file_api_v1_collector_proto_msgTypes[5].OneofWrappers = []interface{}{}
+	file_api_v1_collector_proto_msgTypes[6].OneofWrappers = []interface{}{}
 	type x struct{}
 	out := protoimpl.TypeBuilder{
 		File: protoimpl.DescBuilder{
 			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
 			RawDescriptor: file_api_v1_collector_proto_rawDesc,
 			NumEnums:      0,
-			NumMessages:   5,
+			NumMessages:   7,
 			NumExtensions: 0,
 			NumServices:   1,
 		},
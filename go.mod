@@ -4,6 +4,7 @@ go 1.21.4
 
 require (
 	connectrpc.com/connect v1.17.0
+	github.com/creack/pty v1.1.24
 	github.com/jmoiron/sqlx v1.3.5
 	github.com/manifoldco/promptui v0.9.0
 	github.com/pkg/errors v0.9.1
@@ -13,9 +14,11 @@ require (
 	github.com/spf13/cobra v1.8.0
 	github.com/spf13/viper v1.18.2
 	github.com/stretchr/testify v1.9.0
+	golang.org/x/sys v0.22.0
 	google.golang.org/grpc v1.59.0
 	google.golang.org/protobuf v1.34.2
 	modernc.org/sqlite v1.33.1
+	mvdan.cc/sh/v3 v3.7.0
 )
 
 require (
@@ -51,7 +54,6 @@ require (
 	go.uber.org/multierr v1.9.0 // indirect
 	golang.org/x/exp v0.0.0-20231108232855-2478ac86f678 // indirect
 	golang.org/x/net v0.25.0 // indirect
-	golang.org/x/sys v0.22.0 // indirect
 	golang.org/x/text v0.15.0 // indirect
 	google.golang.org/genproto/googleapis/rpc v0.0.0-20231120223509-83a465c0220f // indirect
 	gopkg.in/ini.v1 v1.67.0 // indirect
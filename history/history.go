@@ -0,0 +1,230 @@
+package history
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Shells whose history can be imported
+const (
+	Zsh  = "zsh"
+	Bash = "bash"
+	Fish = "fish"
+)
+
+// maxLineSize is the longest history line that is read, longer lines are most likely not commands
+const maxLineSize = 1 << 20
+
+// Entry is a command of a shell history
+type Entry struct {
+	Command string
+	// Time is when the command started, zero when the history has no timestamp for it
+	Time time.Time
+	// Duration is the execution time of the command, only known when HasDuration is set
+	Duration time.Duration
+	// HasDuration tells if the shell recorded the execution time, zsh records zero for commands shorter than
+	// a second, bash and fish don't record it at all
+	HasDuration bool
+}
+
+// DefaultPath returns the default location of the history file of the shell, $HISTFILE isn't exported by
+// the shells, so a custom location has to be passed explicitly
+func DefaultPath(shell string, homeDir string) (string, error) {
+	switch shell {
+	case Zsh:
+		return filepath.Join(homeDir, ".zsh_history"), nil
+	case Bash:
+		return filepath.Join(homeDir, ".bash_history"), nil
+	case Fish:
+		dataDir := os.Getenv("XDG_DATA_HOME")
+		if dataDir == "" {
+			dataDir = filepath.Join(homeDir, ".local", "share")
+		}
+		return filepath.Join(dataDir, "fish", "fish_history"), nil
+	default:
+		return "", fmt.Errorf("unsupported shell %q, expected %s, %s or %s", shell, Zsh, Bash, Fish)
+	}
+}
+
+// Parse reads the history of the shell
+func Parse(shell string, reader io.Reader) ([]Entry, error) {
+	switch shell {
+	case Zsh:
+		return ParseZsh(reader)
+	case Bash:
+		return ParseBash(reader)
+	case Fish:
+		return ParseFish(reader)
+	default:
+		return nil, fmt.Errorf("unsupported shell %q, expected %s, %s or %s", shell, Zsh, Bash, Fish)
+	}
+}
+
+// zshExtendedLine is a line of the extended history format, `: <start>:<elapsed seconds>;<command>`
+var zshExtendedLine = regexp.MustCompile(`^: *(\d+):(\d+);(.*)$`)
+
+// ParseZsh reads a zsh history. The extended format has the start time and the elapsed seconds of every
+// command, lines of the plain format have neither. Multi-line commands continue after a trailing backslash.
+func ParseZsh(reader io.Reader) ([]Entry, error) {
+	var entries []Entry
+
+	scanner := newScanner(reader)
+	for scanner.Scan() {
+		line := unmetafy(scanner.Text())
+
+		var entry Entry
+		if match := zshExtendedLine.FindStringSubmatch(line); match != nil {
+			start, _ := strconv.ParseInt(match[1], 10, 64)
+			elapsed, _ := strconv.ParseInt(match[2], 10, 64)
+			entry.Time = time.Unix(start, 0)
+			entry.Duration = time.Duration(elapsed) * time.Second
+			entry.HasDuration = true
+			line = match[3]
+		}
+
+		for strings.HasSuffix(line, "\\") && scanner.Scan() {
+			line = strings.TrimSuffix(line, "\\") + "\n" + unmetafy(scanner.Text())
+		}
+
+		entry.Command = line
+		if strings.TrimSpace(entry.Command) != "" {
+			entries = append(entries, entry)
+		}
+	}
+
+	return entries, scanner.Err()
+}
+
+// unmetafy decodes the bytes zsh escapes in its history, 0x83 followed by the byte xor 32
+func unmetafy(line string) string {
+	if strings.IndexByte(line, 0x83) < 0 {
+		return line
+	}
+
+	decoded := make([]byte, 0, len(line))
+	for i := 0; i < len(line); i++ {
+		if line[i] == 0x83 && i+1 < len(line) {
+			i++
+			decoded = append(decoded, line[i]^32)
+			continue
+		}
+		decoded = append(decoded, line[i])
+	}
+
+	return string(decoded)
+}
+
+// bashTimestampLine is the comment bash writes before every command when HISTTIMEFORMAT is set
+var bashTimestampLine = regexp.MustCompile(`^#(\d{9,})$`)
+
+// ParseBash reads a bash history. With HISTTIMEFORMAT set, a `#<start>` comment precedes every command and
+// the lines up to the next comment are a single multi-line command, otherwise every line is a command
+// without a timestamp.
+func ParseBash(reader io.Reader) ([]Entry, error) {
+	var entries []Entry
+	var current *Entry
+
+	flush := func() {
+		if current != nil && strings.TrimSpace(current.Command) != "" {
+			entries = append(entries, *current)
+		}
+		current = nil
+	}
+
+	scanner := newScanner(reader)
+	for scanner.Scan() {
+		line := scanner.Text()
+
+		if match := bashTimestampLine.FindStringSubmatch(line); match != nil {
+			flush()
+			start, _ := strconv.ParseInt(match[1], 10, 64)
+			current = &Entry{Time: time.Unix(start, 0)}
+			continue
+		}
+
+		if current == nil {
+			if strings.TrimSpace(line) != "" {
+				entries = append(entries, Entry{Command: line})
+			}
+			continue
+		}
+
+		if current.Command == "" {
+			current.Command = line
+		} else {
+			current.Command += "\n" + line
+		}
+	}
+	flush()
+
+	return entries, scanner.Err()
+}
+
+// ParseFish reads the YAML-like fish history, `- cmd: <command>` followed by `  when: <start>`
+func ParseFish(reader io.Reader) ([]Entry, error) {
+	var entries []Entry
+	var current *Entry
+
+	flush := func() {
+		if current != nil && strings.TrimSpace(current.Command) != "" {
+			entries = append(entries, *current)
+		}
+		current = nil
+	}
+
+	scanner := newScanner(reader)
+	for scanner.Scan() {
+		line := scanner.Text()
+
+		switch {
+		case strings.HasPrefix(line, "- cmd: "):
+			flush()
+			current = &Entry{Command: unescapeFish(strings.TrimPrefix(line, "- cmd: "))}
+		case current != nil && strings.HasPrefix(line, "  when: "):
+			start, err := strconv.ParseInt(strings.TrimSpace(strings.TrimPrefix(line, "  when: ")), 10, 64)
+			if err == nil {
+				current.Time = time.Unix(start, 0)
+			}
+		}
+	}
+	flush()
+
+	return entries, scanner.Err()
+}
+
+// unescapeFish decodes the escaped backslashes and newlines of a fish history command
+func unescapeFish(command string) string {
+	var builder strings.Builder
+	for i := 0; i < len(command); i++ {
+		if command[i] == '\\' && i+1 < len(command) {
+			switch command[i+1] {
+			case '\\':
+				builder.WriteByte('\\')
+				i++
+				continue
+			case 'n':
+				builder.WriteByte('\n')
+				i++
+				continue
+			}
+		}
+		builder.WriteByte(command[i])
+	}
+
+	return builder.String()
+}
+
+// newScanner reads the history line by line, allowing long lines
+func newScanner(reader io.Reader) *bufio.Scanner {
+	scanner := bufio.NewScanner(reader)
+	scanner.Buffer(make([]byte, 0, 64*1024), maxLineSize)
+
+	return scanner
+}
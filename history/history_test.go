@@ -0,0 +1,87 @@
+package history
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseZsh(t *testing.T) {
+	input := strings.Join([]string{
+		": 1700000000:12;go test ./...",
+		": 1700000100:0;git commit -m 'first\\",
+		"second'",
+		"ls -la",
+		": 1700000200:3;echo \x83\xa8",
+		"",
+	}, "\n")
+
+	entries, err := ParseZsh(strings.NewReader(input))
+	assert.NoError(t, err)
+	assert.Equal(t, []Entry{
+		{Command: "go test ./...", Time: time.Unix(1700000000, 0), Duration: 12 * time.Second, HasDuration: true},
+		{Command: "git commit -m 'first\nsecond'", Time: time.Unix(1700000100, 0), HasDuration: true},
+		{Command: "ls -la"},
+		{Command: "echo \x88", Time: time.Unix(1700000200, 0), Duration: 3 * time.Second, HasDuration: true},
+	}, entries)
+}
+
+func TestParseBash(t *testing.T) {
+	input := strings.Join([]string{
+		"make build",
+		"#1700000000",
+		"go vet ./...",
+		"#1700000050",
+		"for f in *; do",
+		"  echo $f",
+		"done",
+		"#1700000100",
+		"#comment that isn't a timestamp",
+	}, "\n")
+
+	entries, err := ParseBash(strings.NewReader(input))
+	assert.NoError(t, err)
+	assert.Equal(t, []Entry{
+		{Command: "make build"},
+		{Command: "go vet ./...", Time: time.Unix(1700000000, 0)},
+		{Command: "for f in *; do\n  echo $f\ndone", Time: time.Unix(1700000050, 0)},
+		{Command: "#comment that isn't a timestamp", Time: time.Unix(1700000100, 0)},
+	}, entries)
+}
+
+func TestParseFish(t *testing.T) {
+	input := strings.Join([]string{
+		"- cmd: cargo build --release",
+		"  when: 1700000000",
+		"- cmd: echo 'a\\nb' \\\\ c",
+		"  when: 1700000060",
+		"  paths:",
+		"    - Cargo.toml",
+		"- cmd: ls",
+	}, "\n")
+
+	entries, err := ParseFish(strings.NewReader(input))
+	assert.NoError(t, err)
+	assert.Equal(t, []Entry{
+		{Command: "cargo build --release", Time: time.Unix(1700000000, 0)},
+		{Command: "echo 'a\nb' \\ c", Time: time.Unix(1700000060, 0)},
+		{Command: "ls"},
+	}, entries)
+}
+
+func TestDefaultPath(t *testing.T) {
+	t.Setenv("XDG_DATA_HOME", "")
+
+	path, err := DefaultPath(Zsh, "/home/dev")
+	assert.NoError(t, err)
+	assert.Equal(t, "/home/dev/.zsh_history", path)
+
+	path, err = DefaultPath(Fish, "/home/dev")
+	assert.NoError(t, err)
+	assert.Equal(t, "/home/dev/.local/share/fish/fish_history", path)
+
+	_, err = DefaultPath("tcsh", "/home/dev")
+	assert.Error(t, err)
+}
@@ -0,0 +1,240 @@
+package hook
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/devzero-inc/local-developer-analytics/config"
+
+	"github.com/pkg/errors"
+	"github.com/rs/zerolog"
+)
+
+const (
+	// StartEvent hooks run when a command starts
+	StartEvent = "start"
+	// EndEvent hooks run when a command finishes
+	EndEvent = "end"
+)
+
+const (
+	// DefaultTimeout is the time after which a hook is killed when it doesn't configure a timeout
+	DefaultTimeout = 10 * time.Second
+	// DefaultConcurrency is the number of hooks that can run at the same time when it isn't configured
+	DefaultConcurrency = 4
+	// maxQueued is the number of hook runs waiting for a free slot, further runs are dropped
+	maxQueued = 100
+	// maxOutput is the amount of hook output kept for the logs
+	maxOutput = 4096
+	// waitDelay is the time to wait for the output of a killed hook, e.g. when it has children holding the pipes
+	waitDelay = time.Second
+)
+
+// Payload is written as JSON to the standard input of the hooks
+type Payload struct {
+	// Event is start or end
+	Event string `json:"event"`
+	// Command is the collected command
+	Command interface{} `json:"command"`
+}
+
+// hook is a validated hook configuration
+type hook struct {
+	path    string
+	args    []string
+	events  map[string]bool
+	timeout time.Duration
+}
+
+// Runner runs the hooks of the events in the background. Hooks are isolated from the collection:
+// they run with a timeout, a limited number run at the same time, and when too many are waiting they are dropped.
+type Runner struct {
+	hooks  []hook
+	slots  chan struct{}
+	queued atomic.Int32
+	wg     sync.WaitGroup
+	logger zerolog.Logger
+}
+
+// New validates the hooks, `~` at the beginning of a command is expanded to the home directory
+func New(hooks []config.Hook, concurrency int, homeDir string, logger zerolog.Logger) (*Runner, error) {
+	if concurrency <= 0 {
+		concurrency = DefaultConcurrency
+	}
+
+	runner := &Runner{
+		slots:  make(chan struct{}, concurrency),
+		logger: logger,
+	}
+
+	for i, configured := range hooks {
+		compiled, err := compileHook(configured, homeDir)
+		if err != nil {
+			return nil, errors.Wrapf(err, "invalid hook %d", i+1)
+		}
+		runner.hooks = append(runner.hooks, compiled)
+	}
+
+	return runner, nil
+}
+
+func compileHook(configured config.Hook, homeDir string) (hook, error) {
+	compiled := hook{
+		args:    configured.Args,
+		events:  make(map[string]bool),
+		timeout: DefaultTimeout,
+	}
+
+	if configured.Command == "" {
+		return compiled, fmt.Errorf("command is required")
+	}
+
+	command := configured.Command
+	if homeDir != "" && strings.HasPrefix(command, "~/") {
+		command = filepath.Join(homeDir, strings.TrimPrefix(command, "~"))
+	}
+
+	path, err := exec.LookPath(command)
+	if err != nil {
+		return compiled, errors.Wrapf(err, "command %s is not executable", configured.Command)
+	}
+	compiled.path = path
+
+	events := configured.Events
+	if len(events) == 0 {
+		events = []string{EndEvent}
+	}
+	for _, event := range events {
+		if event != StartEvent && event != EndEvent {
+			return compiled, fmt.Errorf("event must be %q or %q, got %q", StartEvent, EndEvent, event)
+		}
+		compiled.events[event] = true
+	}
+
+	if configured.Timeout < 0 {
+		return compiled, fmt.Errorf("timeout must be positive, got %d", configured.Timeout)
+	}
+	if configured.Timeout > 0 {
+		compiled.timeout = time.Duration(configured.Timeout) * time.Second
+	}
+
+	return compiled, nil
+}
+
+// Dispatch runs the hooks of the event in the background, it never blocks
+func (r *Runner) Dispatch(event string, command interface{}) {
+	if r == nil || len(r.hooks) == 0 {
+		return
+	}
+
+	var input []byte
+	for _, h := range r.hooks {
+		if !h.events[event] {
+			continue
+		}
+
+		if input == nil {
+			var err error
+			if input, err = json.Marshal(Payload{Event: event, Command: command}); err != nil {
+				r.logger.Error().Err(err).Msg("Failed to encode hook payload")
+				return
+			}
+		}
+
+		if r.queued.Add(1) > maxQueued {
+			r.queued.Add(-1)
+			r.logger.Warn().Msgf("Too many hooks waiting, dropping hook %s for %s event", h.path, event)
+			continue
+		}
+
+		r.wg.Add(1)
+		go func(h hook) {
+			defer r.wg.Done()
+
+			r.slots <- struct{}{}
+			r.queued.Add(-1)
+			defer func() { <-r.slots }()
+
+			r.run(h, event, input)
+		}(h)
+	}
+}
+
+// Wait waits for the dispatched hooks to finish
+func (r *Runner) Wait() {
+	if r == nil {
+		return
+	}
+
+	r.wg.Wait()
+}
+
+// run executes the hook with its timeout, failures are only logged
+func (r *Runner) run(h hook, event string, input []byte) {
+	defer func() {
+		if recovered := recover(); recovered != nil {
+			r.logger.Error().Msgf("Hook %s panicked: %v", h.path, recovered)
+		}
+	}()
+
+	ctx, cancel := context.WithTimeout(context.Background(), h.timeout)
+	defer cancel()
+
+	var output limitedBuffer
+	cmd := exec.CommandContext(ctx, h.path, h.args...)
+	cmd.Stdin = bytes.NewReader(input)
+	cmd.Stdout = &output
+	cmd.Stderr = &output
+	killProcessGroup(cmd)
+	cmd.WaitDelay = waitDelay
+
+	start := time.Now()
+	err := cmd.Run()
+
+	if ctx.Err() == context.DeadlineExceeded {
+		r.logger.Error().Msgf("Hook %s for %s event timed out after %s, output: %s", h.path, event, h.timeout, output.String())
+		return
+	}
+	if err != nil {
+		r.logger.Error().Err(err).Msgf("Hook %s for %s event failed, output: %s", h.path, event, output.String())
+		return
+	}
+
+	r.logger.Debug().Msgf("Hook %s for %s event finished in %s", h.path, event, time.Since(start))
+}
+
+// limitedBuffer keeps the beginning of the output, so a chatty hook can't grow the memory of the collector
+type limitedBuffer struct {
+	mutex  sync.Mutex
+	buffer bytes.Buffer
+}
+
+func (b *limitedBuffer) Write(p []byte) (int, error) {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	if remaining := maxOutput - b.buffer.Len(); remaining > 0 {
+		if len(p) > remaining {
+			b.buffer.Write(p[:remaining])
+		} else {
+			b.buffer.Write(p)
+		}
+	}
+
+	return len(p), nil
+}
+
+func (b *limitedBuffer) String() string {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	return strings.TrimSpace(b.buffer.String())
+}
@@ -0,0 +1,130 @@
+package hook
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/devzero-inc/local-developer-analytics/config"
+
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/assert"
+)
+
+// writeScript creates an executable shell script in the directory
+func writeScript(t *testing.T, dir string, name string, body string) string {
+	t.Helper()
+
+	path := filepath.Join(dir, name)
+	assert.NoError(t, os.WriteFile(path, []byte("#!/bin/sh\n"+body), 0755))
+
+	return path
+}
+
+func TestHookReceivesPayload(t *testing.T) {
+	dir := t.TempDir()
+	output := filepath.Join(dir, "payload.json")
+	script := writeScript(t, dir, "hook.sh", `cat > "$1"`)
+
+	runner, err := New([]config.Hook{{Command: script, Args: []string{output}}}, 1, "", zerolog.Nop())
+	assert.NoError(t, err)
+
+	runner.Dispatch(StartEvent, map[string]string{"command": "make"})
+	runner.Wait()
+	assert.NoFileExists(t, output, "hooks only run on end events by default")
+
+	runner.Dispatch(EndEvent, map[string]string{"command": "make"})
+	runner.Wait()
+
+	content, err := os.ReadFile(output)
+	if assert.NoError(t, err) {
+		var payload struct {
+			Event   string            `json:"event"`
+			Command map[string]string `json:"command"`
+		}
+		assert.NoError(t, json.Unmarshal(content, &payload))
+		assert.Equal(t, EndEvent, payload.Event)
+		assert.Equal(t, "make", payload.Command["command"])
+	}
+}
+
+func TestHookIsKilledAfterTimeout(t *testing.T) {
+	dir := t.TempDir()
+	script := writeScript(t, dir, "slow.sh", "sleep 30 & sleep 30\n")
+
+	runner, err := New([]config.Hook{{Command: script, Timeout: 1}}, 1, "", zerolog.Nop())
+	assert.NoError(t, err)
+
+	start := time.Now()
+	runner.Dispatch(EndEvent, nil)
+	runner.Wait()
+
+	assert.Less(t, time.Since(start), 5*time.Second)
+}
+
+func TestDispatchDoesNotBlock(t *testing.T) {
+	dir := t.TempDir()
+	script := writeScript(t, dir, "hook.sh", "exit 0\n")
+
+	runner, err := New([]config.Hook{{Command: script}}, 1, "", zerolog.Nop())
+	assert.NoError(t, err)
+
+	// Occupy the only slot, so every dispatched hook has to wait
+	runner.slots <- struct{}{}
+
+	start := time.Now()
+	for i := 0; i < maxQueued+10; i++ {
+		runner.Dispatch(EndEvent, nil)
+	}
+	assert.Less(t, time.Since(start), time.Second)
+	assert.Equal(t, int32(maxQueued), runner.queued.Load())
+
+	<-runner.slots
+	runner.Wait()
+	assert.Zero(t, runner.queued.Load())
+}
+
+func TestFailingHookIsIsolated(t *testing.T) {
+	dir := t.TempDir()
+	script := writeScript(t, dir, "fail.sh", "echo broken >&2; exit 3\n")
+
+	runner, err := New([]config.Hook{{Command: script, Events: []string{StartEvent, EndEvent}}}, 2, "", zerolog.Nop())
+	assert.NoError(t, err)
+
+	runner.Dispatch(StartEvent, nil)
+	runner.Dispatch(EndEvent, nil)
+	runner.Wait()
+}
+
+func TestNewRejectsInvalidHooks(t *testing.T) {
+	dir := t.TempDir()
+	script := writeScript(t, dir, "hook.sh", "exit 0\n")
+	notExecutable := filepath.Join(dir, "plain.sh")
+	assert.NoError(t, os.WriteFile(notExecutable, []byte("exit 0\n"), 0644))
+
+	invalid := []config.Hook{
+		{},
+		{Command: filepath.Join(dir, "missing.sh")},
+		{Command: notExecutable},
+		{Command: script, Events: []string{"finish"}},
+		{Command: script, Timeout: -1},
+	}
+
+	for _, h := range invalid {
+		_, err := New([]config.Hook{h}, 1, "", zerolog.Nop())
+		assert.Error(t, err, "%+v", h)
+	}
+
+	runner, err := New([]config.Hook{{Command: "~/hook.sh"}}, 1, dir, zerolog.Nop())
+	if assert.NoError(t, err) {
+		assert.Equal(t, script, runner.hooks[0].path)
+	}
+}
+
+func TestNilRunner(t *testing.T) {
+	var runner *Runner
+	runner.Dispatch(EndEvent, nil)
+	runner.Wait()
+}
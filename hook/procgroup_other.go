@@ -0,0 +1,8 @@
+//go:build !unix
+
+package hook
+
+import "os/exec"
+
+// killProcessGroup keeps the default cancellation, only the hook itself is killed on timeout
+func killProcessGroup(_ *exec.Cmd) {}
@@ -0,0 +1,214 @@
+package notify
+
+import (
+	"fmt"
+	"io"
+	"math"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/devzero-inc/local-developer-analytics/config"
+
+	"github.com/rs/zerolog"
+)
+
+// Notification is sent when a long-running command finishes
+type Notification struct {
+	Command   string `json:"command"`
+	Category  string `json:"category"`
+	Directory string `json:"directory"`
+	Result    string `json:"result"`
+	Status    string `json:"status"`
+	// Duration is the execution time of the command in milliseconds
+	Duration int64 `json:"duration"`
+	// Reason is the threshold that was exceeded
+	Reason string `json:"reason"`
+	// EndTime is the time in milliseconds the command finished
+	EndTime int64 `json:"end_time"`
+	// Terminal is the terminal of the shell waiting on the end of the command, nil when the shell has no terminal
+	Terminal io.Writer `json:"-"`
+}
+
+// Message is the human readable text of the notification
+func (n Notification) Message() string {
+	duration := (time.Duration(n.Duration) * time.Millisecond).Round(time.Second)
+	result := n.Result
+	if result == "" {
+		result = "finished"
+	}
+
+	return fmt.Sprintf("%s: %s after %s", n.Command, result, duration)
+}
+
+// Notifier delivers notifications to a sink
+type Notifier interface {
+	Notify(notification Notification) error
+}
+
+// Thresholds decide which commands are long-running
+type Thresholds struct {
+	// Global is the duration after which every command is notified, zero disables it
+	Global time.Duration
+	// Categories override the global threshold for the category
+	Categories map[string]time.Duration
+	// Percentile notifies commands slower than the percentile of their previous runs, zero disables it
+	Percentile float64
+	// MinSamples is the number of previous runs needed before the percentile is used
+	MinSamples int
+	// MinDuration is the duration below which commands are never notified by the percentile
+	MinDuration time.Duration
+}
+
+// Manager decides which finished commands are notified and routes the notifications to the notifiers
+type Manager struct {
+	thresholds Thresholds
+	// inline notifiers run before the shell hook returns, they have to be fast
+	inline []Notifier
+	// background notifiers run in their own goroutines
+	background []Notifier
+	logger     zerolog.Logger
+}
+
+// NewManager creates a manager with the given thresholds and notifiers
+func NewManager(thresholds Thresholds, inline []Notifier, background []Notifier, logger zerolog.Logger) *Manager {
+	return &Manager{
+		thresholds: thresholds,
+		inline:     inline,
+		background: background,
+		logger:     logger,
+	}
+}
+
+// FromConfig creates a manager with the built-in notifiers enabled in the configuration,
+// nil is returned when no notifier is enabled
+func FromConfig(conf config.Notifications, homeDir string, logger zerolog.Logger) (*Manager, error) {
+	if conf.LearnedPercentile < 0 || conf.LearnedPercentile >= 100 {
+		return nil, fmt.Errorf("learned percentile must be between 0 and 100, got %g", conf.LearnedPercentile)
+	}
+
+	var inline, background []Notifier
+
+	if conf.Terminal {
+		inline = append(inline, TerminalNotifier{})
+	}
+
+	if conf.LogFile != "" {
+		logNotifier, err := NewLogNotifier(expandHome(conf.LogFile, homeDir))
+		if err != nil {
+			return nil, err
+		}
+		background = append(background, logNotifier)
+	}
+
+	if conf.WebhookURL != "" {
+		webhookNotifier, err := NewWebhookNotifier(conf.WebhookURL, time.Duration(conf.WebhookTimeout)*time.Second)
+		if err != nil {
+			return nil, err
+		}
+		background = append(background, webhookNotifier)
+	}
+
+	if len(inline) == 0 && len(background) == 0 {
+		return nil, nil
+	}
+
+	thresholds := Thresholds{
+		Global:      time.Duration(conf.Threshold) * time.Second,
+		Categories:  make(map[string]time.Duration),
+		Percentile:  conf.LearnedPercentile,
+		MinSamples:  conf.LearnedMinSamples,
+		MinDuration: time.Duration(conf.LearnedMinDuration) * time.Second,
+	}
+	for category, threshold := range conf.CategoryThresholds {
+		thresholds.Categories[category] = time.Duration(threshold) * time.Second
+	}
+
+	return NewManager(thresholds, inline, background, logger), nil
+}
+
+// Exceeded checks if the duration exceeds a threshold of the category and returns the reason, empty when it doesn't.
+// The history is only loaded when the percentile threshold is enabled, it returns previous durations in milliseconds.
+func (m *Manager) Exceeded(category string, duration time.Duration, history func() ([]int64, error)) string {
+	if m == nil {
+		return ""
+	}
+
+	threshold, ok := m.thresholds.Categories[category]
+	if !ok {
+		threshold = m.thresholds.Global
+	}
+	if threshold > 0 && duration >= threshold {
+		return fmt.Sprintf("took longer than %s", threshold)
+	}
+
+	if m.thresholds.Percentile <= 0 || duration < m.thresholds.MinDuration || history == nil {
+		return ""
+	}
+
+	durations, err := history()
+	if err != nil {
+		m.logger.Error().Err(err).Msg("Failed to load the command history for notifications")
+		return ""
+	}
+	if len(durations) == 0 || len(durations) < m.thresholds.MinSamples {
+		return ""
+	}
+
+	learned := time.Duration(percentile(durations, m.thresholds.Percentile)) * time.Millisecond
+	if duration > learned {
+		return fmt.Sprintf("slower than p%g (%s) of previous runs", m.thresholds.Percentile, learned.Round(time.Second))
+	}
+
+	return ""
+}
+
+// WritesToTerminal tells if an inline notifier writes to the terminal of the shell, only then the shell hook
+// waits for the end of its command to be processed
+func (m *Manager) WritesToTerminal() bool {
+	return m != nil && len(m.inline) > 0
+}
+
+// Send delivers the notification, inline notifiers are run before it returns
+func (m *Manager) Send(notification Notification) {
+	if m == nil {
+		return
+	}
+
+	for _, notifier := range m.inline {
+		if err := notifier.Notify(notification); err != nil {
+			m.logger.Error().Err(err).Msg("Failed to send notification")
+		}
+	}
+
+	// Background notifiers outlive the connection of the shell hook
+	notification.Terminal = nil
+	for _, notifier := range m.background {
+		go func(notifier Notifier) {
+			if err := notifier.Notify(notification); err != nil {
+				m.logger.Error().Err(err).Msg("Failed to send notification")
+			}
+		}(notifier)
+	}
+}
+
+// percentile calculates the nearest-rank percentile of the values
+func percentile(values []int64, p float64) int64 {
+	sorted := append([]int64{}, values...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+
+	rank := int(math.Ceil(p / 100 * float64(len(sorted))))
+	if rank < 1 {
+		rank = 1
+	}
+
+	return sorted[rank-1]
+}
+
+// expandHome expands `~` at the beginning of the path to the home directory
+func expandHome(path string, homeDir string) string {
+	if homeDir != "" && strings.HasPrefix(path, "~/") {
+		return homeDir + strings.TrimPrefix(path, "~")
+	}
+	return path
+}
@@ -0,0 +1,150 @@
+package notify
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/devzero-inc/local-developer-analytics/config"
+
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestExceededThresholds(t *testing.T) {
+	manager := NewManager(Thresholds{
+		Global:     5 * time.Minute,
+		Categories: map[string]time.Duration{"test": time.Minute, "vcs": 0},
+	}, nil, nil, zerolog.Nop())
+
+	assert.NotEmpty(t, manager.Exceeded("build", 6*time.Minute, nil))
+	assert.Empty(t, manager.Exceeded("build", 2*time.Minute, nil))
+	assert.NotEmpty(t, manager.Exceeded("test", 2*time.Minute, nil))
+	// A zero category threshold disables the notifications of the category
+	assert.Empty(t, manager.Exceeded("vcs", time.Hour, nil))
+}
+
+func TestExceededLearnedPercentile(t *testing.T) {
+	manager := NewManager(Thresholds{
+		Percentile:  90,
+		MinSamples:  10,
+		MinDuration: 30 * time.Second,
+	}, nil, nil, zerolog.Nop())
+
+	var history []int64
+	for i := 1; i <= 10; i++ {
+		history = append(history, int64(i)*time.Minute.Milliseconds())
+	}
+	load := func() ([]int64, error) { return history, nil }
+
+	assert.NotEmpty(t, manager.Exceeded("build", 10*time.Minute, load))
+	assert.Empty(t, manager.Exceeded("build", 9*time.Minute, load))
+
+	// Not enough history
+	assert.Empty(t, manager.Exceeded("build", time.Hour, func() ([]int64, error) { return history[:5], nil }))
+	// Too short to notify, even if slower than usual
+	assert.Empty(t, manager.Exceeded("build", 10*time.Second, func() ([]int64, error) { return make([]int64, 10), nil }))
+	// Failing history is not notified
+	assert.Empty(t, manager.Exceeded("build", time.Hour, func() ([]int64, error) { return nil, errors.New("database closed") }))
+}
+
+func TestPercentile(t *testing.T) {
+	assert.Equal(t, int64(9), percentile([]int64{10, 1, 9, 2, 8, 3, 7, 4, 6, 5}, 90))
+	assert.Equal(t, int64(5), percentile([]int64{5}, 90))
+	assert.Equal(t, int64(1), percentile([]int64{3, 1, 2}, 0.1))
+}
+
+func TestTerminalNotifier(t *testing.T) {
+	var terminal bytes.Buffer
+	manager := NewManager(Thresholds{}, []Notifier{TerminalNotifier{}}, nil, zerolog.Nop())
+
+	manager.Send(Notification{Command: "make\x07 build", Result: "success", Duration: 90000, Terminal: &terminal})
+	assert.Equal(t, "\a\x1b]9;make build: success after 1m30s\x07", terminal.String())
+
+	// Without a terminal nothing is written
+	assert.NoError(t, TerminalNotifier{}.Notify(Notification{Command: "make"}))
+}
+
+func TestWritesToTerminal(t *testing.T) {
+	var disabled *Manager
+	assert.False(t, disabled.WritesToTerminal())
+	assert.False(t, NewManager(Thresholds{}, nil, []Notifier{TerminalNotifier{}}, zerolog.Nop()).WritesToTerminal())
+	assert.True(t, NewManager(Thresholds{}, []Notifier{TerminalNotifier{}}, nil, zerolog.Nop()).WritesToTerminal())
+}
+
+func TestLogNotifier(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "logs", "notifications.log")
+	notifier, err := NewLogNotifier(path)
+	assert.NoError(t, err)
+
+	assert.NoError(t, notifier.Notify(Notification{Command: "make", Duration: 1000}))
+	assert.NoError(t, notifier.Notify(Notification{Command: "go test", Duration: 2000}))
+
+	content, err := os.ReadFile(path)
+	assert.NoError(t, err)
+
+	lines := strings.Split(strings.TrimSpace(string(content)), "\n")
+	if assert.Len(t, lines, 2) {
+		var notification Notification
+		assert.NoError(t, json.Unmarshal([]byte(lines[1]), &notification))
+		assert.Equal(t, "go test", notification.Command)
+	}
+}
+
+func TestWebhookNotifier(t *testing.T) {
+	received := make(chan Notification, 1)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var notification Notification
+		assert.NoError(t, json.NewDecoder(r.Body).Decode(&notification))
+		received <- notification
+		if notification.Command == "fail" {
+			w.WriteHeader(http.StatusInternalServerError)
+		}
+	}))
+	defer server.Close()
+
+	notifier, err := NewWebhookNotifier(server.URL, time.Second)
+	assert.NoError(t, err)
+
+	assert.NoError(t, notifier.Notify(Notification{Command: "make"}))
+	assert.Equal(t, "make", (<-received).Command)
+
+	assert.Error(t, notifier.Notify(Notification{Command: "fail"}))
+	<-received
+
+	_, err = NewWebhookNotifier("ftp://example.com", 0)
+	assert.Error(t, err)
+}
+
+func TestFromConfig(t *testing.T) {
+	manager, err := FromConfig(config.Notifications{Threshold: 300}, "", zerolog.Nop())
+	assert.NoError(t, err)
+	assert.Nil(t, manager, "no sink is enabled")
+
+	home := t.TempDir()
+	manager, err = FromConfig(config.Notifications{
+		Threshold:          300,
+		CategoryThresholds: map[string]int{"test": 60},
+		Terminal:           true,
+		LogFile:            "~/notifications.log",
+	}, home, zerolog.Nop())
+	if assert.NoError(t, err) && assert.NotNil(t, manager) {
+		assert.Len(t, manager.inline, 1)
+		assert.Len(t, manager.background, 1)
+		assert.Equal(t, time.Minute, manager.thresholds.Categories["test"])
+		assert.Equal(t, filepath.Join(home, "notifications.log"), manager.background[0].(*LogNotifier).path)
+	}
+
+	_, err = FromConfig(config.Notifications{Terminal: true, LearnedPercentile: 100}, "", zerolog.Nop())
+	assert.Error(t, err)
+
+	_, err = FromConfig(config.Notifications{WebhookURL: "not a url"}, "", zerolog.Nop())
+	assert.Error(t, err)
+}
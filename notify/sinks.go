@@ -0,0 +1,118 @@
+package notify
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+// defaultWebhookTimeout is the timeout of the HTTP POST when it isn't configured
+const defaultWebhookTimeout = 5 * time.Second
+
+// TerminalNotifier rings the bell and sends an OSC 9 desktop notification through the terminal of the shell.
+// Terminals that don't support OSC 9 ignore it.
+type TerminalNotifier struct{}
+
+// Notify writes the escape sequences to the terminal, notifications without a terminal are skipped
+func (TerminalNotifier) Notify(notification Notification) error {
+	if notification.Terminal == nil {
+		return nil
+	}
+
+	_, err := fmt.Fprintf(notification.Terminal, "\a\x1b]9;%s\x07", sanitize(notification.Message()))
+
+	return err
+}
+
+// sanitize removes the control characters, so the message can't end the escape sequence early
+func sanitize(message string) string {
+	return strings.Map(func(r rune) rune {
+		if r < 0x20 || r == 0x7f {
+			return -1
+		}
+		return r
+	}, message)
+}
+
+// LogNotifier appends the notifications as JSON lines to a file
+type LogNotifier struct {
+	path  string
+	mutex sync.Mutex
+}
+
+// NewLogNotifier creates a notifier writing to the file, the directory is created if needed
+func NewLogNotifier(path string) (*LogNotifier, error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return nil, fmt.Errorf("failed to create notification log directory: %w", err)
+	}
+
+	return &LogNotifier{path: path}, nil
+}
+
+// Notify appends the notification to the file
+func (l *LogNotifier) Notify(notification Notification) error {
+	line, err := json.Marshal(notification)
+	if err != nil {
+		return err
+	}
+
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	file, err := os.OpenFile(l.path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0600)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	_, err = file.Write(append(line, '\n'))
+
+	return err
+}
+
+// WebhookNotifier sends the notifications as JSON with an HTTP POST
+type WebhookNotifier struct {
+	url    string
+	client *http.Client
+}
+
+// NewWebhookNotifier creates a notifier posting to the URL, a zero timeout uses the default
+func NewWebhookNotifier(webhookURL string, timeout time.Duration) (*WebhookNotifier, error) {
+	parsed, err := url.Parse(webhookURL)
+	if err != nil || (parsed.Scheme != "http" && parsed.Scheme != "https") || parsed.Host == "" {
+		return nil, fmt.Errorf("invalid webhook URL %q", webhookURL)
+	}
+
+	if timeout <= 0 {
+		timeout = defaultWebhookTimeout
+	}
+
+	return &WebhookNotifier{url: webhookURL, client: &http.Client{Timeout: timeout}}, nil
+}
+
+// Notify posts the notification, responses other than 2xx are errors
+func (w *WebhookNotifier) Notify(notification Notification) error {
+	body, err := json.Marshal(notification)
+	if err != nil {
+		return err
+	}
+
+	response, err := w.client.Post(w.url, "application/json", bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	defer response.Body.Close()
+
+	if response.StatusCode < 200 || response.StatusCode >= 300 {
+		return fmt.Errorf("webhook responded with %s", response.Status)
+	}
+
+	return nil
+}
@@ -60,6 +60,31 @@ type Process struct {
 	PlatformFamily string  `json:"platform_family" db:"platform_family"`
 	CPUUsage       float64 `json:"cpu_usage" db:"cpu_usage"`
 	MemoryUsage    float64 `json:"memory_usage" db:"memory_usage"`
+	// CPUTime is the cumulative user and system CPU time in seconds
+	CPUTime float64 `json:"cpu_time" db:"cpu_time"`
+	// RSS is the resident memory in bytes
+	RSS int64 `json:"rss" db:"rss"`
+	// ReadBytes and WriteBytes are the cumulative I/O counters, not every collector is able to report them
+	ReadBytes  int64 `json:"read_bytes" db:"read_bytes"`
+	WriteBytes int64 `json:"write_bytes" db:"write_bytes"`
+}
+
+// ResourceUsage is the resource usage aggregated over all processes spawned by a command
+type ResourceUsage struct {
+	CPUTime    float64 `json:"cpu_time" db:"cpu_time"`
+	PeakRSS    int64   `json:"peak_rss" db:"peak_rss"`
+	ReadBytes  int64   `json:"read_bytes" db:"read_bytes"`
+	WriteBytes int64   `json:"write_bytes" db:"write_bytes"`
+	// Samples is the number of process samples linked to the command, without any the usage is unknown
+	Samples int64 `json:"samples" db:"samples"`
+}
+
+// CommandProcess links a sampled process to the command that spawned it
+type CommandProcess struct {
+	Id          int64  `json:"id" db:"id"`
+	CommandUUID string `json:"command_uuid" db:"command_uuid"`
+	PID         int64  `json:"pid" db:"pid"`
+	StoredTime  int64  `json:"stored_time" db:"stored_time"`
 }
 
 // GetAllProcessesForPeriod fetches all processes for a given period
@@ -121,10 +146,137 @@ ORDER BY p.stored_time DESC;`
 	return processMetricsMap, nil
 }
 
+// GetAllProcessesForCommand fetches all processes that were spawned by the command
+func GetAllProcessesForCommand(commandUUID string) ([]*Process, error) {
+	var processes []*Process
+
+	query := `SELECT p.pid, p.name, MAX(p.cpu_usage) as cpu_usage, MAX(p.memory_usage) as memory_usage
+FROM command_processes cp
+JOIN processes p ON cp.pid = p.pid AND cp.stored_time = p.stored_time
+WHERE cp.command_uuid = ?
+GROUP BY p.pid, p.name
+ORDER BY cpu_usage DESC, memory_usage DESC
+LIMIT 100;`
+
+	err := database.DB.Select(&processes, query, commandUUID)
+	if err != nil {
+		return nil, err
+	}
+
+	return processes, nil
+}
+
+// GetTopProcessesAndMetricsForCommand fetches the time-series data of the top processes spawned by the command
+func GetTopProcessesAndMetricsForCommand(commandUUID string) (map[int64][]*Process, error) {
+	query := `SELECT p.name, p.pid, p.cpu_usage, p.memory_usage, p.stored_time
+FROM (
+    SELECT p.pid, p.name, MAX(p.cpu_usage) as cpu_usage, MAX(p.memory_usage) as memory_usage
+    FROM command_processes cp
+    JOIN processes p ON cp.pid = p.pid AND cp.stored_time = p.stored_time
+    WHERE cp.command_uuid = ?
+    GROUP BY p.pid, p.name
+    ORDER BY cpu_usage DESC, memory_usage DESC
+    LIMIT 20
+) AS top_processes
+JOIN command_processes cp ON cp.command_uuid = ? AND cp.pid = top_processes.pid
+JOIN processes p ON cp.pid = p.pid AND cp.stored_time = p.stored_time AND p.name = top_processes.name
+ORDER BY p.stored_time DESC;`
+
+	var allMetrics []*Process
+	err := database.DB.Select(&allMetrics, query, commandUUID, commandUUID)
+	if err != nil {
+		return nil, fmt.Errorf("error fetching command process metrics: %v", err)
+	}
+
+	processMetricsMap := make(map[int64][]*Process)
+	for _, metric := range allMetrics {
+		processMetricsMap[metric.PID] = append(processMetricsMap[metric.PID], metric)
+	}
+
+	return processMetricsMap, nil
+}
+
+// GetResourceUsageForCommand aggregates the resource usage of all processes spawned by the command.
+// CPU time and I/O counters are cumulative, and the linked processes were created after the command started,
+// so the last sample of every process is summed, while the peak RSS is the highest combined RSS of the
+// process tree in a single sample.
+func GetResourceUsageForCommand(commandUUID string) (*ResourceUsage, error) {
+	query := `WITH samples AS (
+    SELECT p.pid, p.created_time, p.stored_time, p.cpu_time, p.rss, p.read_bytes, p.write_bytes
+    FROM command_processes cp
+    JOIN processes p ON cp.pid = p.pid AND cp.stored_time = p.stored_time
+    WHERE cp.command_uuid = ?
+), per_process AS (
+    SELECT MAX(cpu_time) AS cpu_time, MAX(read_bytes) AS read_bytes, MAX(write_bytes) AS write_bytes
+    FROM samples
+    GROUP BY pid, created_time
+), per_sample AS (
+    SELECT SUM(rss) AS rss
+    FROM samples
+    GROUP BY stored_time
+)
+SELECT
+    COALESCE((SELECT SUM(cpu_time) FROM per_process), 0) AS cpu_time,
+    COALESCE((SELECT MAX(rss) FROM per_sample), 0) AS peak_rss,
+    COALESCE((SELECT SUM(read_bytes) FROM per_process), 0) AS read_bytes,
+    COALESCE((SELECT SUM(write_bytes) FROM per_process), 0) AS write_bytes,
+    (SELECT COUNT(*) FROM samples) AS samples;`
+
+	var usage ResourceUsage
+	if err := database.DB.Get(&usage, query, commandUUID); err != nil {
+		return nil, fmt.Errorf("error fetching command resource usage: %v", err)
+	}
+
+	return &usage, nil
+}
+
+// GetLastSampleTimeForCommand returns the time in milliseconds of the last sample in which a process spawned by
+// the command was alive, zero when no process was linked to the command
+func GetLastSampleTimeForCommand(commandUUID string) (int64, error) {
+	var lastSample int64
+	if err := database.DB.Get(&lastSample, "SELECT COALESCE(MAX(stored_time), 0) FROM command_processes WHERE command_uuid = ?", commandUUID); err != nil {
+		return 0, fmt.Errorf("error fetching the last sample of the command: %v", err)
+	}
+
+	return lastSample, nil
+}
+
+// InsertCommandProcesses inserts the links between commands and their processes in bulk
+func InsertCommandProcesses(links []CommandProcess) error {
+	query := `INSERT INTO command_processes (command_uuid, pid, stored_time)
+	VALUES (:command_uuid, :pid, :stored_time)`
+
+	tx, err := database.DB.Beginx()
+	if err != nil {
+		return err
+	}
+
+	stmt, err := tx.PrepareNamed(query)
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+	defer stmt.Close()
+
+	for _, link := range links {
+		if _, err := stmt.Exec(link); err != nil {
+			tx.Rollback()
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
+
 // DeleteProcessesByDays deletes records older than n days
 func DeleteProcessesByDays(days int) error {
 	// Calculate the time when old records will be deleted
-	timeToDelete := time.Now().AddDate(0, 0, -days).Unix()
+	// Processes and their links store the time of the sample in milliseconds
+	timeToDelete := time.Now().AddDate(0, 0, -days).UnixMilli()
+
+	if _, err := database.DB.Exec("DELETE FROM command_processes WHERE stored_time < ?", timeToDelete); err != nil {
+		return err
+	}
 
 	result, err := database.DB.Exec("DELETE FROM processes WHERE stored_time < ?", timeToDelete)
 	if err != nil {
@@ -138,8 +290,10 @@ func DeleteProcessesByDays(days int) error {
 
 // InsertProcesses inserts multiple processes into the database in bulk
 func InsertProcesses(processes []Process) error {
-	query := `INSERT INTO processes (pid, name, status, created_time, stored_time, os, platform, platform_family, cpu_usage, memory_usage, ppid)
-	VALUES (:pid, :name, :status, :created_time, :stored_time, :os, :platform, :platform_family, :cpu_usage, :memory_usage, :ppid)`
+	query := `INSERT INTO processes (pid, name, status, created_time, stored_time, os, platform, platform_family, cpu_usage, memory_usage, ppid,
+		cpu_time, rss, read_bytes, write_bytes)
+	VALUES (:pid, :name, :status, :created_time, :stored_time, :os, :platform, :platform_family, :cpu_usage, :memory_usage, :ppid,
+		:cpu_time, :rss, :read_bytes, :write_bytes)`
 
 	// Begin a transaction
 	tx, err := database.DB.Beginx()
@@ -182,5 +336,9 @@ func MapProcessToProto(process Process) *gen.Process {
 		PlatformFamily: process.PlatformFamily,
 		CpuUsage:       process.CPUUsage,
 		MemoryUsage:    process.MemoryUsage,
+		CpuTime:        process.CPUTime,
+		Rss:            process.RSS,
+		ReadBytes:      process.ReadBytes,
+		WriteBytes:     process.WriteBytes,
 	}
 }
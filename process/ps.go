@@ -30,7 +30,7 @@ func (p *Ps) Collect() ([]Process, error) {
 	p.logger.Debug().Msg("Collecting process")
 
 	// Adjust the command to include PPID
-	cmd := exec.Command("ps", "axo", "pid,ppid,pcpu,pmem,lstart,comm")
+	cmd := exec.Command("ps", "axo", "pid,ppid,pcpu,pmem,rss,time,lstart,comm")
 
 	var out bytes.Buffer
 	cmd.Stdout = &out
@@ -43,6 +43,8 @@ func (p *Ps) Collect() ([]Process, error) {
 	scanner.Scan() // Skip the header line
 
 	var processInfo []Process
+	// All processes of a single sample share the stored time
+	storedTime := time.Now().UnixMilli()
 
 	for scanner.Scan() {
 		line := scanner.Text()
@@ -53,9 +55,12 @@ func (p *Ps) Collect() ([]Process, error) {
 		ppid, _ := strconv.ParseInt(fields[1], 10, 64)
 		cpuUsage, _ := strconv.ParseFloat(fields[2], 64)
 		memUsage, _ := strconv.ParseFloat(fields[3], 64)
+		// RSS is reported in kilobytes
+		rss, _ := strconv.ParseInt(fields[4], 10, 64)
+		cpuTime := parseCPUTime(fields[5])
 
 		// Parse the start time
-		lstart := strings.Join(fields[4:9], " ")
+		lstart := strings.Join(fields[6:11], " ")
 		const lstartLayout = "Mon Jan 2 15:04:05 2006"
 		startTime, err := time.Parse(lstartLayout, lstart)
 		if err != nil {
@@ -64,7 +69,7 @@ func (p *Ps) Collect() ([]Process, error) {
 		}
 
 		// Command name might contain spaces, so we join remaining fields
-		name := strings.Join(fields[9:], " ")
+		name := strings.Join(fields[11:], " ")
 
 		// Create the Process instance
 		process := Process{
@@ -73,8 +78,10 @@ func (p *Ps) Collect() ([]Process, error) {
 			Name:        path.Base(name),
 			CPUUsage:    cpuUsage,
 			MemoryUsage: memUsage,
+			CPUTime:     cpuTime,
+			RSS:         rss * 1024,
 			CreatedTime: startTime.UnixMilli(),
-			StoredTime:  time.Now().UnixMilli(),
+			StoredTime:  storedTime,
 			OS:          runtime.GOOS,
 			Platform:    runtime.GOOS,
 		}
@@ -89,3 +96,29 @@ func (p *Ps) Collect() ([]Process, error) {
 
 	return processInfo, nil
 }
+
+// parseCPUTime parses the cumulative CPU time reported by ps into seconds.
+// Linux reports `[DD-]HH:MM:SS`, while BSD and macOS report `MM:SS.ss`.
+func parseCPUTime(value string) float64 {
+	var seconds float64
+
+	if days, rest, found := strings.Cut(value, "-"); found {
+		d, err := strconv.ParseFloat(days, 64)
+		if err != nil {
+			return 0
+		}
+		seconds += d * 24 * 60 * 60
+		value = rest
+	}
+
+	var clock float64
+	for _, part := range strings.Split(value, ":") {
+		v, err := strconv.ParseFloat(part, 64)
+		if err != nil {
+			return 0
+		}
+		clock = clock*60 + v
+	}
+
+	return seconds + clock
+}
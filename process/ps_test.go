@@ -18,3 +18,22 @@ func TestPsCollectWithRealOutput(t *testing.T) {
 	assert.NoError(t, err, "Collect method should not return an error")
 	assert.NotEmpty(t, processes, "Collect method should return list of processes")
 }
+
+func TestParseCPUTime(t *testing.T) {
+	tests := []struct {
+		value string
+		want  float64
+	}{
+		{"00:00:00", 0},
+		{"00:01:05", 65},
+		{"01:00:00", 3600},
+		{"2-00:00:01", 2*24*3600 + 1},
+		{"0:00.50", 0.5},
+		{"12:34.25", 12*60 + 34.25},
+		{"invalid", 0},
+	}
+
+	for _, tt := range tests {
+		assert.InDelta(t, tt.want, parseCPUTime(tt.value), 0.001, tt.value)
+	}
+}
@@ -34,6 +34,8 @@ func (p *Psutil) Collect() ([]Process, error) {
 	}
 
 	var processInfo []Process
+	// All processes of a single sample share the stored time
+	storedTime := time.Now().UnixMilli()
 	for _, proc := range processes {
 		createTime, err := proc.CreateTime()
 		if err != nil {
@@ -70,18 +72,39 @@ func (p *Psutil) Collect() ([]Process, error) {
 			p.logger.Err(err).Msg("Error retrieving parent PID")
 		}
 
+		var cpuTime float64
+		if times, err := proc.Times(); err == nil {
+			cpuTime = times.User + times.System
+		}
+
+		var rss int64
+		if memoryInfo, err := proc.MemoryInfo(); err == nil {
+			rss = int64(memoryInfo.RSS)
+		}
+
+		// I/O counters are not available on every platform nor for processes of other users
+		var readBytes, writeBytes int64
+		if ioCounters, err := proc.IOCounters(); err == nil {
+			readBytes = int64(ioCounters.ReadBytes)
+			writeBytes = int64(ioCounters.WriteBytes)
+		}
+
 		processInfo = append(processInfo, Process{
 			PID:            int64(proc.Pid),
 			PPID:           int64(ppid),
 			Name:           name,
 			Status:         status,
 			CreatedTime:    createTime,
-			StoredTime:     time.Now().UnixMilli(),
+			StoredTime:     storedTime,
 			OS:             hostInfo.OS,
 			Platform:       hostInfo.Platform,
 			PlatformFamily: hostInfo.PlatformFamily,
 			CPUUsage:       cpuPercent,
 			MemoryUsage:    float64(memorypercent),
+			CPUTime:        cpuTime,
+			RSS:            rss,
+			ReadBytes:      readBytes,
+			WriteBytes:     writeBytes,
 		})
 	}
 
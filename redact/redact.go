@@ -0,0 +1,201 @@
+package redact
+
+import (
+	"math"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+const (
+	// Mask replaces the redacted secrets
+	Mask = "[REDACTED]"
+	// DefaultEntropyThreshold is the entropy in bits per character above which a string is considered a secret
+	DefaultEntropyThreshold = 4.0
+	// minEntropyLength is the minimum length of a string before its entropy is checked
+	minEntropyLength = 20
+)
+
+// Finding is a secret found in a command
+type Finding struct {
+	// Detector is the name of the detector that found the secret
+	Detector string
+	// Value is the secret that was masked
+	Value string
+}
+
+// detector masks the `secret` group of every match, or the whole match if the group doesn't exist
+type detector struct {
+	name    string
+	pattern *regexp.Regexp
+}
+
+// builtInDetectors detect the most common ways secrets end up on a command line
+var builtInDetectors = []detector{
+	{"private-key", regexp.MustCompile(`(?s)(?P<secret>-----BEGIN [A-Z ]*PRIVATE KEY-----.*?(?:-----END [A-Z ]*PRIVATE KEY-----|$))`)},
+	{"authorization-header", regexp.MustCompile(`(?i)(?:authorization|proxy-authorization)\s*:\s*(?:bearer|basic|token|digest)?\s*(?P<secret>[^\s"']+)`)},
+	{"basic-auth-flag", regexp.MustCompile(`(?:^|\s)(?:-u|--user)[\s=]+["']?[^\s:"']+:(?P<secret>[^\s"']+)`)},
+	{"url-credentials", regexp.MustCompile(`(?i)\b[a-z][a-z0-9+.-]*://[^\s:/@"']*:(?P<secret>[^\s@/"']+)@`)},
+	{"secret-flag", regexp.MustCompile(`(?i)(?:^|\s)--?[a-z0-9-]*(?:password|passwd|secret|token|api-?key|access-?key|private-?key|credentials?)(?:=|\s+)(?P<secret>"[^"]*"|'[^']*'|[^\s"'-][^\s"']*)`)},
+	{"secret-assignment", regexp.MustCompile(`(?i)\b[a-z0-9_.-]*(?:secret|token|passw(?:or)?d|api_?key|access_?key|private_?key|credentials?)[a-z0-9_.-]*\s*[=:]\s*(?P<secret>"[^"]*"|'[^']*'|[^\s"']+)`)},
+	{"github-token", regexp.MustCompile(`(?P<secret>\b(?:gh[pousr]_[A-Za-z0-9]{36,}|github_pat_[A-Za-z0-9_]{22,}))`)},
+	{"aws-access-key", regexp.MustCompile(`(?P<secret>\b(?:AKIA|ASIA)[0-9A-Z]{16}\b)`)},
+	{"slack-token", regexp.MustCompile(`(?P<secret>\bxox[abposr]-[A-Za-z0-9-]{10,})`)},
+	{"google-api-key", regexp.MustCompile(`(?P<secret>\bAIza[0-9A-Za-z_-]{35})`)},
+	{"api-key", regexp.MustCompile(`(?P<secret>\bsk-[A-Za-z0-9_-]{20,})`)},
+	{"jwt", regexp.MustCompile(`(?P<secret>\beyJ[A-Za-z0-9_-]{5,}\.[A-Za-z0-9_-]{5,}\.[A-Za-z0-9_-]{5,})`)},
+}
+
+// candidate splits a command into strings that are checked for high entropy
+var candidate = regexp.MustCompile(`[A-Za-z0-9+/_=.-]{20,}`)
+
+// Redactor masks secrets in command text
+type Redactor struct {
+	detectors        []detector
+	entropyThreshold float64
+}
+
+// New creates a redactor with the built-in detectors and the user patterns.
+// An entropy threshold of 0 disables the high-entropy detector.
+func New(patterns []string, entropyThreshold float64) (*Redactor, error) {
+	redactor := &Redactor{
+		detectors:        append([]detector{}, builtInDetectors...),
+		entropyThreshold: entropyThreshold,
+	}
+
+	for _, pattern := range patterns {
+		compiled, err := regexp.Compile(pattern)
+		if err != nil {
+			return nil, errors.Wrapf(err, "invalid redaction pattern %q", pattern)
+		}
+		redactor.detectors = append(redactor.detectors, detector{name: "custom", pattern: compiled})
+	}
+
+	return redactor, nil
+}
+
+// Default creates a redactor with only the built-in detectors
+func Default() *Redactor {
+	return &Redactor{
+		detectors:        builtInDetectors,
+		entropyThreshold: DefaultEntropyThreshold,
+	}
+}
+
+// Redact masks all secrets in the command and reports what was masked
+func (r *Redactor) Redact(command string) (string, []Finding) {
+	type span struct {
+		start, end int
+		detector   string
+	}
+
+	var spans []span
+	for _, d := range r.detectors {
+		group := d.pattern.SubexpIndex("secret")
+		for _, match := range d.pattern.FindAllStringSubmatchIndex(command, -1) {
+			start, end := match[0], match[1]
+			if group > 0 {
+				start, end = match[2*group], match[2*group+1]
+			}
+			if start < 0 || start == end {
+				continue
+			}
+			spans = append(spans, span{start, end, d.name})
+		}
+	}
+
+	if r.entropyThreshold > 0 {
+		for _, match := range candidate.FindAllStringIndex(command, -1) {
+			value := command[match[0]:match[1]]
+			if looksLikeSecret(value, r.entropyThreshold) {
+				spans = append(spans, span{match[0], match[1], "high-entropy"})
+			}
+		}
+	}
+
+	if len(spans) == 0 {
+		return command, nil
+	}
+
+	// Merge overlapping spans, so every secret is masked exactly once
+	sort.Slice(spans, func(i, j int) bool {
+		if spans[i].start == spans[j].start {
+			return spans[i].end > spans[j].end
+		}
+		return spans[i].start < spans[j].start
+	})
+
+	merged := []span{spans[0]}
+	for _, s := range spans[1:] {
+		last := &merged[len(merged)-1]
+		if s.start < last.end {
+			if s.end > last.end {
+				last.end = s.end
+			}
+			continue
+		}
+		merged = append(merged, s)
+	}
+
+	var builder strings.Builder
+	var findings []Finding
+	position := 0
+	for _, s := range merged {
+		builder.WriteString(command[position:s.start])
+		builder.WriteString(Mask)
+		findings = append(findings, Finding{Detector: s.detector, Value: command[s.start:s.end]})
+		position = s.end
+	}
+	builder.WriteString(command[position:])
+
+	return builder.String(), findings
+}
+
+// looksLikeSecret checks if the string is random enough to be a token, paths and words are ignored
+func looksLikeSecret(value string, threshold float64) bool {
+	if len(value) < minEntropyLength || strings.Contains(value, Mask) {
+		return false
+	}
+
+	// Paths, domains and file names contain separators secrets usually don't repeat
+	if strings.Count(value, "/") > 1 || strings.Count(value, ".") > 1 || strings.HasPrefix(value, "-") {
+		return false
+	}
+
+	// Generated tokens mix all character classes, while identifiers like pod names or hashes don't
+	var hasDigit, hasLower, hasUpper bool
+	for _, r := range value {
+		switch {
+		case r >= '0' && r <= '9':
+			hasDigit = true
+		case r >= 'a' && r <= 'z':
+			hasLower = true
+		case r >= 'A' && r <= 'Z':
+			hasUpper = true
+		}
+	}
+	if !hasDigit || !hasLower || !hasUpper {
+		return false
+	}
+
+	return entropy(value) >= threshold
+}
+
+// entropy calculates the Shannon entropy of the string in bits per character
+func entropy(value string) float64 {
+	frequencies := make(map[rune]float64)
+	for _, r := range value {
+		frequencies[r]++
+	}
+
+	var result float64
+	length := float64(len([]rune(value)))
+	for _, count := range frequencies {
+		p := count / length
+		result -= p * math.Log2(p)
+	}
+
+	return result
+}
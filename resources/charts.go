@@ -41,6 +41,7 @@ type DataPoint struct {
 	X           interface{} `json:"x"` // Can be a string or numeric type, depending on the chart type
 	Y           interface{} `json:"y"` // Can be a string or numeric type, depending on the chart type
 	ProcessName string      `json:"processName,omitempty"`
+	Command     string      `json:"command,omitempty"`
 	R           float64     `json:"r,omitempty"`
 }
 
@@ -51,6 +52,7 @@ type ChartOptions struct {
 	MaintainAspectRatio bool          `json:"maintainAspectRatio"`
 	Responsive          bool          `json:"responsive"`
 	AspectRatio         int           `json:"aspectRatio,omitempty"`
+	IndexAxis           string        `json:"indexAxis,omitempty"`
 }
 
 // ChartScales defines the axes of the chart, including their types and specific configurations.
@@ -413,3 +415,75 @@ func PrepareProcessesResourceUsageChartData(processes []*process.Process) (strin
 
 	return string(chartJSON), nil
 }
+
+// PrepareSessionTimelineChartData prepares the session timeline, a floating bar from the start to the end
+// of every command on the row of its terminal session, so commands running in parallel terminals line up.
+func PrepareSessionTimelineChartData(commands []collector.SessionCommand) (string, error) {
+
+	if len(commands) == 0 {
+		return "", nil
+	}
+
+	var labels []string
+	var ids []int
+	var data []DataPoint
+	seen := make(map[string]bool)
+
+	for _, cmd := range commands {
+		label := cmd.Session.Label()
+		if !seen[cmd.SessionID] {
+			seen[cmd.SessionID] = true
+			labels = append(labels, label)
+		}
+
+		ids = append(ids, int(cmd.CommandID))
+		data = append(data, DataPoint{
+			X:       []int64{cmd.StartTime, cmd.EndTime},
+			Y:       label,
+			Command: cmd.Command,
+		})
+	}
+
+	chartData := ChartData{
+		Type: "bar",
+		Data: ChartDataData{
+			Ids:    ids,
+			Labels: labels,
+			Datasets: []ChartDataDataset{{
+				Label:       "Commands",
+				Data:        data,
+				BorderWidth: 1,
+			}},
+		},
+		Options: ChartOptions{
+			IndexAxis: "y",
+			Scales: &ChartScales{
+				XAxes: ChartAxisOptions{
+					Type:     "linear",
+					Position: "bottom",
+					Title: &ChartAxisTitle{
+						Display: true,
+						Text:    "Time",
+					},
+				},
+				YAxes: ChartAxisOptions{
+					Type: "category",
+				},
+			},
+			Plugins: &ChartPlugins{
+				Legend: &ChartLegendOptions{
+					Display: false,
+				},
+			},
+			MaintainAspectRatio: false,
+			Responsive:          true,
+		},
+	}
+
+	chartJSON, err := json.Marshal(chartData)
+	if err != nil {
+		return "", err
+	}
+
+	return string(chartJSON), nil
+}
@@ -0,0 +1,27 @@
+package resources
+
+import (
+	"fmt"
+	"text/template"
+)
+
+// templateFuncs are the helper functions available in the views
+var templateFuncs = template.FuncMap{
+	"formatBytes": formatBytes,
+}
+
+// formatBytes formats a byte count in a human readable form
+func formatBytes(bytes int64) string {
+	const unit = 1024
+	if bytes < unit {
+		return fmt.Sprintf("%d B", bytes)
+	}
+
+	div, exp := int64(unit), 0
+	for n := bytes / unit; n >= unit; n /= unit {
+		div *= unit
+		exp++
+	}
+
+	return fmt.Sprintf("%.1f %ciB", float64(bytes)/float64(div), "KMGTPE"[exp])
+}
@@ -4,16 +4,107 @@ import (
 	"embed"
 	"encoding/json"
 	"net/http"
+	"net/url"
 	"strconv"
+	"strings"
 	"sync"
 	"text/template"
 	"time"
 
+	"github.com/devzero-inc/local-developer-analytics/category"
 	"github.com/devzero-inc/local-developer-analytics/collector"
 	"github.com/devzero-inc/local-developer-analytics/logging"
 	"github.com/devzero-inc/local-developer-analytics/process"
 )
 
+const (
+	// scopeCommand limits the overview to the processes spawned by the command
+	scopeCommand = "command"
+	// scopeAll shows every process sampled while the command was running
+	scopeAll = "all"
+)
+
+// modes are the command modes that can be selected on the dashboard
+var modes = []string{category.ModeBatch, category.ModeInteractive, category.ModeService}
+
+// modeOption is a mode checkbox on the dashboard
+type modeOption struct {
+	Name     string
+	Selected bool
+}
+
+// selectedModes reads the selected modes from the query, only batch commands are shown by default,
+// so the wall time of editors, shells and dev servers doesn't dominate the execution time
+func selectedModes(query url.Values) ([]string, []modeOption) {
+	requested := make(map[string]bool)
+	for _, mode := range query["mode"] {
+		requested[mode] = true
+	}
+
+	var selected []string
+	for _, mode := range modes {
+		if requested[mode] {
+			selected = append(selected, mode)
+		}
+	}
+
+	if len(selected) == 0 {
+		selected = []string{category.ModeBatch}
+		requested = map[string]bool{category.ModeBatch: true}
+	}
+
+	options := make([]modeOption, 0, len(modes))
+	for _, mode := range modes {
+		options = append(options, modeOption{Name: mode, Selected: requested[mode]})
+	}
+
+	return selected, options
+}
+
+// splitComponents checks if the execution time of compound command lines is attributed to their components,
+// e.g. `go generate && go test | tee out.log` counts for the go and tee categories instead of one go command
+func splitComponents(query url.Values) bool {
+	return query.Get("components") == "1"
+}
+
+// filterQuery encodes the dashboard filters for the links to other pages
+func filterQuery(modes []string, components bool) string {
+	query := url.Values{"mode": modes}
+	if components {
+		query.Set("components", "1")
+	}
+
+	return query.Encode()
+}
+
+// resultCount is the number of commands with a result on the dashboard
+type resultCount struct {
+	Name  string
+	Count int64
+}
+
+// resultCounts orders the counts by the known results, so interrupted and killed commands are reported
+// apart from failures, results unknown to this version are listed last
+func resultCounts(counts []collector.ResultCount) []resultCount {
+	byResult := make(map[string]int64, len(counts))
+	for _, count := range counts {
+		byResult[count.Result] = count.Count
+	}
+
+	results := make([]resultCount, 0, len(collector.Results))
+	for _, result := range collector.Results {
+		results = append(results, resultCount{Name: strings.ReplaceAll(result, "_", " "), Count: byResult[result]})
+		delete(byResult, result)
+	}
+	for _, count := range counts {
+		if _, unknown := byResult[count.Result]; unknown && count.Result != "" {
+			results = append(results, resultCount{Name: count.Result, Count: count.Count})
+		}
+	}
+
+	return results
+}
+
 // Embedding directory
 //
 //go:embed views/*
@@ -60,24 +151,35 @@ func homeHandler(w http.ResponseWriter, r *http.Request) {
 		}
 	}
 
+	selected, modeOptions := selectedModes(r.URL.Query())
+	components := splitComponents(r.URL.Query())
+
 	logging.Log.Debug().Msg("Creating waiting groups")
 
 	// Initialize wait group and channels for concurrent operations
 	var wg sync.WaitGroup
 	commandsChan := make(chan []*collector.Command, 1)
+	resultsChan := make(chan []collector.ResultCount, 1)
+	sessionsChan := make(chan []collector.SessionCommand, 1)
 	processesChan := make(chan []*process.Process, 1)
 	timeProcessesChan := make(chan map[int64][]*process.Process, 1)
 
 	logging.Log.Debug().Msg("Fetching data concurrently")
 
 	// Increment wait group count for each concurrent operation
-	wg.Add(3)
+	wg.Add(5)
 
 	// Fetch commands concurrently
 	go func() {
 		logging.Log.Debug().Msg("Fetching commands")
 		defer wg.Done()
-		commands, err := collector.GetAllCommandsForPeriod(startMillis, endMillis)
+		var commands []*collector.Command
+		var err error
+		if components {
+			commands, err = collector.GetAllComponentsForPeriod(startMillis, endMillis, selected...)
+		} else {
+			commands, err = collector.GetAllCommandsForPeriod(startMillis, endMillis, selected...)
+		}
 		logging.Log.Debug().Msg("Sending commands")
 		if err != nil {
 			logging.Log.Err(err).Msg("Failed to fetch commands")
@@ -88,6 +190,40 @@ func homeHandler(w http.ResponseWriter, r *http.Request) {
 		logging.Log.Debug().Msg("Fetched commands")
 	}()
 
+	// Fetch result counts concurrently
+	go func() {
+		logging.Log.Debug().Msg("Fetching result counts")
+		defer wg.Done()
+		counts, err := collector.GetResultCountsForPeriod(startMillis, endMillis, selected...)
+		if err != nil {
+			logging.Log.Err(err).Msg("Failed to fetch result counts")
+			resultsChan <- nil
+			return
+		}
+		if counts == nil {
+			counts = []collector.ResultCount{}
+		}
+		resultsChan <- counts
+		logging.Log.Debug().Msg("Fetched result counts")
+	}()
+
+	// Fetch session commands concurrently
+	go func() {
+		logging.Log.Debug().Msg("Fetching session commands")
+		defer wg.Done()
+		sessionCommands, err := collector.GetSessionCommandsForPeriod(startMillis, endMillis, selected...)
+		if err != nil {
+			logging.Log.Err(err).Msg("Failed to fetch session commands")
+			sessionsChan <- nil
+			return
+		}
+		if sessionCommands == nil {
+			sessionCommands = []collector.SessionCommand{}
+		}
+		sessionsChan <- sessionCommands
+		logging.Log.Debug().Msg("Fetched session commands")
+	}()
+
 	// Fetch processes concurrently
 	go func() {
 		logging.Log.Debug().Msg("Fetching processes")
@@ -123,16 +259,20 @@ func homeHandler(w http.ResponseWriter, r *http.Request) {
 	// Wait for all goroutines to finish
 	wg.Wait()
 	close(commandsChan)
+	close(resultsChan)
+	close(sessionsChan)
 	close(processesChan)
 	close(timeProcessesChan)
 
 	// Receive from channels
 	commands := <-commandsChan
+	results := <-resultsChan
+	sessionCommands := <-sessionsChan
 	processes := <-processesChan
 	timeProcesses := <-timeProcessesChan
 
 	// Check for errors after receiving data
-	if commands == nil || processes == nil || timeProcesses == nil {
+	if commands == nil || results == nil || sessionCommands == nil || processes == nil || timeProcesses == nil {
 		showError(w)
 		return
 	}
@@ -144,6 +284,11 @@ func homeHandler(w http.ResponseWriter, r *http.Request) {
 		showError(w)
 		return
 	}
+	sessionTimelineJson, err := PrepareSessionTimelineChartData(sessionCommands)
+	if err != nil {
+		showError(w)
+		return
+	}
 	processResourceJson, err := PrepareProcessesResourceUsageChartData(processes)
 	if err != nil {
 		showError(w)
@@ -178,11 +323,16 @@ func homeHandler(w http.ResponseWriter, r *http.Request) {
 
 	if err := tmpl.Execute(w, map[string]interface{}{
 		"CommandsJSON":         commandsJson,
+		"SessionTimelineJSON":  sessionTimelineJson,
 		"ProcessesJSON":        processResourceJson,
 		"CPUTimeSeriesJSON":    cpuResourceJson,
 		"MemoryTimeSeriesJSON": memoryResourceJson,
+		"Results":              resultCounts(results),
 		"StartTime":            start,
 		"EndTime":              end,
+		"Modes":                modeOptions,
+		"Components":           components,
+		"FilterQuery":          filterQuery(selected, components),
 	}); err != nil {
 		showError(w)
 	}
@@ -226,8 +376,18 @@ func commandHandler(w http.ResponseWriter, r *http.Request) {
 		}
 	}
 
-	commands, err := collector.GetAllCommandsForCategoryForPeriod(
-		label, startMillis, endMillis)
+	selected, modeOptions := selectedModes(queryParams)
+	components := splitComponents(queryParams)
+
+	var commands []collector.Command
+	var err error
+	if components {
+		commands, err = collector.GetAllComponentsForCategoryForPeriod(
+			label, startMillis, endMillis, selected...)
+	} else {
+		commands, err = collector.GetAllCommandsForCategoryForPeriod(
+			label, startMillis, endMillis, selected...)
+	}
 	if err != nil {
 		showError(w)
 		return
@@ -239,7 +399,7 @@ func commandHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	tmpl, err := template.ParseFS(templateFS, "views/command.html")
+	tmpl, err := template.New("command.html").Funcs(templateFuncs).ParseFS(templateFS, "views/command.html")
 	if err != nil {
 		showError(w)
 		return
@@ -260,6 +420,8 @@ func commandHandler(w http.ResponseWriter, r *http.Request) {
 		"StartTime":    start,
 		"EndTime":      end,
 		"Commands":     commands,
+		"Modes":        modeOptions,
+		"Components":   components,
 	}); err != nil {
 		showError(w)
 	}
@@ -287,12 +449,33 @@ func overviewHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	// Commands stored before the decomposition have no components
+	var components []collector.CommandComponent
+	if command.UUID != "" {
+		components, err = collector.GetCommandComponents(command.UUID)
+		if err != nil {
+			showError(w)
+			return
+		}
+	}
+
+	// Commands reported with a shell PID have their spawned processes linked, older ones can only show everything
+	canFilter := command.UUID != "" && command.ShellPID > 0
+	scope := queryParams.Get("scope")
+	if scope != scopeAll && scope != scopeCommand {
+		scope = scopeAll
+		if canFilter {
+			scope = scopeCommand
+		}
+	}
+	filterToCommand := canFilter && scope == scopeCommand
+
 	// Initialize wait group and channels for concurrent operations
 	var wg sync.WaitGroup
 	processesChan := make(chan []*process.Process, 1)
 	timeProcessesChan := make(chan map[int64][]*process.Process, 1)
 
-	logging.Log.Debug().Msgf("Start time: %d, End time: %d", command.StartTime, command.EndTime)
+	logging.Log.Debug().Msgf("Start time: %d, End time: %d, scope: %s", command.StartTime, command.EndTime, scope)
 
 	// Increment wait group count for each concurrent operation
 	wg.Add(2)
@@ -301,7 +484,13 @@ func overviewHandler(w http.ResponseWriter, r *http.Request) {
 	go func() {
 		logging.Log.Debug().Msg("Fetching overview processes")
 		defer wg.Done()
-		processes, err := process.GetAllProcessesForPeriod(command.StartTime, command.EndTime)
+		var processes []*process.Process
+		var err error
+		if filterToCommand {
+			processes, err = process.GetAllProcessesForCommand(command.UUID)
+		} else {
+			processes, err = process.GetAllProcessesForPeriod(command.StartTime, command.EndTime)
+		}
 		logging.Log.Debug().Msg("Sending processes")
 		if err != nil {
 			logging.Log.Err(err).Msg("Failed to fetch processes")
@@ -316,7 +505,13 @@ func overviewHandler(w http.ResponseWriter, r *http.Request) {
 	go func() {
 		logging.Log.Debug().Msg("Fetching overview time processes")
 		defer wg.Done()
-		timeProcesses, err := process.GetTopProcessesAndMetrics(command.StartTime, command.EndTime)
+		var timeProcesses map[int64][]*process.Process
+		var err error
+		if filterToCommand {
+			timeProcesses, err = process.GetTopProcessesAndMetricsForCommand(command.UUID)
+		} else {
+			timeProcesses, err = process.GetTopProcessesAndMetrics(command.StartTime, command.EndTime)
+		}
 		logging.Log.Debug().Msg("Sending time processes")
 		if err != nil {
 			logging.Log.Err(err).Msg("Failed to fetch time processes")
@@ -374,7 +569,7 @@ func overviewHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	tmpl, err := template.ParseFS(templateFS, "views/overview.html")
+	tmpl, err := template.New("overview.html").Funcs(templateFuncs).ParseFS(templateFS, "views/overview.html")
 	if err != nil {
 		logging.Log.Err(err).Msg("Failed to render template")
 		showError(w)
@@ -387,6 +582,11 @@ func overviewHandler(w http.ResponseWriter, r *http.Request) {
 		"MemoryTimeSeriesJSON": memoryResourceJson,
 		"Processes":            processes,
 		"ProcessJSON":          string(processesJson),
+		"Command":              command,
+		"Components":           components,
+		"CommandID":            command.Id,
+		"Scope":                scope,
+		"CanFilter":            canFilter,
 	}); err != nil {
 		logging.Log.Err(err).Msg("Failed to render template")
 		showError(w)
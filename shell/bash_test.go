@@ -0,0 +1,233 @@
+package shell
+
+import (
+	"bytes"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/devzero-inc/local-developer-analytics/config"
+
+	"github.com/creack/pty"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// hookEvent is an event sent by the shell hook to the collector script
+type hookEvent struct {
+	Phase   string
+	Command string
+	UUID    string
+	Status  string
+}
+
+// runBash drives an interactive bash through a pseudo-terminal with the hook sourced after the rc lines,
+// the input is typed line by line and the events the hook sent are returned
+func runBash(t *testing.T, rc string, input ...string) []hookEvent {
+	t.Helper()
+
+	if _, err := exec.LookPath("bash"); err != nil {
+		t.Skip("bash is not installed")
+	}
+
+	dir := t.TempDir()
+	logPath := filepath.Join(dir, "events")
+
+	// The collector script records the events, fields are separated by NUL bytes as commands span lines
+	collectorPath := filepath.Join(dir, "collector.sh")
+	require.NoError(t, os.WriteFile(collectorPath, []byte(`#!/bin/sh
+printf '%s\0%s\0%s\0%s\0' "$1" "$2" "$5" "$7" >> "`+logPath+`"
+`), 0755))
+
+	tmpl, err := templateFS.ReadFile(templateSources[config.Bash])
+	require.NoError(t, err)
+	hookPath := filepath.Join(dir, "bash.sh")
+	require.NoError(t, os.WriteFile(hookPath, bytes.ReplaceAll(tmpl, []byte("{{.EmitCommand}}"), []byte(collectorPath)), 0755))
+
+	rcPath := filepath.Join(dir, "bashrc")
+	require.NoError(t, os.WriteFile(rcPath, []byte("PS1='$ '\n"+rc+"\nsource "+hookPath+"\n"), 0644))
+
+	cmd := exec.Command("bash", "--noprofile", "--rcfile", rcPath, "-i")
+	cmd.Dir = dir
+	cmd.Env = []string{"HOME=" + dir, "HISTFILE=" + filepath.Join(dir, "history"), "PATH=" + os.Getenv("PATH"), "TERM=dumb", "USER=dev"}
+
+	terminal, err := pty.Start(cmd)
+	require.NoError(t, err)
+	defer terminal.Close()
+
+	output := make(chan string)
+	go func() {
+		var buffer bytes.Buffer
+		_, _ = io.Copy(&buffer, terminal)
+		output <- buffer.String()
+	}()
+
+	for _, line := range append(input, "exit") {
+		_, err := terminal.Write([]byte(line + "\r"))
+		require.NoError(t, err)
+		// Typed ahead lines are read by the command that is running, like `cat`, so every line waits its turn
+		time.Sleep(100 * time.Millisecond)
+	}
+
+	done := make(chan error)
+	go func() { done <- cmd.Wait() }()
+	select {
+	case <-done:
+	case <-time.After(10 * time.Second):
+		_ = cmd.Process.Kill()
+		t.Fatalf("bash didn't exit, output:\n%s", <-output)
+	}
+
+	raw, err := os.ReadFile(logPath)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	require.NoError(t, err)
+
+	fields := strings.Split(strings.TrimSuffix(string(raw), "\x00"), "\x00")
+	require.Zero(t, len(fields)%4, "incomplete events: %q", fields)
+
+	var events []hookEvent
+	for i := 0; i < len(fields); i += 4 {
+		events = append(events, hookEvent{Phase: fields[i], Command: fields[i+1], UUID: fields[i+2], Status: fields[i+3]})
+	}
+
+	// The exit command never ends
+	if last := len(events) - 1; last >= 0 && events[last].Phase == "start" && events[last].Command == "exit" {
+		events = events[:last]
+	}
+
+	return events
+}
+
+// assertPairs checks that every command line sent a start and an end event with the same UUID
+func assertPairs(t *testing.T, events []hookEvent, commands []string, statuses []string) {
+	t.Helper()
+
+	if !assert.Len(t, events, 2*len(commands), "events: %+v", events) {
+		return
+	}
+
+	for i, command := range commands {
+		start, end := events[2*i], events[2*i+1]
+		assert.Equal(t, "start", start.Phase)
+		assert.Equal(t, "end", end.Phase)
+		assert.Equal(t, command, start.Command)
+		assert.Equal(t, command, end.Command)
+		assert.NotEmpty(t, start.UUID)
+		assert.Equal(t, start.UUID, end.UUID)
+		assert.Equal(t, statuses[i], end.Status)
+	}
+}
+
+func TestBashHookSendsOneEventPairPerCommandLine(t *testing.T) {
+	events := runBash(t, "",
+		"echo a | cat | wc -l; true && false",
+		"f() { true; true; }",
+		"f",
+		"echo $(true; echo nested) > /dev/null",
+		"",
+		"# only a comment",
+		"for i in 1 2; do true; done",
+		"(true; exit 3)",
+		"true",
+	)
+
+	// Like with bash-preexec, lines that don't run a command in the shell itself, a function definition or
+	// a subshell, don't send anything, and don't leave a start event behind for the next line
+	assertPairs(t, events, []string{
+		"echo a | cat | wc -l; true && false",
+		"f",
+		"echo $(true; echo nested) > /dev/null",
+		"for i in 1 2; do true; done",
+		"true",
+	}, []string{"1", "0", "0", "0", "0"})
+}
+
+func TestBashHookKeepsExistingTrapAndPromptCommand(t *testing.T) {
+	dir := t.TempDir()
+	promptLog := filepath.Join(dir, "prompt")
+	trapLog := filepath.Join(dir, "trap")
+
+	events := runBash(t, strings.Join([]string{
+		`PROMPT_COMMAND='echo "$?" >> ` + promptLog + `'`,
+		`trap 'echo "it'\''s $BASH_COMMAND" >> ` + trapLog + `' DEBUG`,
+	}, "\n"), "false", "true")
+
+	assertPairs(t, events, []string{"false", "true"}, []string{"1", "0"})
+
+	// The existing PROMPT_COMMAND still sees the exit status of the command
+	prompt, err := os.ReadFile(promptLog)
+	require.NoError(t, err)
+	assert.Contains(t, string(prompt), "1\n0\n")
+
+	trap, err := os.ReadFile(trapLog)
+	require.NoError(t, err)
+	assert.Contains(t, string(trap), "it's false\n")
+}
+
+func TestBashHookWithPromptCommandArray(t *testing.T) {
+	promptLog := filepath.Join(t.TempDir(), "prompt")
+
+	events := runBash(t, `PROMPT_COMMAND=('echo "$?" >> `+promptLog+`' ':')`, "false", "", "true")
+
+	assertPairs(t, events, []string{"false", "true"}, []string{"1", "0"})
+
+	prompt, err := os.ReadFile(promptLog)
+	require.NoError(t, err)
+	assert.Contains(t, string(prompt), "1\n1\n0\n")
+}
+
+func TestBashHookIgnoresCompletions(t *testing.T) {
+	events := runBash(t, strings.Join([]string{
+		`_complete_deploy() { true; COMPREPLY=(production); }`,
+		`complete -F _complete_deploy deploy`,
+		`deploy() { return 0; }`,
+	}, "\n"), "deploy pro\t")
+
+	assertPairs(t, events, []string{"deploy production "}, []string{"0"})
+}
+
+func TestBashHookSourcedTwice(t *testing.T) {
+	events := runBash(t, `source "$HOME/bash.sh"`, "true")
+
+	assertPairs(t, events, []string{"true"}, []string{"0"})
+}
+
+func TestBashHookWithHistControl(t *testing.T) {
+	for _, histControl := range []string{"ignorespace", "ignoreboth", "ignoredups", "erasedups"} {
+		t.Run(histControl, func(t *testing.T) {
+			historyPath := filepath.Join(t.TempDir(), "history")
+
+			events := runBash(t, "HISTCONTROL="+histControl+"\nHISTFILE="+historyPath,
+				"echo one", " echo secret | cat", "echo two | cat", "echo two | cat", " echo two | cat")
+
+			// Lines that are not added to the history are not mistaken for the previous entry
+			assertPairs(t, events,
+				[]string{"echo one", "echo secret | cat", "echo two | cat", "echo two | cat", "echo two | cat"},
+				[]string{"0", "0", "0", "0", "0"})
+
+			// The space-prefixed lines are still kept out of the history
+			history, err := os.ReadFile(historyPath)
+			require.NoError(t, err)
+			if strings.Contains(histControl, "ignore") && histControl != "ignoredups" {
+				assert.NotContains(t, string(history), "secret")
+			}
+		})
+	}
+}
+
+func TestBashHookWithoutHistory(t *testing.T) {
+	// Without a history entry only the first simple command of the line is known
+	events := runBash(t, "set +o history", "echo one", "echo two | cat")
+
+	assertPairs(t, events, []string{"echo one", "echo two"}, []string{"0", "0"})
+
+	events = runBash(t, "HISTIGNORE='echo secret*'", "echo one | cat", "echo secret", "echo one")
+
+	assertPairs(t, events, []string{"echo one | cat", "echo secret", "echo one"}, []string{"0", "0", "0"})
+}
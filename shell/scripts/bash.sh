@@ -1,30 +1,192 @@
-generate_uuid() {
-    echo "$(date +%s)-$$-$RANDOM"
+# LDA bash integration, it sends a single start and end event for every command line entered at the prompt.
+#
+# The DEBUG trap runs before every simple command, including the ones of pipelines, functions, subshells,
+# completions and PROMPT_COMMAND, so like bash-preexec the start event is only sent for the first command
+# after the prompt was drawn, and the end event only when a start event was sent.
+
+# Interactive shells only, and only once when the file is sourced again
+[[ $- == *i* ]] || return 0
+[[ -n "${__lda_imported:-}" ]] && return 0
+__lda_imported=1
+
+# Start time of the shell, with the shell PID it identifies the terminal session of the commands
+export LDA_SHELL_START=$(date +%s)
+
+# Terminal of the shell, it tells interactive commands apart from long-running services
+if [[ -t 0 ]]; then
+  export LDA_TTY=$(tty)
+else
+  unset LDA_TTY
+fi
+
+# __lda_interactive_mode is set when the prompt is drawn, the next command is the one entered by the user
+__lda_interactive_mode=""
+__lda_command=""
+__lda_uuid=""
+# __lda_history_entry is the last history entry when the prompt was drawn, __lda_ignorespace is set when the
+# space-prefixed command lines are removed from the history by the hook instead of HISTCONTROL
+__lda_history_entry=""
+__lda_ignorespace=""
+
+__lda_generate_uuid() {
+  echo "$(date +%s)-$$-$RANDOM"
 }
 
-preexec_invoke_exec() {
-    # Avoid running preexec_invoke_exec for PROMPT_COMMAND
-    if [[ "$BASH_COMMAND" != "$PROMPT_COMMAND" ]]; then
-        export UUID=$(generate_uuid)
-        export LAST_COMMAND="$BASH_COMMAND"
-        # Send a start execution message
-        {{.CommandScriptPath}} "start" "$LAST_COMMAND" "$PWD" "$USER" "$UUID"
+# __lda_trim stores the text without the leading and trailing whitespace in the variable
+__lda_trim() {
+  local text="$2"
+  text="${text#"${text%%[![:space:]]*}"}"
+  text="${text%"${text##*[![:space:]]}"}"
+  printf -v "$1" '%s' "$text"
+}
+
+# __lda_in_prompt_command tells if the command is one of the commands of PROMPT_COMMAND
+__lda_in_prompt_command() {
+  local prompt_commands IFS=$'\n;'
+  read -rd '' -a prompt_commands <<< "${PROMPT_COMMAND[*]:-}"
+
+  local trimmed_command trimmed_prompt_command prompt_command
+  __lda_trim trimmed_command "$1"
+  for prompt_command in "${prompt_commands[@]}"; do
+    __lda_trim trimmed_prompt_command "$prompt_command"
+    if [[ "$trimmed_prompt_command" == "$trimmed_command" ]]; then
+      return 0
     fi
+  done
+
+  return 1
 }
-trap 'preexec_invoke_exec' DEBUG
 
-precmd_invoke_cmd() {
-    local exit_status=$?
-    local result="success"
+# __lda_preexec runs before every simple command
+__lda_preexec() {
+  # Completions and subshells aren't entered by the user
+  if [[ -z "$__lda_interactive_mode" || -n "${COMP_LINE:-}" || "${BASH_SUBSHELL:-0}" -gt 0 ]]; then
+    return
+  fi
+  __lda_interactive_mode=""
+
+  # PROMPT_COMMAND runs after an empty line, the last history entry is then the previous command
+  if __lda_in_prompt_command "$BASH_COMMAND"; then
+    return
+  fi
+
+  # The whole command line is the last history entry, BASH_COMMAND is only its first simple command
+  local history_entry this_command
+  history_entry=$(HISTTIMEFORMAT= builtin history 1)
+  this_command="${history_entry#*[[:digit:]][* ] }"
+  this_command="${this_command#"${this_command%%[![:space:]]*}"}"
+
+  if [[ "$history_entry" != "$__lda_history_entry" ]]; then
+    # The space-prefixed command line was only added to the history to be read here
+    if [[ -n "$__lda_ignorespace" && "${history_entry#*[[:digit:]][* ] }" == [[:space:]]* ]]; then
+      local history_number="${history_entry#"${history_entry%%[![:space:]]*}"}"
+      builtin history -d "${history_number%%[!0-9]*}"
+    fi
+  elif [[ "$this_command" != "$BASH_COMMAND" && "$this_command" != "$BASH_COMMAND"[[:space:]\;\&\|]* ]]; then
+    # Nothing was added to the history, because history is disabled, the line matches HISTIGNORE or it is a
+    # duplicate with ignoredups. The previous entry is only the command line when it starts with BASH_COMMAND.
+    this_command="$BASH_COMMAND"
+  fi
+
+  __lda_start "$this_command"
+}
+
+# __lda_start sends the start event of the command line
+__lda_start() {
+  __lda_command="$1"
+  __lda_uuid=$(__lda_generate_uuid)
+  # Send a start execution message
+  {{.EmitCommand}} "start" "$__lda_command" "$PWD" "$USER" "$__lda_uuid" "" "" "$$"
+}
+
+# __lda_precmd runs first in PROMPT_COMMAND, it keeps the exit status for the rest of PROMPT_COMMAND
+__lda_precmd() {
+  local exit_status=$?
 
+  # Nothing was started for an empty line or a line that only contained a comment
+  if [[ -n "$__lda_uuid" ]]; then
+    local result="success"
     if [[ $exit_status -ne 0 ]]; then
-        result="failure"
+      result="failure"
     fi
 
     # Send an end execution message with the result and exit status
-    {{.CommandScriptPath}} "end" "$LAST_COMMAND" "$PWD" "$USER" "$UUID" "$result" "$exit_status"
+    {{.EmitCommand}} "end" "$__lda_command" "$PWD" "$USER" "$__lda_uuid" "$result" "$exit_status" "$$"
+    __lda_uuid=""
+  fi
+
+  return $exit_status
+}
+
+# __lda_prompt runs last in PROMPT_COMMAND, the next command is entered by the user
+__lda_prompt() {
+  local exit_status=$?
+  __lda_interactive_mode="on"
+  __lda_history_entry=$(HISTTIMEFORMAT= builtin history 1)
+  return $exit_status
+}
+
+# __lda_install hooks into the DEBUG trap and PROMPT_COMMAND at the first prompt. Sourced files don't see the
+# DEBUG trap of the shell, so it is read by the installation string in PROMPT_COMMAND, and the rc files can
+# still set their own trap and PROMPT_COMMAND after sourcing this file.
+__lda_install() {
+  if [[ "$(declare -p PROMPT_COMMAND 2>/dev/null)" == "declare -a"* ]]; then
+    local prompt_command prompt_commands=()
+    for prompt_command in "${PROMPT_COMMAND[@]}"; do
+      if [[ "$prompt_command" != "$__lda_install_string" ]]; then
+        prompt_commands+=("$prompt_command")
+      fi
+    done
+    PROMPT_COMMAND=("${prompt_commands[@]}")
+  else
+    PROMPT_COMMAND="${PROMPT_COMMAND//$'\n'$__lda_install_string/}"
+    PROMPT_COMMAND="${PROMPT_COMMAND//$__lda_install_string/}"
+  fi
+
+  if [[ -n "${bash_preexec_imported:-}${__bp_imported:-}" ]]; then
+    # bash-preexec owns the DEBUG trap and PROMPT_COMMAND, the hooks are registered with it instead
+    preexec_functions+=(__lda_bp_preexec)
+    precmd_functions=(__lda_precmd "${precmd_functions[@]}")
+  else
+    # Like bash-preexec, space-prefixed command lines are added to the history so they can be read, the hook
+    # removes them again before they run
+    if [[ "${HISTCONTROL:-}" == *ignorespace* || "${HISTCONTROL:-}" == *ignoreboth* ]]; then
+      __lda_ignorespace="on"
+      HISTCONTROL="${HISTCONTROL//ignorespace/}"
+      HISTCONTROL="${HISTCONTROL//ignoreboth/ignoredups}"
+    fi
+
+    # An existing DEBUG trap keeps running after the hook, "$_" is passed last so the trap doesn't change it
+    local previous_trap="${__lda_trap_string#trap -- \'}"
+    previous_trap="${previous_trap%\' DEBUG}"
+    previous_trap="${previous_trap//\'\\\'\'/\'}"
+    if [[ -n "$previous_trap" ]]; then
+      trap '__lda_preexec "$_"; '"$previous_trap" DEBUG
+    else
+      trap '__lda_preexec "$_"' DEBUG
+    fi
+
+    # The existing commands of PROMPT_COMMAND run between the hooks, so they see the exit status of the command
+    if [[ "$(declare -p PROMPT_COMMAND 2>/dev/null)" == "declare -a"* ]]; then
+      PROMPT_COMMAND=(__lda_precmd "${PROMPT_COMMAND[@]}" __lda_prompt)
+    else
+      PROMPT_COMMAND="__lda_precmd${PROMPT_COMMAND:+$'\n'$PROMPT_COMMAND}"$'\n'"__lda_prompt"
+    fi
+  fi
+  unset __lda_trap_string __lda_install_string
+
+  # The installation ran at a prompt, the next command is entered by the user
+  __lda_prompt
+}
+
+# __lda_bp_preexec is called by bash-preexec once per command line, it already read the command line
+__lda_bp_preexec() {
+  __lda_start "$1"
 }
 
-# Update PROMPT_COMMAND to invoke precmd_invoke_cmd
-# Append precmd_invoke_cmd to PROMPT_COMMAND to run after each command
-PROMPT_COMMAND="${PROMPT_COMMAND:+$PROMPT_COMMAND; }precmd_invoke_cmd"
+__lda_install_string=$'__lda_trap_string="$(trap -p DEBUG)"\n__lda_install'
+if [[ "$(declare -p PROMPT_COMMAND 2>/dev/null)" == "declare -a"* ]]; then
+  PROMPT_COMMAND+=("$__lda_install_string")
+else
+  PROMPT_COMMAND="${PROMPT_COMMAND:+$PROMPT_COMMAND$'\n'}$__lda_install_string"
+fi
@@ -1,5 +1,6 @@
 #!/bin/sh
-# POSIX-compliant script to log command details to a Unix socket
+# Sends a command event to the collector, kept for integrations calling it directly,
+# the shell hooks run `lda emit` themselves to avoid the extra fork on every prompt.
 
 # Parameters:
 # $1 - Execution phase (start/end)
@@ -9,56 +10,9 @@
 # $5 - Unique identifier
 # $6 - Command result (success/failure)
 # $7 - Exit status
-
-# UNIX socket path
-SOCKET_PATH="{{.SocketPath}}"
-
-# Function to check command existence
-command_exists() {
-  command -v "$1" >/dev/null 2>&1
-}
-
-# Check if nc supports the -U flag
-nc_supports_U() {
-    error_message=$(echo | nc -U "" 2>&1)
-    if echo "$error_message" | grep -q 'invalid option'; then
-        return 1
-    else
-        return 0
-    fi
-}
-
-# Python function to communicate via socket
-send_via_python() {
-  python -c "import socket; import sys; \
-    s = socket.socket(socket.AF_UNIX, socket.SOCK_STREAM); \
-    s.connect('$SOCKET_PATH'); \
-    s.sendall('$LOG_MESSAGE'.encode('utf-8')); \
-    s.close()" 2>/dev/null
-}
-
-# Perl function to communicate via socket
-send_via_perl() {
-  perl -e "use IO::Socket::UNIX; \
-    \$sock = IO::Socket::UNIX->new(Type => SOCK_STREAM, Peer => '$SOCKET_PATH'); \
-    print \$sock '$LOG_MESSAGE'; \
-    close(\$sock);" 2>/dev/null
-}
-
-# Construct the log message including result and exit status
-LOG_MESSAGE="$1|$2|$3|$4|$5|$6|$7"
-
-# Send the log message to the Go application via UNIX socket
-if command_exists nc && nc_supports_U; then
-  echo "$LOG_MESSAGE" | nc -U "$SOCKET_PATH"
-elif command_exists socat; then
-  echo "$LOG_MESSAGE" | socat - UNIX-CONNECT:"$SOCKET_PATH"
-elif command_exists python; then
-  send_via_python
-elif command_exists perl; then
-  send_via_perl
-else
-  # Output error if no suitable command is available
-  echo "Neither nc, socat, python or perl are available on this system." >&2
-  exit 1
-fi
+# $8 - PID of the shell that executed the command
+#
+# The development context (virtualenv, nvm, kubeconfig and direnv), the terminal of the shell ($LDA_TTY),
+# the start time of the shell ($LDA_SHELL_START) and the terminal session ($TMUX, $TMUX_PANE, $STY,
+# $SSH_CONNECTION and $TERM_PROGRAM) are read from the environment exported by the shell.
+exec {{.EmitCommand}} "$@"
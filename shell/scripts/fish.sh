@@ -1,3 +1,13 @@
+# Start time of the shell, with the shell PID it identifies the terminal session of the commands
+set -gx LDA_SHELL_START (date +%s)
+
+# Terminal of the shell, it tells interactive commands apart from long-running services
+if isatty stdin
+    set -gx LDA_TTY (tty)
+else
+    set -e LDA_TTY
+end
+
 function generate_uuid
     echo (date +%s)"-"(echo %self)"-"(random)
 end
@@ -6,7 +16,7 @@ function fish_preexec --on-event fish_preexec
     set -gx LAST_COMMAND $argv[1]
     set -gx UUID (generate_uuid)
     # Send a start execution message
-    {{.CommandScriptPath}} "start" "$LAST_COMMAND" "$PWD" "$USER" "$UUID"
+    {{.EmitCommand}} "start" "$LAST_COMMAND" "$PWD" "$USER" "$UUID" "" "" %self
 end
 
 function fish_postexec --on-event fish_postexec
@@ -18,5 +28,5 @@ function fish_postexec --on-event fish_postexec
     end
     
     # Send an end execution message with result and exit status
-    {{.CommandScriptPath}} "end" "$LAST_COMMAND" "$PWD" "$USER" "$UUID" $result $exit_status
+    {{.EmitCommand}} "end" "$LAST_COMMAND" "$PWD" "$USER" "$UUID" $result $exit_status %self
 end
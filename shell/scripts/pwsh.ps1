@@ -0,0 +1,58 @@
+# LDA PowerShell integration, it needs pwsh 7.3 or newer, older versions drop the empty arguments of the events
+# when running native commands.
+
+# Start time of the shell, with the shell PID it identifies the terminal session of the commands
+$env:LDA_SHELL_START = [DateTimeOffset]::UtcNow.ToUnixTimeSeconds()
+
+# Terminal of the shell, it tells interactive commands apart from long-running services
+if (-not [Console]::IsInputRedirected) {
+    $env:LDA_TTY = (tty 2>$null)
+} else {
+    Remove-Item Env:LDA_TTY -ErrorAction SilentlyContinue
+}
+
+$global:__lda_command = $null
+$global:__lda_uuid = $null
+
+# PSReadLine calls the history handler once for every command line entered at the prompt, before it is
+# executed, an existing handler still decides if the line is added to the history
+$global:__lda_history_handler = (Get-PSReadLineOption).AddToHistoryHandler
+Set-PSReadLineOption -AddToHistoryHandler {
+    param([string]$line)
+
+    if (-not [string]::IsNullOrWhiteSpace($line)) {
+        $global:__lda_command = $line
+        $global:__lda_uuid = "$([DateTimeOffset]::UtcNow.ToUnixTimeSeconds())-$PID-$(Get-Random -Maximum 32768)"
+        # Send a start execution message
+        {{.EmitCommand}} "start" $line $PWD.ProviderPath $env:USER $global:__lda_uuid "" "" $PID | Out-Null
+    }
+
+    if ($global:__lda_history_handler) {
+        return $global:__lda_history_handler.Invoke($line)
+    }
+    return $true
+}
+
+# The prompt function runs after every command, the existing prompt is drawn after the end event
+$global:__lda_prompt = $function:prompt
+function global:prompt {
+    $success = $?
+    $lastExitCode = $global:LASTEXITCODE
+
+    if ($global:__lda_uuid) {
+        # Failed cmdlets don't set an exit code
+        $exitStatus = if ($success) { 0 } elseif ($lastExitCode) { $lastExitCode } else { 1 }
+        $result = if ($exitStatus -eq 0) { "success" } else { "failure" }
+
+        # Send an end execution message with result and exit status, the reply of the collector goes to the terminal
+        $reply = {{.EmitCommand}} "end" $global:__lda_command $PWD.ProviderPath $env:USER $global:__lda_uuid $result "$exitStatus" $PID
+        if ($reply) {
+            [Console]::Write(($reply -join "`n"))
+        }
+
+        $global:__lda_uuid = $null
+    }
+
+    $global:LASTEXITCODE = $lastExitCode
+    & $global:__lda_prompt
+}
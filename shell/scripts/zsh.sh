@@ -1,3 +1,13 @@
+# Start time of the shell, with the shell PID it identifies the terminal session of the commands
+export LDA_SHELL_START=$(date +%s)
+
+# Terminal of the shell, it tells interactive commands apart from long-running services
+if [[ -t 0 ]]; then
+  export LDA_TTY=$(tty)
+else
+  unset LDA_TTY
+fi
+
 generate_uuid() {
   echo "$(date +%s)-$$-$RANDOM"
 }
@@ -6,7 +16,7 @@ preexec() {
   export LAST_COMMAND=$1
   UUID=$(generate_uuid)
   # Send a start execution message
-  {{.CommandScriptPath}} "start" "$LAST_COMMAND" "$PWD" "$USER" "$UUID"
+  {{.EmitCommand}} "start" "$LAST_COMMAND" "$PWD" "$USER" "$UUID" "" "" "$$"
 }
 
 precmd() {
@@ -18,5 +28,5 @@ precmd() {
   fi
   
   # Send an end execution message with result and exit status
-  {{.CommandScriptPath}} "end" "$LAST_COMMAND" "$PWD" "$USER" "$UUID" "$result" "$exit_status"
+  {{.EmitCommand}} "end" "$LAST_COMMAND" "$PWD" "$USER" "$UUID" "$result" "$exit_status" "$$"
 }
@@ -10,7 +10,6 @@ import (
 	"strings"
 	"text/template"
 
-	"github.com/devzero-inc/local-developer-analytics/collector"
 	"github.com/devzero-inc/local-developer-analytics/config"
 	"github.com/devzero-inc/local-developer-analytics/util"
 
@@ -21,23 +20,29 @@ import (
 const (
 	ldaScript       = "lda.sh"
 	execPermissions = 0755
-	CollectorName   = "collector.sh"
-	CollectorScript = "scripts/collector.sh"
+	// configPermissions are the permissions of the shell config files created for the user
+	configPermissions = 0644
+	CollectorName     = "collector.sh"
+	CollectorScript   = "scripts/collector.sh"
 )
 
 var (
 	// TODO: Seems like we could combine this somehow so we don't have to repeat the same information
 
 	templateSources = map[config.ShellType]string{
-		config.Zsh:  "scripts/zsh.sh",
-		config.Bash: "scripts/bash.sh",
-		config.Fish: "scripts/fish.sh",
+		config.Zsh:        "scripts/zsh.sh",
+		config.Bash:       "scripts/bash.sh",
+		config.Fish:       "scripts/fish.sh",
+		config.Nushell:    "scripts/nushell.nu",
+		config.PowerShell: "scripts/pwsh.ps1",
 	}
 
 	shellScriptName = map[config.ShellType]string{
-		config.Zsh:  "zsh.sh",
-		config.Bash: "bash.sh",
-		config.Fish: "fish.sh",
+		config.Zsh:        "zsh.sh",
+		config.Bash:       "bash.sh",
+		config.Fish:       "fish.sh",
+		config.Nushell:    "nushell.nu",
+		config.PowerShell: "pwsh.ps1",
 	}
 
 	sourceScripts = map[config.ShellType]string{
@@ -56,6 +61,17 @@ fi`,
 if test -f "$HOME/.lda/fish.sh"
     source "$HOME/.lda/fish.sh"
 end`,
+		// Nushell reads the sourced files when parsing the config, so the check is a constant and null is sourced
+		// when the file is missing, otherwise the config fails to parse once LDA is removed
+		config.Nushell: `
+# LDA shell source
+const lda_source = if ($nu.home-path | path join ".lda" "nushell.nu" | path exists) { $nu.home-path | path join ".lda" "nushell.nu" } else { null }
+source $lda_source`,
+		config.PowerShell: `
+# LDA shell source
+if (Test-Path "$HOME/.lda/pwsh.ps1") {
+    . "$HOME/.lda/pwsh.ps1"
+}`,
 	}
 
 	// Embedding scripts directory
@@ -72,6 +88,11 @@ type Config struct {
 	SudoExecUser  *user.User
 	LdaDir        string
 	HomeDir       string
+	SocketPath    string
+	// ExePath is the path to the lda binary the shell hooks run
+	ExePath string
+	// Os decides where the shells that follow the platform conventions keep their config
+	Os config.OSType
 }
 
 // Shell is the shell configuration
@@ -104,7 +125,7 @@ func (s *Shell) InstallShellConfiguration() error {
 
 	var cmdContent bytes.Buffer
 	if err := cmdTmpl.Execute(&cmdContent, map[string]interface{}{
-		"SocketPath": collector.SocketPath,
+		"EmitCommand": emitCommand(config.Sh, s.Config.ExePath, s.Config.SocketPath),
 	}); err != nil {
 		s.logger.Err(err).Msg("Failed to execute cmd template")
 		return err
@@ -129,7 +150,7 @@ func (s *Shell) InstallShellConfiguration() error {
 
 	var shellContent bytes.Buffer
 	if err := shellTmpl.Execute(&shellContent, map[string]interface{}{
-		"CommandScriptPath": collectorFilePath,
+		"EmitCommand": s.EmitCommand(),
 	}); err != nil {
 		s.logger.Err(err).Msg("Failed to execute shell template")
 		return err
@@ -145,6 +166,41 @@ func (s *Shell) InstallShellConfiguration() error {
 	return nil
 }
 
+// EmitCommand returns the command the hooks of the shell run to send their events to the collector
+func (s *Shell) EmitCommand() string {
+	return emitCommand(s.Config.ShellType, s.Config.ExePath, s.Config.SocketPath)
+}
+
+// emitCommand returns the emit command with the paths quoted for the shell
+func emitCommand(shellType config.ShellType, exePath string, socketPath string) string {
+	switch shellType {
+	case config.Nushell:
+		// Quoted external commands are run with the caret
+		return fmt.Sprintf("^%s emit --socket %s", doubleQuote(exePath), doubleQuote(socketPath))
+	case config.PowerShell:
+		// Quoted commands are run with the call operator
+		return fmt.Sprintf("& %s emit --socket %s", powerShellQuote(exePath), powerShellQuote(socketPath))
+	default:
+		return fmt.Sprintf("%s emit --socket %s", quote(exePath), quote(socketPath))
+	}
+}
+
+// quote single quotes the value for the POSIX shells and fish, single quotes in the value are closed, escaped
+// and reopened
+func quote(value string) string {
+	return "'" + strings.ReplaceAll(value, "'", `'\''`) + "'"
+}
+
+// doubleQuote double quotes the value for Nushell, which has no escapes in single quoted strings
+func doubleQuote(value string) string {
+	return `"` + strings.NewReplacer(`\`, `\\`, `"`, `\"`).Replace(value) + `"`
+}
+
+// powerShellQuote single quotes the value for PowerShell, single quotes in the value are doubled
+func powerShellQuote(value string) string {
+	return "'" + strings.ReplaceAll(value, "'", "''") + "'"
+}
+
 // DeleteShellConfiguration removes the shell configuration
 func (s *Shell) DeleteShellConfiguration() error {
 
@@ -178,6 +234,14 @@ func (s *Shell) InjectShellSource(nonInteractive bool) error {
 		shellConfigFile = filepath.Join(s.Config.HomeDir, ".bashrc")
 	case config.Fish:
 		shellConfigFile = filepath.Join(s.Config.HomeDir, ".config/fish/config.fish")
+	case config.Nushell:
+		if s.Config.Os == config.MacOS {
+			shellConfigFile = filepath.Join(s.Config.HomeDir, "Library/Application Support/nushell/config.nu")
+		} else {
+			shellConfigFile = filepath.Join(s.Config.HomeDir, ".config/nushell/config.nu")
+		}
+	case config.PowerShell:
+		shellConfigFile = filepath.Join(s.Config.HomeDir, ".config/powershell/Microsoft.PowerShell_profile.ps1")
 	default:
 		s.logger.Error().Msg("Unsupported shell")
 		return fmt.Errorf("unsupported shell")
@@ -200,6 +264,18 @@ func (s *Shell) InjectShellSource(nonInteractive bool) error {
 	}
 
 	s.logger.Debug().Msgf("Shell config file: %s", shellConfigFile)
+	// pwsh has no profile and nu no config until they are created, missing files are created
+	if !util.FileExists(shellConfigFile) {
+		if err := util.CreateDirAndChown(filepath.Dir(shellConfigFile), execPermissions, s.Config.SudoExecUser); err != nil {
+			s.logger.Err(err).Msg("Failed to create the shell config directory")
+			return err
+		}
+		if err := util.WriteFileAndChown(shellConfigFile, nil, configPermissions, s.Config.SudoExecUser); err != nil {
+			s.logger.Err(err).Msg("Failed to create the shell config file")
+			return err
+		}
+	}
+
 	// Check if the script is already present to avoid duplicates
 	if !util.IsScriptPresent(shellConfigFile, "LDA shell source") {
 		if err := util.AppendToFile(shellConfigFile, source); err != nil {
@@ -0,0 +1,99 @@
+package shell
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/devzero-inc/local-developer-analytics/config"
+	"github.com/devzero-inc/local-developer-analytics/util"
+
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEmitCommandQuotesThePaths(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "it's a dir")
+	require.NoError(t, os.Mkdir(dir, 0755))
+
+	exePath := filepath.Join(dir, "lda")
+	require.NoError(t, os.WriteFile(exePath, []byte("#!/bin/sh\nprintf '%s\\n' \"$@\"\n"), 0755))
+
+	shl, err := NewShell(&Config{ExePath: exePath, SocketPath: filepath.Join(dir, "lda.sock")}, zerolog.Nop())
+	require.NoError(t, err)
+
+	output, err := exec.Command("sh", "-c", shl.EmitCommand()+" start").Output()
+	require.NoError(t, err)
+	assert.Equal(t, "emit\n--socket\n"+filepath.Join(dir, "lda.sock")+"\nstart\n", string(output))
+}
+
+func TestEmitCommandIsQuotedForTheShell(t *testing.T) {
+	exePath := `/opt/it's "lda"/lda`
+	socketPath := "/run/user/1000/lda.socket"
+
+	assert.Equal(t, `'/opt/it'\''s "lda"/lda' emit --socket '/run/user/1000/lda.socket'`, emitCommand(config.Zsh, exePath, socketPath))
+	assert.Equal(t, `^"/opt/it's \"lda\"/lda" emit --socket "/run/user/1000/lda.socket"`, emitCommand(config.Nushell, exePath, socketPath))
+	assert.Equal(t, `& '/opt/it''s "lda"/lda' emit --socket '/run/user/1000/lda.socket'`, emitCommand(config.PowerShell, exePath, socketPath))
+}
+
+func TestShellConfigurationForEveryShell(t *testing.T) {
+	util.SetupFS()
+
+	tests := []struct {
+		shellType  config.ShellType
+		os         config.OSType
+		script     string
+		configFile string
+	}{
+		{config.Bash, config.Linux, "bash.sh", ".bashrc"},
+		{config.Zsh, config.MacOS, "zsh.sh", ".zshrc"},
+		{config.Fish, config.Linux, "fish.sh", ".config/fish/config.fish"},
+		{config.Nushell, config.Linux, "nushell.nu", ".config/nushell/config.nu"},
+		{config.Nushell, config.MacOS, "nushell.nu", "Library/Application Support/nushell/config.nu"},
+		{config.PowerShell, config.Linux, "pwsh.ps1", ".config/powershell/Microsoft.PowerShell_profile.ps1"},
+	}
+
+	for _, tt := range tests {
+		homeDir := t.TempDir()
+		ldaDir := filepath.Join(homeDir, ".lda")
+		require.NoError(t, os.Mkdir(ldaDir, 0755))
+
+		shl, err := NewShell(&Config{
+			ShellType:  tt.shellType,
+			HomeDir:    homeDir,
+			LdaDir:     ldaDir,
+			SocketPath: filepath.Join(ldaDir, "lda.socket"),
+			ExePath:    "/usr/local/bin/lda",
+			Os:         tt.os,
+		}, zerolog.Nop())
+		require.NoError(t, err)
+
+		require.NoError(t, shl.InstallShellConfiguration(), tt.script)
+
+		hook, err := os.ReadFile(filepath.Join(ldaDir, tt.script))
+		require.NoError(t, err, tt.script)
+		assert.Contains(t, string(hook), shl.EmitCommand()+` "start"`, tt.script)
+		assert.Contains(t, string(hook), shl.EmitCommand()+` "end"`, tt.script)
+
+		// The source is injected once, the config file is created when it doesn't exist yet
+		require.NoError(t, shl.InjectShellSource(true), tt.script)
+		require.NoError(t, shl.InjectShellSource(true), tt.script)
+
+		rc, err := os.ReadFile(filepath.Join(homeDir, tt.configFile))
+		require.NoError(t, err, tt.script)
+		assert.Equal(t, 1, strings.Count(string(rc), "LDA shell source"), tt.script)
+		assert.Contains(t, string(rc), tt.script, tt.script)
+	}
+}
+
+func TestNushellSourceIsOptional(t *testing.T) {
+	// nu parses the sourced file with the config, a missing file is only skipped when null is sourced
+	source := sourceScripts[config.Nushell]
+	assert.Contains(t, source, `path join ".lda" "nushell.nu" | path exists`)
+	assert.Contains(t, source, "else { null }")
+	assert.Contains(t, source, "source $lda_source")
+	assert.True(t, strings.HasPrefix(strings.TrimSpace(strings.Split(source, "\n")[2]), "const "), "the check has to be a parse time constant")
+}
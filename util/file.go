@@ -126,19 +126,14 @@ func AppendToFile(filePath, content string) error {
 	return nil
 }
 
-// GetRepoNameFromConfig reads the .git/config file and extracts the repository name
-func GetRepoNameFromConfig(path string) (string, error) {
+// GetRepoNameFromConfig reads the config file of the git directory and extracts the repository name, the git
+// directory of worktrees is their common directory (see GitInfo.CommonDir)
+func GetRepoNameFromConfig(gitDir string) (string, error) {
 
-	gitPath := filepath.Join(path, ".git")
-	info, err := os.Stat(gitPath)
-	if err != nil && info != nil && !info.IsDir() {
-		return "", fmt.Errorf("could not find .git directory: %w", err)
-	}
-
-	configPath := filepath.Join(gitPath, "config")
+	configPath := filepath.Join(gitDir, "config")
 	file, err := Fs.Open(configPath)
 	if err != nil {
-		return "", fmt.Errorf("could not open .git/config: %w", err)
+		return "", fmt.Errorf("could not open git config: %w", err)
 	}
 	defer file.Close()
 
@@ -155,11 +150,11 @@ func GetRepoNameFromConfig(path string) (string, error) {
 	}
 
 	if err := scanner.Err(); err != nil {
-		return "", fmt.Errorf("error reading .git/config: %w", err)
+		return "", fmt.Errorf("error reading git config: %w", err)
 	}
 
 	if url == "" {
-		return "", fmt.Errorf("no origin URL found in .git/config")
+		return "", fmt.Errorf("no origin URL found in git config")
 	}
 
 	url = strings.TrimSuffix(url, ".git") // Remove .git suffix if present
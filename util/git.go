@@ -0,0 +1,168 @@
+package util
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// gitStatusTimeout is the maximum time we wait for git to report the dirty state of a repository
+const gitStatusTimeout = 2 * time.Second
+
+// GitInfo is the state of the git repository a command was executed in
+type GitInfo struct {
+	// Root is the working tree root of the repository
+	Root string
+	// CommonDir is the git directory with the config of the repository, worktrees share it with the main
+	// working tree
+	CommonDir string
+	// Branch is the checked out branch, empty when HEAD is detached
+	Branch string
+	// Commit is the commit HEAD points to
+	Commit string
+	// Dirty is true when tracked files have uncommitted changes
+	Dirty bool
+}
+
+// GetGitInfo finds the git repository containing the path and reads its branch, commit and dirty state
+func GetGitInfo(path string) (*GitInfo, error) {
+	root, gitDir, err := findGitDir(path)
+	if err != nil {
+		return nil, err
+	}
+
+	info := &GitInfo{Root: root, CommonDir: commonDir(gitDir)}
+
+	head, err := readFirstLine(filepath.Join(gitDir, "HEAD"))
+	if err != nil {
+		return nil, fmt.Errorf("could not read HEAD: %w", err)
+	}
+
+	if ref, ok := strings.CutPrefix(head, "ref: "); ok {
+		info.Branch = strings.TrimPrefix(ref, "refs/heads/")
+		info.Commit = resolveRef(gitDir, ref)
+	} else {
+		info.Commit = head
+	}
+
+	info.Dirty = isDirty(root)
+
+	return info, nil
+}
+
+// findGitDir walks up from the path looking for a .git directory or a .git file used by worktrees and submodules
+func findGitDir(path string) (string, string, error) {
+	dir := filepath.Clean(path)
+	for {
+		gitPath := filepath.Join(dir, ".git")
+		if info, err := Fs.Stat(gitPath); err == nil {
+			if info.IsDir() {
+				return dir, gitPath, nil
+			}
+
+			line, err := readFirstLine(gitPath)
+			if err != nil {
+				return "", "", fmt.Errorf("could not read .git file: %w", err)
+			}
+			gitDir, ok := strings.CutPrefix(line, "gitdir: ")
+			if !ok {
+				return "", "", fmt.Errorf("invalid .git file: %s", gitPath)
+			}
+			if !filepath.IsAbs(gitDir) {
+				gitDir = filepath.Join(dir, gitDir)
+			}
+			return dir, gitDir, nil
+		}
+
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return "", "", fmt.Errorf("could not find git repository for %s", path)
+		}
+		dir = parent
+	}
+}
+
+// resolveRef resolves a reference to a commit, looking at loose refs first and packed refs second
+func resolveRef(gitDir string, ref string) string {
+	dirs := []string{gitDir}
+	// worktrees keep the shared refs in the common directory
+	if common := commonDir(gitDir); common != gitDir {
+		dirs = append(dirs, common)
+	}
+
+	for _, dir := range dirs {
+		if commit, err := readFirstLine(filepath.Join(dir, filepath.FromSlash(ref))); err == nil {
+			return commit
+		}
+	}
+
+	for _, dir := range dirs {
+		file, err := Fs.Open(filepath.Join(dir, "packed-refs"))
+		if err != nil {
+			continue
+		}
+
+		scanner := bufio.NewScanner(file)
+		for scanner.Scan() {
+			fields := strings.Fields(scanner.Text())
+			if len(fields) == 2 && fields[1] == ref {
+				file.Close()
+				return fields[0]
+			}
+		}
+		file.Close()
+	}
+
+	// unborn branch, there are no commits yet
+	return ""
+}
+
+// commonDir returns the git directory shared by the worktrees, the git directory itself for the main working
+// tree and submodules
+func commonDir(gitDir string) string {
+	common, err := readFirstLine(filepath.Join(gitDir, "commondir"))
+	if err != nil {
+		return gitDir
+	}
+	if !filepath.IsAbs(common) {
+		common = filepath.Join(gitDir, common)
+	}
+
+	return filepath.Clean(common)
+}
+
+// isDirty checks if tracked files in the working tree have uncommitted changes
+func isDirty(root string) bool {
+	ctx, cancel := context.WithTimeout(context.Background(), gitStatusTimeout)
+	defer cancel()
+
+	out, err := exec.CommandContext(ctx, "git", "-C", root, "status", "--porcelain", "--untracked-files=no").Output()
+	if err != nil {
+		return false
+	}
+
+	return len(strings.TrimSpace(string(out))) > 0
+}
+
+// readFirstLine reads the first line of a file without surrounding whitespace
+func readFirstLine(filePath string) (string, error) {
+	file, err := Fs.Open(filePath)
+	if err != nil {
+		return "", err
+	}
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+	if !scanner.Scan() {
+		if err := scanner.Err(); err != nil {
+			return "", err
+		}
+		return "", fmt.Errorf("%s is empty", filePath)
+	}
+
+	return strings.TrimSpace(scanner.Text()), nil
+}
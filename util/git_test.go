@@ -0,0 +1,114 @@
+package util
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func runGit(t *testing.T, dir string, args ...string) string {
+	t.Helper()
+
+	cmd := exec.Command("git", append([]string{"-C", dir}, args...)...)
+	cmd.Env = append(os.Environ(),
+		"GIT_AUTHOR_NAME=lda", "GIT_AUTHOR_EMAIL=lda@devzero.io",
+		"GIT_COMMITTER_NAME=lda", "GIT_COMMITTER_EMAIL=lda@devzero.io",
+	)
+	out, err := cmd.Output()
+	if err != nil {
+		t.Fatalf("git %v failed: %s", args, err)
+	}
+
+	return string(out)
+}
+
+func TestGetGitInfo(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git is not installed")
+	}
+
+	SetupFS()
+
+	root := t.TempDir()
+	runGit(t, root, "init", "-q", "-b", "feature/context")
+
+	file := filepath.Join(root, "README.md")
+	assert.NoError(t, os.WriteFile(file, []byte("lda"), 0644))
+	runGit(t, root, "add", ".")
+	runGit(t, root, "commit", "-q", "-m", "initial")
+	commit := runGit(t, root, "rev-parse", "HEAD")
+
+	subDir := filepath.Join(root, "cmd", "lda")
+	assert.NoError(t, os.MkdirAll(subDir, 0755))
+
+	info, err := GetGitInfo(subDir)
+	assert.NoError(t, err)
+	assert.Equal(t, root, info.Root)
+	assert.Equal(t, "feature/context", info.Branch)
+	assert.Equal(t, commit[:40], info.Commit)
+	assert.False(t, info.Dirty)
+
+	assert.NoError(t, os.WriteFile(file, []byte("lda changed"), 0644))
+	info, err = GetGitInfo(root)
+	assert.NoError(t, err)
+	assert.True(t, info.Dirty)
+
+	runGit(t, root, "pack-refs", "--all")
+	runGit(t, root, "checkout", "-q", "--detach")
+	info, err = GetGitInfo(root)
+	assert.NoError(t, err)
+	assert.Empty(t, info.Branch)
+	assert.Equal(t, commit[:40], info.Commit)
+
+	_, err = GetGitInfo(t.TempDir())
+	assert.Error(t, err)
+}
+
+func TestRepoNameInWorktreesAndSubmodules(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git is not installed")
+	}
+
+	SetupFS()
+
+	newRepo := func(url string) string {
+		root := t.TempDir()
+		runGit(t, root, "init", "-q", "-b", "main")
+		runGit(t, root, "remote", "add", "origin", url)
+		assert.NoError(t, os.WriteFile(filepath.Join(root, "README.md"), []byte("lda"), 0644))
+		runGit(t, root, "add", ".")
+		runGit(t, root, "commit", "-q", "-m", "initial")
+		return root
+	}
+
+	root := newRepo("https://github.com/devzero-inc/local-developer-analytics.git")
+
+	// Worktrees and submodules have a .git file pointing to their git directory
+	worktree := filepath.Join(t.TempDir(), "worktree")
+	runGit(t, root, "worktree", "add", "-q", "-b", "feature", worktree)
+
+	// The submodule is cloned from the local repository, its origin is the path of the repository
+	library := newRepo("https://github.com/devzero-inc/library.git")
+	runGit(t, root, "-c", "protocol.file.allow=always", "submodule", "add", "-q", library, "library")
+
+	for dir, name := range map[string]string{
+		root:                           "local-developer-analytics",
+		worktree:                       "local-developer-analytics",
+		filepath.Join(root, "library"): filepath.Base(library),
+	} {
+		info, err := GetGitInfo(dir)
+		if !assert.NoError(t, err, dir) {
+			continue
+		}
+		repo, err := GetRepoNameFromConfig(info.CommonDir)
+		assert.NoError(t, err, dir)
+		assert.Equal(t, name, repo, dir)
+	}
+
+	info, err := GetGitInfo(worktree)
+	assert.NoError(t, err)
+	assert.Equal(t, "feature", info.Branch)
+}